@@ -0,0 +1,67 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// graphqlAlias returns the alias of the field selected by the graphql tag of f, e.g. "first" for
+// `graphql:"first: repository(name: \"a\")"`, and whether it has one.
+func graphqlAlias(f reflect.StructField) (string, bool) {
+	tag, ok := f.Tag.Lookup("graphql")
+	if !ok || strings.HasPrefix(strings.TrimSpace(tag), "...") {
+		return "", false
+	}
+	selection := tagSelection(tag)
+	key, field := splitAlias(selection)
+	if field == strings.TrimSpace(selection) {
+		return "", false
+	}
+	return key, true
+}
+
+// aliasTypes maps types to whether they have aliased fields, as reported by hasAliases.
+var aliasTypes sync.Map // map[reflect.Type]bool
+
+// hasAliases reports whether t has fields with an aliased graphql tag and no json tag, directly or in nested types,
+// which encoding/json can't decode as it doesn't know about the alias.
+func hasAliases(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if found, ok := aliasTypes.Load(t); ok {
+		return found.(bool)
+	}
+	found := findAliases(t, map[reflect.Type]bool{})
+	aliasTypes.Store(t, found)
+	return found
+}
+
+// findAliases reports whether t has aliased fields, skipping the types in seen.
+func findAliases(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return findAliases(t.Elem(), seen)
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+			return false
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if _, hasName := jsonName(f); !hasName {
+				if _, ok := graphqlAlias(f); ok {
+					return true
+				}
+			}
+			if findAliases(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_Query_aliases(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"first": {"name": "a", "stars": 1}, "second": {"name": "b", "stars": 2}, "viewer": {"me": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	type repository struct {
+		Name  graphql.String
+		Stars graphql.Int `json:"stars"`
+	}
+	var q struct {
+		First  repository `graphql:"first: repository(name: \"a\")"`
+		Second repository `graphql:"second:repository(name: \"b\")"`
+		Viewer struct {
+			Login graphql.String `graphql:"me: login"`
+		}
+	}
+	document, err := graphql.ConstructQuery(&q, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{first: repository(name: "a"){name,stars},second:repository(name: "b"){name,stars},viewer{me: login}}`; document != want {
+		t.Errorf("got query: %v, want: %v", document, want)
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: document, Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if q.First.Name != "a" || q.First.Stars != 1 || q.Second.Name != "b" || q.Second.Stars != 2 {
+		t.Errorf("got repositories: %+v and %+v, want a and b", q.First, q.Second)
+	}
+	if q.Viewer.Login != "gopher" {
+		t.Errorf("got login: %q, want: %q", q.Viewer.Login, "gopher")
+	}
+}
@@ -0,0 +1,100 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TokenSource supplies the bearer token sent in the Authorization header of every request.
+// Implementations are responsible for caching and refreshing the token,
+// and must be safe for concurrent use.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same token.
+type StaticToken string
+
+// Token implements TokenSource.
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// credentialsFunc authenticates an outgoing request, typically by setting its Authorization header.
+type credentialsFunc func(ctx context.Context, req *http.Request) error
+
+// WithTokenSource returns a copy of the client that authenticates requests
+// with a bearer token obtained from ts.
+//
+// The token source replaces any other credentials configured on the client.
+func (c *Client) WithTokenSource(ts TokenSource) *Client {
+	c2 := c.clone()
+	c2.credentials = bearerCredentials(ts)
+	return c2
+}
+
+// WithBasicAuth returns a copy of the client that authenticates requests with HTTP basic authentication.
+// The credentials are sent UTF-8 and base64 encoded, as specified by RFC 7617,
+// so they may contain any character except for a colon in the username.
+//
+// Basic authentication replaces any TokenSource configured on the client, and vice versa.
+func (c *Client) WithBasicAuth(username, password string) *Client {
+	c2 := c.clone()
+	c2.credentials = basicCredentials(username, password)
+	return c2
+}
+
+// WithRequestTokenSource authenticates a single request with a bearer token obtained from ts,
+// instead of the client's credentials. It allows acting as a specific principal for one operation.
+func WithRequestTokenSource(ts TokenSource) RequestOption {
+	return func(opts *requestOptions) {
+		opts.credentials = bearerCredentials(ts)
+	}
+}
+
+// WithRequestToken authenticates a single request with the given bearer token,
+// instead of the client's credentials.
+func WithRequestToken(token string) RequestOption {
+	return WithRequestTokenSource(StaticToken(token))
+}
+
+// WithRequestBasicAuth authenticates a single request with HTTP basic authentication,
+// instead of the client's credentials.
+func WithRequestBasicAuth(username, password string) RequestOption {
+	return func(opts *requestOptions) {
+		opts.credentials = basicCredentials(username, password)
+	}
+}
+
+func bearerCredentials(ts TokenSource) credentialsFunc {
+	return func(ctx context.Context, req *http.Request) error {
+		token, err := ts.Token(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+func basicCredentials(username, password string) credentialsFunc {
+	return func(ctx context.Context, req *http.Request) error {
+		if strings.Contains(username, ":") {
+			return fmt.Errorf("basic auth username must not contain a colon")
+		}
+		req.SetBasicAuth(username, password)
+		return nil
+	}
+}
+
+// WithAuthRefresh returns a copy of the client that, when the server rejects a request
+// with 401 Unauthorized or 403 Forbidden, calls refresh and retries the request exactly once.
+// refresh typically renews the token returned by the client's TokenSource.
+// If refresh returns an error, the request fails with it.
+func (c *Client) WithAuthRefresh(refresh func(ctx context.Context) error) *Client {
+	c2 := c.clone()
+	c2.authRefresh = refresh
+	return c2
+}
@@ -0,0 +1,258 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Batch collects several GraphQL operations and sends them to the server in a single HTTP request,
+// as a JSON array of operations, in the style of Apollo query batching.
+// The server must support batching, and respond with an array of results in the same order.
+//
+// A Batch must not be used concurrently, nor reused after Send.
+type Batch struct {
+	client *Client
+	items  []*BatchResult
+}
+
+// BatchResult is the outcome of an operation in a Batch, available once the batch is sent.
+type BatchResult struct {
+	op      operationType
+	request ManualRequest
+	payload requestPayload
+	err     error
+}
+
+// Err returns the error of the operation, or nil if it succeeded.
+// If the response had GraphQL errors, they are returned, and the data that was returned is populated.
+func (r *BatchResult) Err() error {
+	return r.err
+}
+
+// NewBatch returns an empty batch of operations to send with the client.
+func (c *Client) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// Query adds a query to the batch. Its response is decoded into request.Result when the batch is sent.
+func (b *Batch) Query(request ManualRequest, variables map[string]interface{}) *BatchResult {
+	return b.add(queryOperation, request, variables)
+}
+
+// Mutate adds a mutation to the batch. Its response is decoded into request.Result when the batch is sent.
+func (b *Batch) Mutate(request ManualRequest, variables map[string]interface{}) *BatchResult {
+	return b.add(mutationOperation, request, variables)
+}
+
+func (b *Batch) add(op operationType, request ManualRequest, variables map[string]interface{}) *BatchResult {
+	r := &BatchResult{op: op, request: request}
+	in, uploads, _, err := b.client.newPayload(op, request, variables, "", nil)
+	switch {
+	case err != nil:
+		r.err = err
+	case len(uploads) > 0:
+		r.err = fmt.Errorf("file uploads cannot be batched")
+	default:
+		r.payload = in
+	}
+	b.items = append(b.items, r)
+	return r
+}
+
+// Send sends the operations of the batch in a single HTTP request,
+// and decodes their responses. The request-specific headers of all operations are merged.
+//
+// The returned error reports a failure to send the batch, in which case it is also the error of every operation.
+// The errors of individual operations are reported by their BatchResult.
+func (b *Batch) Send(ctx context.Context, options ...RequestOption) error {
+	opts := newRequestOptions(options)
+	if opts.proxySet {
+		ctx = context.WithValue(ctx, proxyOverrideKey{}, opts.proxyURL)
+	}
+
+	var items []*BatchResult
+	op := queryOperation
+	header := make(http.Header)
+	for _, item := range b.items {
+		if item.err != nil {
+			continue
+		}
+		items = append(items, item)
+		if item.op == mutationOperation {
+			op = mutationOperation
+		}
+		for key, values := range item.request.Headers {
+			header[key] = values
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	payloads := make([]requestPayload, len(items))
+	for i, item := range items {
+		payloads[i] = item.payload
+	}
+
+	results, err := b.client.sendBatch(ctx, op, payloads, header, opts)
+	if err != nil {
+		for _, item := range items {
+			item.err = err
+		}
+		return err
+	}
+	for i, item := range items {
+		item.err = b.client.decodeResponse(bytes.NewReader(results[i]), item.request.Result)
+	}
+	return nil
+}
+
+// sendBatch sends payloads as a single batched request, and returns the response to each of them.
+func (c *Client) sendBatch(ctx context.Context, op operationType, payloads []requestPayload, header http.Header, opts requestOptions) ([]json.RawMessage, error) {
+	data, err := c.marshal(payloads)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(ctx, op, requestBody{contentType: "application/json", data: data}, header, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.statusError(resp)
+	}
+	var results []json.RawMessage
+	recorder := bodyRecorder{r: resp.Body, buf: getBuffer()}
+	defer putBuffer(recorder.buf)
+	if err := c.decode(recorder, &results); err != nil {
+		return nil, decodeError(fmt.Errorf("decoding batch response: %w", err), recorder.body())
+	}
+	if len(results) != len(payloads) {
+		return nil, fmt.Errorf("batch response has %d results, want %d", len(results), len(payloads))
+	}
+	return results, nil
+}
+
+// BatchConfig configures automatic batching, see WithBatching.
+type BatchConfig struct {
+	// Window is how long operations are collected before being sent.
+	//
+	// Defaults to 10 milliseconds.
+	Window time.Duration
+
+	// MaxSize is the maximum number of operations in a batch. A full batch is sent right away.
+	//
+	// Defaults to 10.
+	MaxSize int
+}
+
+// WithBatching returns a copy of the client that batches the operations sent concurrently:
+// operations sent within config.Window of each other are sent in a single HTTP request, see Batch.
+// Each caller still receives its own response and errors.
+//
+// Operations with request-specific headers, headers from the context, request options other than those
+// about decoding the response, such as WithExtensions, file uploads, or sent with QueryStream are sent on their own, as are operations of different clients derived from the returned one.
+// Batches are sent independently of the callers' contexts, so that a canceled caller doesn't fail the others.
+func (c *Client) WithBatching(config BatchConfig) *Client {
+	if config.Window <= 0 {
+		config.Window = 10 * time.Millisecond
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = 10
+	}
+	c2 := c.clone()
+	c2.batcher = &batcher{config: config, pending: make(map[*Client][]*batchCall)}
+	return c2
+}
+
+// batcher collects operations sent concurrently into batches, per client.
+type batcher struct {
+	config BatchConfig
+
+	mu      sync.Mutex
+	pending map[*Client][]*batchCall
+}
+
+// batchCall is an operation waiting in a batcher.
+type batchCall struct {
+	op      operationType
+	payload requestPayload
+
+	done   chan struct{}
+	result json.RawMessage
+	err    error
+}
+
+// canBatch reports whether an operation may be batched.
+func canBatch(ctx context.Context, header http.Header, uploads []fileUpload, opts requestOptions) bool {
+	_, hasContextHeaders := ctx.Value(contextHeadersKey{}).(http.Header)
+	return len(header) == 0 && !hasContextHeaders && len(uploads) == 0 && !opts.affectRequest() && !opts.stream
+}
+
+// do adds an operation of client c to its pending batch, and waits for its response.
+func (b *batcher) do(ctx context.Context, c *Client, op operationType, payload requestPayload) (*http.Response, error) {
+	call := &batchCall{op: op, payload: payload, done: make(chan struct{})}
+
+	b.mu.Lock()
+	calls := append(b.pending[c], call)
+	switch {
+	case len(calls) >= b.config.MaxSize:
+		delete(b.pending, c)
+		go b.send(c, calls)
+	case len(calls) == 1:
+		b.pending[c] = calls
+		time.AfterFunc(b.config.Window, func() { b.flush(c, call) })
+	default:
+		b.pending[c] = calls
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	return syntheticResponse(call.result, nil), nil
+}
+
+// flush sends the pending batch of c whose first operation is first,
+// unless it was already sent because it was full.
+func (b *batcher) flush(c *Client, first *batchCall) {
+	b.mu.Lock()
+	calls := b.pending[c]
+	if len(calls) == 0 || calls[0] != first {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.pending, c)
+	b.mu.Unlock()
+	b.send(c, calls)
+}
+
+// send sends a batch of operations of c, and hands the responses to the waiting callers.
+func (b *batcher) send(c *Client, calls []*batchCall) {
+	op := queryOperation
+	payloads := make([]requestPayload, len(calls))
+	for i, call := range calls {
+		payloads[i] = call.payload
+		if call.op == mutationOperation {
+			op = mutationOperation
+		}
+	}
+	results, err := c.sendBatch(context.Background(), op, payloads, nil, requestOptions{})
+	for i, call := range calls {
+		if err != nil {
+			call.err = err
+		} else {
+			call.result = results[i]
+		}
+		close(call.done)
+	}
+}
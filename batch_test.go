@@ -0,0 +1,105 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+// batchHandler answers batched requests, responding to each operation with the login given by its variables,
+// or with an error if there is none.
+func batchHandler(t *testing.T, requests *[]int) http.Handler {
+	var mu sync.Mutex
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var in []struct {
+			Query     string
+			Variables map[string]string
+		}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			t.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		*requests = append(*requests, len(in))
+		mu.Unlock()
+		out := make([]interface{}, len(in))
+		for i, op := range in {
+			if login, ok := op.Variables["login"]; ok {
+				out[i] = map[string]interface{}{"data": map[string]interface{}{"user": map[string]string{"login": login}}}
+			} else {
+				out[i] = map[string]interface{}{"errors": []map[string]string{{"message": "missing login"}}}
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	})
+	return mux
+}
+
+type userQuery struct {
+	User struct {
+		Login graphql.String
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var requests []int
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: batchHandler(t, &requests)}})
+
+	var q1, q2 userQuery
+	batch := client.NewBatch()
+	r1 := batch.Query(graphql.ManualRequest{Query: "query($login:String!){user(login:$login){login}}", Result: &q1}, map[string]interface{}{"login": "gopher"})
+	r2 := batch.Query(graphql.ManualRequest{Query: "{user{login}}", Result: &q2}, nil)
+	if err := batch.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || requests[0] != 2 {
+		t.Errorf("got requests with %v operations, want one with 2", requests)
+	}
+	if r1.Err() != nil {
+		t.Errorf("got error: %v, want: nil", r1.Err())
+	}
+	if q1.User.Login != "gopher" {
+		t.Errorf("got login: %q, want: %q", q1.User.Login, "gopher")
+	}
+	if got, want := r2.Err(), "Message: missing login, Locations: []"; got == nil || got.Error() != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+}
+
+func TestClient_WithBatching(t *testing.T) {
+	var requests []int
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: batchHandler(t, &requests)}}).
+		WithBatching(graphql.BatchConfig{Window: 50 * time.Millisecond, MaxSize: 3})
+
+	logins := []string{"a", "b", "c", "d", "e"}
+	var wg sync.WaitGroup
+	for _, login := range logins {
+		wg.Add(1)
+		go func(login string) {
+			defer wg.Done()
+			var q userQuery
+			err := client.Query(context.Background(), graphql.ManualRequest{
+				Query:  "query($login:String!){user(login:$login){login}}",
+				Result: &q,
+			}, map[string]interface{}{"login": login})
+			if err != nil {
+				t.Error(err)
+			}
+			if string(q.User.Login) != login {
+				t.Errorf("got login: %q, want: %q", q.User.Login, login)
+			}
+		}(login)
+	}
+	wg.Wait()
+	if len(requests) != 2 || requests[0]+requests[1] != 5 || (requests[0] != 3 && requests[1] != 3) {
+		t.Errorf("got requests with %v operations, want a full batch of 3 and one of 2", requests)
+	}
+}
@@ -0,0 +1,66 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which buffers are not returned to bufferPool,
+// so that an occasional large response doesn't stay allocated.
+const maxPooledBufferSize = 1 << 20
+
+// bufferPool holds the buffers used to encode requests and read responses,
+// reused across requests to reduce allocations at high request rates.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from bufferPool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns buf to bufferPool. buf must not be used afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// encodeJSON encodes v as JSON followed by a newline, as json.Encoder does,
+// into a pooled buffer. The returned slice is a copy of exactly the right size.
+func encodeJSON(v interface{}) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+// readAll reads r until EOF, as ioutil.ReadAll does, into a pooled buffer.
+// The returned slice is a copy of exactly the right size.
+func readAll(r io.Reader) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+// decodeJSON reads r until EOF into a pooled buffer, and decodes it into v.
+// v must not retain the decoded bytes, which json.Unmarshal never does, even for json.RawMessage.
+func decodeJSON(r io.Reader, v interface{}) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return err
+	}
+	return json.Unmarshal(buf.Bytes(), v)
+}
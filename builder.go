@@ -0,0 +1,344 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Selection is a field, or an inline fragment, of an operation built at runtime, for selection sets known only
+// at runtime, e.g. user-configurable columns, rather than from structs. It is created with Field or On,
+// and configured with its chainable methods:
+//
+//	graphql.Field("repository").Arg("owner", graphql.Var("owner")).Arg("name", "go").Select(
+//		graphql.Field("name"),
+//		graphql.Field("issues").Alias("open").Arg("states", []graphql.Enum{"OPEN"}).Select(graphql.Field("totalCount")),
+//	)
+type Selection struct {
+	alias      string
+	name       string
+	condition  string
+	args       []argument
+	directives []string
+	fields     []*Selection
+}
+
+// argument is an argument of a Selection.
+type argument struct {
+	name  string
+	value interface{}
+}
+
+// Var is a variable used as the value of an argument, e.g. Var("owner") for $owner.
+type Var string
+
+// Enum is an enum value used as the value of an argument, e.g. Enum("OPEN"), written without quotes.
+type Enum string
+
+// Field returns a selection of the field name.
+func Field(name string) *Selection {
+	return &Selection{name: name}
+}
+
+// On returns an inline fragment selecting fields on the type typename, e.g. On("User", Field("login")).
+func On(typename string, fields ...*Selection) *Selection {
+	return &Selection{condition: typename, fields: fields}
+}
+
+// Alias sets the alias of the field, its key in the data of responses.
+func (s *Selection) Alias(alias string) *Selection {
+	s.alias = alias
+	return s
+}
+
+// Arg adds the argument name with value to the field. value is written as a GraphQL literal:
+// Var and Enum values as such, strings, numbers and booleans as in JSON, nil as null,
+// slices as lists, and maps and structs as input objects, with the keys of their JSON encoding.
+// Values that can't be encoded as JSON are written as null.
+func (s *Selection) Arg(name string, value interface{}) *Selection {
+	s.args = append(s.args, argument{name: name, value: value})
+	return s
+}
+
+// Directive adds a directive, e.g. "@include(if: $withDetails)", to the field or inline fragment.
+func (s *Selection) Directive(directive string) *Selection {
+	s.directives = append(s.directives, directive)
+	return s
+}
+
+// Select adds fields to the selection set of the field or inline fragment.
+func (s *Selection) Select(fields ...*Selection) *Selection {
+	s.fields = append(s.fields, fields...)
+	return s
+}
+
+// Operation is an operation built at runtime from selections, see Selection.
+// It is executed with Client.QueryOperation, e.g. with a map result:
+//
+//	op := graphql.NewQuery(graphql.Field("viewer").Select(graphql.Field("login"))).Named("GetViewer")
+//	var result map[string]interface{}
+//	err := client.QueryOperation(ctx, op, &result, nil)
+type Operation struct {
+	keyword string
+	name    string
+	fields  []*Selection
+}
+
+// NewQuery returns a query operation selecting fields.
+func NewQuery(fields ...*Selection) *Operation {
+	return &Operation{keyword: "query", fields: fields}
+}
+
+// NewMutation returns a mutation operation selecting fields.
+func NewMutation(fields ...*Selection) *Operation {
+	return &Operation{keyword: "mutation", fields: fields}
+}
+
+// Named sets the name of the operation.
+func (o *Operation) Named(name string) *Operation {
+	o.name = name
+	return o
+}
+
+// Select adds fields to the selection set of the operation.
+func (o *Operation) Select(fields ...*Selection) *Operation {
+	o.fields = append(o.fields, fields...)
+	return o
+}
+
+// Document returns the document of the operation, declaring variables with their types inferred from their
+// Go types, as for operations constructed from structs by the ConstructQuery function.
+// Use Client.Build for the variable types of the client.
+func (o *Operation) Document(variables map[string]interface{}) string {
+	return o.document(queryArguments(variables))
+}
+
+// Build returns the document of op, declaring variables as for operations constructed from structs
+// by the client: with the types of WithVariableTypes and RegisterScalar, or inferred from their Go types,
+// leaving unset Optionals out. It is minified with WithMinifiedDocuments.
+// It returns an error if a variable is rejected by the Validate function of its registered scalar.
+func (c *Client) Build(op *Operation, variables map[string]interface{}) (string, error) {
+	if err := validateVariables(variables); err != nil {
+		return "", err
+	}
+	return c.minifiedDocument(op.document(variableDefinitions(variables, c.variableTypes)), nil)
+}
+
+// QueryOperation executes op, a query or a mutation built at runtime, with the given variables,
+// and decodes the data of the response into result, a pointer or a non-nil map, as Query does
+// for the Result of a ManualRequest.
+func (c *Client) QueryOperation(ctx context.Context, op *Operation, result interface{}, variables map[string]interface{}, options ...RequestOption) error {
+	query, err := c.Build(op, variables)
+	if err != nil {
+		return err
+	}
+	operation := queryOperation
+	if op.keyword == "mutation" {
+		operation = mutationOperation
+	}
+	return c.Do(ctx, operation, ManualRequest{Query: query, Result: result}, variables, "", options...)
+}
+
+// document returns the document of the operation, with the given variable definitions.
+func (o *Operation) document(definitions string) string {
+	var buf bytes.Buffer
+	writeSelections(&buf, o.fields)
+	query := buf.String()
+	switch {
+	case definitions != "":
+		return o.keyword + " " + o.name + "(" + definitions + ")" + query
+	case o.name != "":
+		return o.keyword + " " + o.name + query
+	case o.keyword == "query":
+		return query
+	}
+	return o.keyword + query
+}
+
+// writeSelections writes the minified selection set of fields to buf, e.g. "{login,name}".
+func writeSelections(buf *bytes.Buffer, fields []*Selection) {
+	buf.WriteString("{")
+	for i, s := range fields {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		if s.condition != "" {
+			buf.WriteString("... on " + s.condition)
+		} else {
+			if s.alias != "" {
+				buf.WriteString(s.alias + ":")
+			}
+			buf.WriteString(s.name)
+		}
+		if len(s.args) > 0 {
+			buf.WriteString("(")
+			for j, arg := range s.args {
+				if j > 0 {
+					buf.WriteString(",")
+				}
+				buf.WriteString(arg.name + ":")
+				writeValue(buf, arg.value)
+			}
+			buf.WriteString(")")
+		}
+		for _, directive := range s.directives {
+			buf.WriteString(" " + directive)
+		}
+		if len(s.fields) > 0 {
+			writeSelections(buf, s.fields)
+		}
+	}
+	buf.WriteString("}")
+}
+
+// writeValue writes value as a GraphQL literal to buf, see Selection.Arg.
+func writeValue(buf *bytes.Buffer, value interface{}) {
+	switch value := value.(type) {
+	case Var:
+		buf.WriteString("$" + string(value))
+		return
+	case Enum:
+		buf.WriteString(string(value))
+		return
+	case nil:
+		buf.WriteString("null")
+		return
+	case GraphQLMarshaler:
+		marshaled, err := value.MarshalGraphQL()
+		if err != nil {
+			buf.WriteString("null")
+			return
+		}
+		writeValue(buf, marshaled)
+		return
+	case json.Marshaler:
+		writeJSONValue(buf, value)
+		return
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return
+		}
+		writeValue(buf, v.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteString("null")
+			return
+		}
+		buf.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			writeValue(buf, v.Index(i).Interface())
+		}
+		buf.WriteString("]")
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			writeJSONValue(buf, value)
+			return
+		}
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		buf.WriteString("{")
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString(key + ":")
+			writeValue(buf, v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())).Interface())
+		}
+		buf.WriteString("}")
+	default:
+		writeJSONValue(buf, value)
+	}
+}
+
+// writeJSONValue writes value to buf through its JSON encoding, as a GraphQL literal.
+// JSON objects, e.g. of structs, are written as input objects, with unquoted keys.
+func writeJSONValue(buf *bytes.Buffer, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		buf.WriteString("null")
+		return
+	}
+	if !strings.HasPrefix(string(data), "{") && !strings.HasPrefix(string(data), "[") {
+		buf.Write(data)
+		return
+	}
+	var decoded interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil {
+		buf.WriteString("null")
+		return
+	}
+	writeValue(buf, decoded)
+}
+
+// Fields returns the selections of a selection set described by a map, a lighter-weight alternative to Field
+// for dynamic selection sets. The keys are fields as in graphql tags, with their alias, arguments and
+// directives, e.g. "open: issues(states: [OPEN])". The values are nil or true for leaf fields, false to leave
+// the field out, a []string or []interface{} of the names of leaf subfields, a map[string]bool of leaf subfields,
+// or a nested map[string]interface{} of subfields, as decoded from JSON:
+//
+//	graphql.NewQuery(graphql.Fields(map[string]interface{}{
+//		"viewer": map[string]interface{}{
+//			"login":                   true,
+//			"repositories(first: 10)": map[string]interface{}{"nodes": []string{"name", "url"}},
+//		},
+//	})...)
+//
+// Fields are selected in the order of their keys. It panics if a value is of another type.
+func Fields(selection map[string]interface{}) []*Selection {
+	keys := make([]string, 0, len(selection))
+	for key := range selection {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]*Selection, 0, len(keys))
+	for _, key := range keys {
+		field := Field(key)
+		switch value := selection[key].(type) {
+		case bool:
+			if !value {
+				continue
+			}
+		case nil:
+		case []string:
+			for _, name := range value {
+				field.Select(Field(name))
+			}
+		case []interface{}:
+			for _, name := range value {
+				s, ok := name.(string)
+				if !ok {
+					panic(fmt.Sprintf("graphql: Fields with a %T subfield of %q, want a string", name, key))
+				}
+				field.Select(Field(s))
+			}
+		case map[string]bool:
+			subfields := make(map[string]interface{}, len(value))
+			for name, selected := range value {
+				subfields[name] = selected
+			}
+			field.Select(Fields(subfields)...)
+		case map[string]interface{}:
+			field.Select(Fields(value)...)
+		default:
+			panic(fmt.Sprintf("graphql: Fields with a %T value for %q, want nil, a bool, a []string, a []interface{}, a map[string]bool or a map[string]interface{}", value, key))
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
@@ -0,0 +1,142 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestOperation_Document(t *testing.T) {
+	type orderBy struct {
+		Field     graphql.Enum `json:"field"`
+		Direction string       `json:"direction"`
+	}
+	op := graphql.NewQuery(
+		graphql.Field("repository").Arg("owner", graphql.Var("owner")).Arg("name", "go").Select(
+			graphql.Field("name"),
+			graphql.Field("issues").Alias("open").
+				Arg("first", 10).
+				Arg("states", []graphql.Enum{"OPEN"}).
+				Arg("orderBy", orderBy{Field: "CREATED_AT", Direction: "DESC"}).
+				Arg("labels", nil).
+				Select(graphql.Field("totalCount")),
+			graphql.Field("owner").Select(
+				graphql.Field("login"),
+				graphql.On("User", graphql.Field("bio")).Directive("@include(if: $withBio)"),
+			),
+		),
+	).Named("GetRepository")
+
+	variables := map[string]interface{}{
+		"owner":   graphql.String("golang"),
+		"withBio": false,
+	}
+	want := `query GetRepository($owner:String!$withBio:Boolean!){repository(owner:$owner,name:"go"){name,open:issues(first:10,states:[OPEN],orderBy:{direction:"DESC",field:"CREATED_AT"},labels:null){totalCount},owner{login,... on User @include(if: $withBio){bio}}}}`
+	if got := op.Document(variables); got != want {
+		t.Errorf("got document:\n%v\nwant:\n%v", got, want)
+	}
+	if got, want := graphql.NewMutation(graphql.Field("ping")).Document(nil), `mutation{ping}`; got != want {
+		t.Errorf("got document: %v, want: %v", got, want)
+	}
+}
+
+func TestOperation_query(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := mustRead(req.Body), `{"query":"{viewer{login,createdAt}}"}`+"\n"; got != want {
+			t.Errorf("got body: %v, want: %v", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher", "createdAt": "2009-11-10T23:00:00Z"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	columns := []string{"login", "createdAt"}
+	viewer := graphql.Field("viewer")
+	for _, column := range columns {
+		viewer.Select(graphql.Field(column))
+	}
+	var result map[string]interface{}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: graphql.NewQuery(viewer).Document(nil), Result: &result}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := result["viewer"].(map[string]interface{})["login"]; got != "gopher" {
+		t.Errorf("got login: %v, want: gopher", got)
+	}
+}
+
+func TestFields(t *testing.T) {
+	op := graphql.NewQuery(graphql.Fields(map[string]interface{}{
+		"viewer": map[string]interface{}{
+			"login":                   true,
+			"email":                   false,
+			"name":                    nil,
+			"repositories(first: 10)": map[string]interface{}{"nodes": []string{"name", "url"}},
+		},
+		"rateLimit": []string{"remaining"},
+		"licenses":  []interface{}{"key", "name"},
+		"meta":      map[string]bool{"version": true, "region": false},
+	})...)
+	if got, want := op.Document(nil), `{licenses{key,name},meta{version},rateLimit{remaining},viewer{login,name,repositories(first: 10){nodes{name,url}}}}`; got != want {
+		t.Errorf("got document: %v, want: %v", got, want)
+	}
+
+	for _, selection := range []map[string]interface{}{
+		{"viewer": "login"},
+		{"viewer": []interface{}{"login", 1}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Fields(%v) didn't panic", selection)
+				}
+			}()
+			graphql.Fields(selection)
+		}()
+	}
+}
+
+func TestClient_QueryOperation(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"node": {"id": "1", "createdAt": 1257894000000}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithVariableTypes(map[reflect.Type]string{reflect.TypeOf(""): "UUID"}).
+		WithTimeFormat(graphql.TimeUnixMillis)
+
+	op := graphql.NewQuery(
+		graphql.Field("node").Arg("id", graphql.Var("id")).Arg("after", graphql.Var("after")).Select(graphql.Field("id"), graphql.Field("createdAt")),
+	).Named("GetNode")
+	variables := map[string]interface{}{"id": "1", "after": graphql.Optional{}}
+	query, err := client.Build(op, variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `query GetNode($id:UUID!){node(id:$id,after:$after){id,createdAt}}`; query != want {
+		t.Errorf("got document: %v, want: %v", query, want)
+	}
+
+	var result struct {
+		Node struct {
+			ID        string
+			CreatedAt time.Time
+		}
+	}
+	if err := client.QueryOperation(context.Background(), op, &result, variables); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"query":"` + query + `","operationName":"GetNode","variables":{"id":"1"}}` + "\n"; gotBody != want {
+		t.Errorf("got body: %v, want: %v", gotBody, want)
+	}
+	if want := time.Unix(1257894000, 0); result.Node.ID != "1" || !result.Node.CreatedAt.Equal(want) {
+		t.Errorf("got node: %+v", result.Node)
+	}
+}
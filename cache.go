@@ -0,0 +1,531 @@
+package graphql
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is a key-value store backing the response cache and the normalized cache.
+// Implementations backed by shared stores, such as Redis, let several processes share cached responses.
+//
+// Caches must be safe for concurrent use. Failures of remote stores should be reported as cache misses,
+// so that requests fall back to the server.
+type Cache interface {
+	// Get returns the value stored under key, and whether there is one.
+	Get(ctx context.Context, key string) ([]byte, bool)
+
+	// Set stores value under key. If ttl is positive, the value expires after ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+
+	// Delete removes the value stored under key, if any.
+	Delete(ctx context.Context, key string)
+}
+
+// LRUCache is an in-memory Cache holding a bounded number of entries.
+// When full, the least recently used entries are evicted first.
+type LRUCache struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Of *lruEntry, most recently used first.
+}
+
+// lruEntry is a value stored in an LRUCache.
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time // Zero if the entry doesn't expire.
+}
+
+// NewLRUCache returns an empty in-memory cache holding up to maxEntries entries.
+func NewLRUCache(maxEntries int) *LRUCache {
+	return &LRUCache{
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Get implements Cache.
+func (c *LRUCache) Get(_ context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set implements Cache.
+func (c *LRUCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &lruEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = c.now().Add(ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Delete implements Cache.
+func (c *LRUCache) Delete(_ context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// CacheConfig configures the response cache of a client, see WithCache.
+type CacheConfig struct {
+	// TTL is how long a response is served from the cache.
+	//
+	// Defaults to 1 minute.
+	TTL time.Duration
+
+	// MaxEntries is the maximum number of cached responses in the default in-memory backend.
+	// The least recently used ones are evicted first.
+	//
+	// Defaults to 1000.
+	MaxEntries int
+
+	// Backend stores the cached responses.
+	//
+	// Defaults to an LRUCache holding MaxEntries responses.
+	Backend Cache
+
+	// StaleWhileRevalidate is how long after expiring a response is still served from the cache,
+	// while a fresh one is fetched in the background to replace it.
+	//
+	// Defaults to 0, in which case expired responses are never served.
+	StaleWhileRevalidate time.Duration
+
+	// RevalidationWindow is how long after expiring a response with an ETag or Last-Modified header is kept,
+	// to be revalidated with a conditional request, before it is evicted from Backend.
+	//
+	// Defaults to 24 hours.
+	RevalidationWindow time.Duration
+
+	// IgnoreCacheHints disables the cache hints of the server, so that responses are always cached for TTL.
+	// See WithCache.
+	IgnoreCacheHints bool
+
+	// OnRefresh, if set, is called once a stale response was refreshed in the background,
+	// with the cache key of the request, and the body of the fresh response or the error fetching it.
+	OnRefresh func(key string, body []byte, err error)
+}
+
+// WithCache returns a copy of the client that caches the responses to queries for config.TTL,
+// keyed by their document and variables, so that repeated identical queries don't reach the server.
+// Mutations, requests with file uploads and responses with errors are never cached.
+//
+// Expired responses with an ETag or Last-Modified header are kept for config.RevalidationWindow, and revalidated with a conditional request
+// (If-None-Match or If-Modified-Since). If the server answers 304 Not Modified, the cached response is used again.
+//
+// The cache hints of the server take precedence over config.TTL: the max-age directive of the Cache-Control header,
+// or else the smallest maxAge of the Apollo cacheControl response extension. Responses that the server marks as
+// private, no-store or no-cache, or with a max age of zero, aren't cached.
+//
+// If config.StaleWhileRevalidate is set, responses that expired within that window are returned right away,
+// and refreshed in the background. Refreshes outlive the request that started them, but keep its context values.
+//
+// The cache is shared by the client and the clients derived from it. Entries are keyed by the headers and
+// credentials sent with the request as well as by the request itself, so that responses aren't shared
+// between users: see WithRequestToken, WithContextHeaders and ManualRequest.Headers.
+func (c *Client) WithCache(config CacheConfig) *Client {
+	if config.TTL <= 0 {
+		config.TTL = time.Minute
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 1000
+	}
+	if config.RevalidationWindow <= 0 {
+		config.RevalidationWindow = 24 * time.Hour
+	}
+	if config.Backend == nil {
+		config.Backend = NewLRUCache(config.MaxEntries)
+	}
+	c2 := c.clone()
+	c2.cache = &responseCache{config: config, now: time.Now, refreshing: make(map[string]bool)}
+	return c2
+}
+
+// RequestKey returns a stable key identifying a request by its query document and variables,
+// suitable for caching or deduplicating requests, including in external systems.
+//
+// Documents are compared without their insignificant white space, commas and comments.
+// Variables are encoded as JSON, with the keys of maps sorted, so the key doesn't depend on map iteration order.
+// It returns an empty string if the variables can't be encoded.
+func RequestKey(query string, variables map[string]interface{}) string {
+	return requestKey(requestPayload{Query: query, Variables: variables})
+}
+
+// requestKey returns the key of a request payload, see RequestKey.
+// The operation name and extensions, such as the hash of a persisted query, are part of the key.
+func requestKey(in requestPayload) string {
+	in.Query = normalizeDocument(in.Query)
+	b, err := json.Marshal(in)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// requestScope returns a hash of the headers and credentials that the client sends with a request,
+// or "" if there are none. The cached responses and entities of requests with different scopes are kept apart.
+func (c *Client) requestScope(ctx context.Context, header http.Header, opts requestOptions) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := c.setHeaders(ctx, req, header, opts); err != nil {
+		return "", err
+	}
+	if len(req.Header) == 0 {
+		return "", nil
+	}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(h, "%s: %q\n", name, value)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// CachePolicy controls how a request uses the response and normalized caches of the client,
+// in the style of the fetch policies of Apollo Client. See WithCachePolicy.
+type CachePolicy int
+
+const (
+	// CacheFirst answers requests from the cache when possible, and sends them to the server otherwise.
+	// It is the default.
+	CacheFirst CachePolicy = iota
+
+	// CacheOnly answers requests from the cache, without ever sending them to the server.
+	// Requests whose response isn't cached fail with ErrCacheMiss.
+	CacheOnly
+
+	// NetworkOnly always sends requests to the server, and caches their responses.
+	// It refreshes the cached responses.
+	NetworkOnly
+
+	// NoCache always sends requests to the server, and doesn't cache their responses.
+	NoCache
+)
+
+// ErrCacheMiss is returned by requests with the CacheOnly policy whose response isn't cached.
+var ErrCacheMiss = fmt.Errorf("response is not cached")
+
+// WithCachePolicy sets how the request uses the caches of the client. It has no effect on clients without caches,
+// except that CacheOnly requests then always fail.
+func WithCachePolicy(policy CachePolicy) RequestOption {
+	return func(opts *requestOptions) {
+		opts.cachePolicy = policy
+	}
+}
+
+// WithCacheTTL caches the response to the request for ttl, instead of the TTL configured with WithCache.
+func WithCacheTTL(ttl time.Duration) RequestOption {
+	return func(opts *requestOptions) {
+		opts.cacheTTL = ttl
+	}
+}
+
+// responseCache caches response bodies in a Cache.
+// It is shared by a client and the clients derived from it.
+type responseCache struct {
+	config CacheConfig
+	now    func() time.Time
+
+	mu sync.Mutex
+	// refreshing is the set of keys whose stale responses are being refreshed.
+	refreshing map[string]bool
+}
+
+// cacheEntry is a cached response, as stored in the backend.
+type cacheEntry struct {
+	Body    []byte      `json:"body"`
+	Header  http.Header `json:"header"`
+	Expires time.Time   `json:"expires"`
+}
+
+// backendKey returns the backend key of the response with the given cache key.
+func (c *responseCache) backendKey(key string) string {
+	return "graphql:response:" + key
+}
+
+func (c *responseCache) get(ctx context.Context, key string) (*cacheEntry, bool) {
+	value, ok := c.config.Backend.Get(ctx, c.backendKey(key))
+	if !ok {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *responseCache) set(ctx context.Context, key string, entry *cacheEntry) {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	ttl := entry.Expires.Sub(c.now()) + c.keptFor(entry.Header)
+	if ttl <= 0 {
+		// A ttl of 0 would store the entry forever.
+		return
+	}
+	c.config.Backend.Set(ctx, c.backendKey(key), value, ttl)
+}
+
+// keptFor returns how long a response with the given header is kept in the backend after it expires:
+// to be served while it is revalidated, or to be revalidated with a conditional request if it has validators.
+func (c *responseCache) keptFor(header http.Header) time.Duration {
+	kept := c.config.StaleWhileRevalidate
+	if (header.Get("ETag") != "" || header.Get("Last-Modified") != "") && c.config.RevalidationWindow > kept {
+		kept = c.config.RevalidationWindow
+	}
+	return kept
+}
+
+// ttl returns how long to cache a response with the given header and body, and whether to cache it at all.
+// The body may be nil if it isn't known. A positive override takes precedence over cache hints.
+func (c *responseCache) ttl(override time.Duration, header http.Header, body []byte) (time.Duration, bool) {
+	if override > 0 {
+		return override, true
+	}
+	if !c.config.IgnoreCacheHints {
+		if maxAge, ok := cacheHint(header, body); ok {
+			return maxAge, maxAge > 0
+		}
+	}
+	return c.config.TTL, true
+}
+
+// cacheHint returns the max age of a response given by the server, either in the Cache-Control header,
+// or in the Apollo cacheControl extension of body, if any. Responses that must not be cached have a max age of 0.
+//
+// Specification: https://www.apollographql.com/docs/apollo-server/performance/caching/.
+func cacheHint(header http.Header, body []byte) (time.Duration, bool) {
+	if value := header.Get("Cache-Control"); value != "" {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			switch {
+			case directive == "no-store" || directive == "no-cache" || directive == "private":
+				return 0, true
+			case strings.HasPrefix(directive, "max-age="):
+				if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+					return time.Duration(seconds) * time.Second, true
+				}
+			}
+		}
+	}
+	if body == nil {
+		return 0, false
+	}
+	var out struct {
+		Extensions struct {
+			CacheControl struct {
+				Hints []struct {
+					MaxAge int
+					Scope  string
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(body, &out); err != nil || len(out.Extensions.CacheControl.Hints) == 0 {
+		return 0, false
+	}
+	maxAge := -1
+	for _, hint := range out.Extensions.CacheControl.Hints {
+		if strings.EqualFold(hint.Scope, "PRIVATE") {
+			return 0, true
+		}
+		if maxAge < 0 || hint.MaxAge < maxAge {
+			maxAge = hint.MaxAge
+		}
+	}
+	return time.Duration(maxAge) * time.Second, true
+}
+
+// lookup returns the cached response for key, or nil if there is none or it expired.
+// If an expired response has an ETag or Last-Modified header, it is kept for the RevalidationWindow,
+// and lookup returns the conditional request headers to revalidate it with.
+//
+// Responses that expired within the StaleWhileRevalidate window are still returned, and reported as stale,
+// along with their conditional request headers, if any.
+func (c *responseCache) lookup(ctx context.Context, key string) (resp *http.Response, conditional http.Header, stale bool) {
+	if key == "" {
+		return nil, nil, false
+	}
+	entry, ok := c.get(ctx, key)
+	if !ok {
+		return nil, nil, false
+	}
+	now := c.now()
+	if now.Before(entry.Expires) {
+		return syntheticResponse(entry.Body, entry.Header), nil, false
+	}
+	conditional = make(http.Header)
+	if etag := entry.Header.Get("ETag"); etag != "" {
+		conditional.Set("If-None-Match", etag)
+	}
+	if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+		conditional.Set("If-Modified-Since", lastModified)
+	}
+	if len(conditional) == 0 {
+		conditional = nil
+	}
+	if now.Before(entry.Expires.Add(c.config.StaleWhileRevalidate)) {
+		return syntheticResponse(entry.Body, entry.Header), conditional, true
+	}
+	if conditional == nil || !now.Before(entry.Expires.Add(c.keptFor(entry.Header))) {
+		// Backends may keep entries for longer than their ttl.
+		c.config.Backend.Delete(ctx, c.backendKey(key))
+		return nil, nil, false
+	}
+	return nil, conditional, false
+}
+
+// refresh calls fetch in the background to refresh the stale response cached under key,
+// unless it is already being refreshed, then calls the OnRefresh callback.
+func (c *responseCache) refresh(key string, fetch func() ([]byte, error)) {
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	go func() {
+		body, err := fetch()
+		c.mu.Lock()
+		delete(c.refreshing, key)
+		c.mu.Unlock()
+		if c.config.OnRefresh != nil {
+			c.config.OnRefresh(key, body, err)
+		}
+	}()
+}
+
+// detachedContext carries the values of a context, but not its deadline and cancellation,
+// for background work outliving the request that started it.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// revalidated handles a 304 Not Modified response to a conditional request for key,
+// extending the lifetime of the cached response and returning it.
+// A positive ttl overrides the lifetime given by the configuration and the cache hints of the server.
+// It returns nil if the response is no longer cached.
+func (c *responseCache) revalidated(ctx context.Context, key string, header http.Header, ttl time.Duration) *http.Response {
+	entry, ok := c.get(ctx, key)
+	if !ok {
+		return nil
+	}
+	ttl, _ = c.ttl(ttl, header, nil)
+	for _, name := range []string{"ETag", "Last-Modified"} {
+		if value := header.Get(name); value != "" {
+			entry.Header.Set(name, value)
+		}
+	}
+	entry.Expires = c.now().Add(ttl)
+	c.set(ctx, key, entry)
+	return syntheticResponse(entry.Body, entry.Header)
+}
+
+// store reads the body of resp and caches it under key, unless the response has errors or mustn't be cached.
+// A positive ttl overrides the lifetime given by the configuration and the cache hints of the server.
+// The body of resp is replaced so that it can still be read.
+func (c *responseCache) store(ctx context.Context, key string, resp *http.Response, ttl time.Duration) error {
+	if key == "" {
+		return nil
+	}
+	body, err := readAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if hasErrors(body) {
+		return nil
+	}
+	ttl, ok := c.ttl(ttl, resp.Header, body)
+	if !ok {
+		return nil
+	}
+	c.set(ctx, key, &cacheEntry{Body: body, Header: resp.Header.Clone(), Expires: c.now().Add(ttl)})
+	return nil
+}
+
+// hasErrors reports whether a GraphQL response body has errors, or can't be decoded.
+func hasErrors(body []byte) bool {
+	var out struct {
+		Errors []json.RawMessage
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return true
+	}
+	return len(out.Errors) > 0
+}
+
+// syntheticResponse returns a 200 OK response that wasn't received from the server, such as a cached one.
+func syntheticResponse(body []byte, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{"Content-Type": {"application/json"}}
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Header:        header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
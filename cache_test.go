@@ -0,0 +1,479 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+// countingHandler answers queries with the login given by their variables, counting the requests.
+// Logins starting with "!" are answered with an error.
+func countingHandler(t *testing.T, requests *int) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		*requests++
+		var in struct {
+			Variables struct {
+				Login string
+			}
+		}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if in.Variables.Login[0] == '!' {
+			mustWrite(w, `{"data": null, "errors": [{"message": "bad login"}]}`)
+			return
+		}
+		mustWrite(w, `{"data": {"user": {"login": "`+in.Variables.Login+`"}}}`)
+	})
+	return mux
+}
+
+func queryUser(client *graphql.Client, login string, options ...graphql.RequestOption) (string, error) {
+	var q userQuery
+	err := client.Query(context.Background(), graphql.ManualRequest{
+		Query:  "query($login:String!){user(login:$login){login}}",
+		Result: &q,
+	}, map[string]interface{}{"login": login}, options...)
+	return string(q.User.Login), err
+}
+
+func TestClient_WithCache(t *testing.T) {
+	requests := 0
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: countingHandler(t, &requests)}}).
+		WithCache(graphql.CacheConfig{TTL: 50 * time.Millisecond, MaxEntries: 2})
+
+	for _, tc := range []struct {
+		login        string
+		wantRequests int
+	}{
+		{login: "a", wantRequests: 1},
+		{login: "a", wantRequests: 1}, // Cached.
+		{login: "b", wantRequests: 2},
+		{login: "c", wantRequests: 3}, // Evicts a.
+		{login: "c", wantRequests: 3},
+		{login: "a", wantRequests: 4},
+		{login: "!", wantRequests: 5},
+		{login: "!", wantRequests: 6}, // Errors aren't cached.
+	} {
+		got, err := queryUser(client, tc.login)
+		if err != nil && tc.login != "!" {
+			t.Fatal(err)
+		}
+		if got != tc.login && tc.login != "!" {
+			t.Errorf("got login: %q, want: %q", got, tc.login)
+		}
+		if requests != tc.wantRequests {
+			t.Errorf("after querying %q: got %v requests, want: %v", tc.login, requests, tc.wantRequests)
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := queryUser(client, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 7 {
+		t.Errorf("after expiry: got %v requests, want: 7", requests)
+	}
+}
+
+func TestClient_WithCache_conditional(t *testing.T) {
+	var got []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		got = append(got, req.Header.Get("If-None-Match")+"|"+req.Header.Get("If-Modified-Since"))
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"login": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithCache(graphql.CacheConfig{TTL: 20 * time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			time.Sleep(30 * time.Millisecond)
+		}
+		login, err := queryUser(client, "gopher")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if login != "gopher" {
+			t.Errorf("got login: %q, want: %q", login, "gopher")
+		}
+	}
+	want := []string{"|", `"v1"|Mon, 02 Jan 2006 15:04:05 GMT`, `"v1"|Mon, 02 Jan 2006 15:04:05 GMT`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got conditional headers: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_WithCache_revalidationWindow(t *testing.T) {
+	var got []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		got = append(got, req.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"login": "gopher"}}}`)
+	})
+	backend := &mapCache{values: make(map[string][]byte)}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithCache(graphql.CacheConfig{TTL: 20 * time.Millisecond, RevalidationWindow: 40 * time.Millisecond, Backend: backend})
+
+	// The response is revalidated within the window, and fetched again after it, even though the backend never expires it.
+	for _, sleep := range []time.Duration{0, 30 * time.Millisecond, 100 * time.Millisecond} {
+		time.Sleep(sleep)
+		if _, err := queryUser(client, "gopher"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"", `"v1"`, ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got If-None-Match headers: %q, want: %q", got, want)
+	}
+	for key, ttl := range backend.ttls {
+		if ttl <= 0 || ttl > 60*time.Millisecond {
+			t.Errorf("got ttl %v for %s, want the TTL and the revalidation window at most", ttl, key)
+		}
+	}
+}
+
+func TestClient_WithCache_credentials(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"login": "`+req.Header.Get("Authorization")+req.Header.Get("X-Tenant")+`"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithCache(graphql.CacheConfig{})
+
+	for _, tc := range []struct {
+		options      []graphql.RequestOption
+		want         string
+		wantRequests int
+	}{
+		{options: []graphql.RequestOption{graphql.WithRequestToken("a")}, want: "Bearer a", wantRequests: 1},
+		{options: []graphql.RequestOption{graphql.WithRequestToken("b")}, want: "Bearer b", wantRequests: 2},
+		{options: []graphql.RequestOption{graphql.WithRequestToken("a")}, want: "Bearer a", wantRequests: 2}, // Cached.
+		{want: "", wantRequests: 3},
+		{want: "", wantRequests: 3},
+	} {
+		got, err := queryUser(client, "gopher", tc.options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want || requests != tc.wantRequests {
+			t.Errorf("got login: %q after %v requests, want: %q after %v", got, requests, tc.want, tc.wantRequests)
+		}
+	}
+
+	var q userQuery
+	ctx := graphql.WithContextHeaders(context.Background(), http.Header{"X-Tenant": {"t1"}})
+	if err := client.Query(ctx, graphql.ManualRequest{Query: "query($login:String!){user(login:$login){login}}", Result: &q}, map[string]interface{}{"login": "gopher"}); err != nil {
+		t.Fatal(err)
+	}
+	if q.User.Login != "t1" || requests != 4 {
+		t.Errorf("got login: %q after %v requests, want: %q after 4", q.User.Login, requests, "t1")
+	}
+}
+
+func TestClient_WithCache_staleWhileRevalidate(t *testing.T) {
+	var mu sync.Mutex
+	version := 0
+	release := make(chan struct{}) // Holds the refreshes until closed.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		version++
+		login := "v" + strconv.Itoa(version)
+		mu.Unlock()
+		if login != "v1" {
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"login": "`+login+`"}}}`)
+	})
+	refreshed := make(chan string, 10)
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithCache(graphql.CacheConfig{
+			TTL:                  20 * time.Millisecond,
+			StaleWhileRevalidate: time.Minute,
+			OnRefresh: func(key string, body []byte, err error) {
+				if err != nil {
+					t.Error(err)
+				}
+				refreshed <- string(body)
+			},
+		})
+
+	check := func(want string) {
+		t.Helper()
+		got, err := queryUser(client, "gopher")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got login: %q, want: %q", got, want)
+		}
+	}
+	check("v1")
+	time.Sleep(30 * time.Millisecond)
+	// Stale, refreshed once in the background.
+	check("v1")
+	check("v1")
+	close(release)
+	if got, want := <-refreshed, `{"data": {"user": {"login": "v2"}}}`; got != want {
+		t.Errorf("got refreshed body: %q, want: %q", got, want)
+	}
+	check("v2")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if version != 2 {
+		t.Errorf("got %v requests, want: 2", version)
+	}
+	select {
+	case body := <-refreshed:
+		t.Errorf("got unexpected refresh: %q", body)
+	default:
+	}
+}
+
+func TestClient_WithCache_policies(t *testing.T) {
+	requests := 0
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: countingHandler(t, &requests)}}).
+		WithCache(graphql.CacheConfig{TTL: 20 * time.Millisecond})
+
+	for _, tc := range []struct {
+		login        string
+		options      []graphql.RequestOption
+		sleep        time.Duration
+		wantErr      error
+		wantRequests int
+	}{
+		{login: "a", options: []graphql.RequestOption{graphql.WithCachePolicy(graphql.CacheOnly)}, wantErr: graphql.ErrCacheMiss},
+		{login: "a", wantRequests: 1},
+		{login: "a", options: []graphql.RequestOption{graphql.WithCachePolicy(graphql.CacheOnly)}, wantRequests: 1},
+		{login: "a", options: []graphql.RequestOption{graphql.WithCachePolicy(graphql.NetworkOnly)}, wantRequests: 2},
+		{login: "b", options: []graphql.RequestOption{graphql.WithCachePolicy(graphql.NoCache)}, wantRequests: 3},
+		{login: "b", options: []graphql.RequestOption{graphql.WithCachePolicy(graphql.CacheOnly)}, wantErr: graphql.ErrCacheMiss, wantRequests: 3},
+		{login: "c", options: []graphql.RequestOption{graphql.WithCacheTTL(time.Hour)}, wantRequests: 4},
+		{login: "c", sleep: 30 * time.Millisecond, wantRequests: 4}, // Cached for an hour.
+		{login: "a", wantRequests: 5}, // Expired.
+	} {
+		time.Sleep(tc.sleep)
+		got, err := queryUser(client, tc.login, tc.options...)
+		if err != tc.wantErr {
+			t.Fatalf("querying %q: got error: %v, want: %v", tc.login, err, tc.wantErr)
+		}
+		if err == nil && got != tc.login {
+			t.Errorf("got login: %q, want: %q", got, tc.login)
+		}
+		if requests != tc.wantRequests {
+			t.Errorf("after querying %q: got %v requests, want: %v", tc.login, requests, tc.wantRequests)
+		}
+	}
+}
+
+func TestClient_WithCache_hints(t *testing.T) {
+	requests := make(map[string]int)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Variables struct {
+				Login string
+			}
+		}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			t.Error(err)
+		}
+		login := in.Variables.Login
+		requests[login]++
+		extensions := `{}`
+		switch login {
+		case "header":
+			w.Header().Set("Cache-Control", "public, max-age=3600")
+		case "private":
+			w.Header().Set("Cache-Control", "private, max-age=3600")
+		case "extension":
+			extensions = `{"cacheControl": {"version": 1, "hints": [{"path": ["user"], "maxAge": 3600}, {"path": ["user", "login"], "maxAge": 7200}]}}`
+		case "zero":
+			extensions = `{"cacheControl": {"version": 1, "hints": [{"path": ["user"], "maxAge": 0}]}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"login": "`+login+`"}}, "extensions": `+extensions+`}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithCache(graphql.CacheConfig{TTL: 20 * time.Millisecond})
+
+	logins := []string{"default", "header", "private", "extension", "zero"}
+	for i := 0; i < 3; i++ {
+		if i == 2 {
+			time.Sleep(30 * time.Millisecond)
+		}
+		for _, login := range logins {
+			if _, err := queryUser(client, login); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	want := map[string]int{"default": 2, "header": 1, "private": 3, "extension": 1, "zero": 3}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("got requests: %v, want: %v", requests, want)
+	}
+}
+
+// mapCache is a Cache recording the keys it stores, and their ttl, without ever expiring them.
+type mapCache struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+}
+
+func (c *mapCache) Get(_ context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	return value, ok
+}
+
+func (c *mapCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	if c.ttls == nil {
+		c.ttls = make(map[string]time.Duration)
+	}
+	c.ttls[key] = ttl
+}
+
+func (c *mapCache) Delete(_ context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+}
+
+func TestClient_WithCache_backend(t *testing.T) {
+	requests := 0
+	backend := &mapCache{values: make(map[string][]byte)}
+	newClient := func() *graphql.Client {
+		return graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: countingHandler(t, &requests)}}).
+			WithCache(graphql.CacheConfig{Backend: backend})
+	}
+
+	// Clients sharing a backend share cached responses, as processes sharing a remote store would.
+	for _, client := range []*graphql.Client{newClient(), newClient()} {
+		login, err := queryUser(client, "gopher")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if login != "gopher" {
+			t.Errorf("got login: %q, want: %q", login, "gopher")
+		}
+	}
+	if requests != 1 {
+		t.Errorf("got %v requests, want: 1", requests)
+	}
+	if len(backend.values) != 1 {
+		t.Errorf("got %v cached values, want: 1", len(backend.values))
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	cache := graphql.NewLRUCache(2)
+	cache.Set(ctx, "a", []byte("1"), 0)
+	cache.Set(ctx, "b", []byte("2"), 0)
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", []byte("3"), 0) // Evicts b, the least recently used.
+	cache.Set(ctx, "d", []byte("4"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	for _, tc := range []struct {
+		key  string
+		want string
+		ok   bool
+	}{
+		{key: "a"}, // Evicted by d.
+		{key: "b"},
+		{key: "c", want: "3", ok: true},
+		{key: "d"}, // Expired.
+	} {
+		got, ok := cache.Get(ctx, tc.key)
+		if string(got) != tc.want || ok != tc.ok {
+			t.Errorf("got %q, %v for %q, want: %q, %v", got, ok, tc.key, tc.want, tc.ok)
+		}
+	}
+	cache.Delete(ctx, "c")
+	if _, ok := cache.Get(ctx, "c"); ok {
+		t.Error("got c after deleting it")
+	}
+}
+
+func TestRequestKey(t *testing.T) {
+	key := graphql.RequestKey(`query($id: ID!) { user(id: $id) { login, name } }`, map[string]interface{}{
+		"id":     "1",
+		"filter": map[string]interface{}{"a": 1, "b": 2, "c": 3},
+	})
+	for _, tc := range []struct {
+		query     string
+		variables map[string]interface{}
+		same      bool
+	}{
+		{
+			query:     "query($id:ID!){user(id:$id){login name}}",
+			variables: map[string]interface{}{"filter": map[string]interface{}{"c": 3, "b": 2, "a": 1}, "id": "1"},
+			same:      true,
+		},
+		{
+			query: `
+				# Comments are ignored.
+				query ( $id : ID! ) {
+					user(id: $id) {
+						login
+						name
+					}
+				}`,
+			variables: map[string]interface{}{"id": "1", "filter": map[string]interface{}{"b": 2, "a": 1, "c": 3}},
+			same:      true,
+		},
+		{
+			query:     "query($id:ID!){user(id:$id){login}}",
+			variables: map[string]interface{}{"id": "1", "filter": map[string]interface{}{"a": 1, "b": 2, "c": 3}},
+		},
+		{
+			query:     "query($id:ID!){user(id:$id){login name}}",
+			variables: map[string]interface{}{"id": "2", "filter": map[string]interface{}{"a": 1, "b": 2, "c": 3}},
+		},
+	} {
+		if got := graphql.RequestKey(tc.query, tc.variables); (got == key) != tc.same {
+			t.Errorf("same key for %q: %v, want %v", tc.query, got == key, tc.same)
+		}
+	}
+
+	// Strings are kept as they are.
+	if graphql.RequestKey(`{user(login: "a  b, c"){id}}`, nil) == graphql.RequestKey(`{user(login: "a b c"){id}}`, nil) {
+		t.Error("got the same key for different string arguments")
+	}
+	if graphql.RequestKey(`{a b}`, nil) == graphql.RequestKey(`{ab}`, nil) {
+		t.Error("got the same key for different documents")
+	}
+}
@@ -0,0 +1,80 @@
+// graphqlgen generates Go types and functions for GraphQL operations, from the schema of the server,
+// see package codegen.
+//
+// Usage:
+//
+//	graphqlgen -schema schema.graphql -package api [-o operations.go] [-scalar Name=importpath.Type]... operations.graphql...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/darrensapalo/go-graphql-client/codegen"
+)
+
+// scalarsFlag collects the Go types of custom scalars, given as -scalar Name=importpath.Type.
+type scalarsFlag map[string]string
+
+func (f scalarsFlag) String() string {
+	return fmt.Sprint(map[string]string(f))
+}
+
+func (f scalarsFlag) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i <= 0 || i == len(value)-1 {
+		return fmt.Errorf("got %q, want Name=importpath.Type", value)
+	}
+	f[value[:i]] = value[i+1:]
+	return nil
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("graphqlgen: ")
+	var (
+		schema  = flag.String("schema", "", "path of the schema of the server, in the schema definition language")
+		pkg     = flag.String("package", "", "name of the package of the generated code")
+		out     = flag.String("o", "", "path of the generated file; standard output if empty")
+		scalars = scalarsFlag{}
+	)
+	flag.Var(scalars, "scalar", "Go type of a custom scalar, as Name=importpath.Type, e.g. DateTime=time.Time; repeatable")
+	flag.Parse()
+	if *schema == "" || *pkg == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: graphqlgen -schema schema.graphql -package name [-o file.go] [-scalar Name=importpath.Type]... operations.graphql...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	if err := run(*schema, *pkg, *out, scalars, flag.Args()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(schemaPath, pkg, out string, scalars map[string]string, operationPaths []string) error {
+	schema, err := ioutil.ReadFile(schemaPath)
+	if err != nil {
+		return err
+	}
+	config := codegen.Config{Package: pkg, Schema: string(schema), Scalars: scalars}
+	for _, p := range operationPaths {
+		operations, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		config.Operations = append(config.Operations, string(operations))
+	}
+	src, err := codegen.Generate(config)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
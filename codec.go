@@ -0,0 +1,141 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/darrensapalo/go-graphql-client/internal/jsonutil"
+)
+
+// Codec encodes and decodes the JSON of GraphQL requests and responses, so that encoding/json can be replaced by
+// a faster implementation, such as jsoniter, go-json or sonic. Codecs must behave as encoding/json does,
+// including for json.Marshaler, json.Unmarshaler and json.RawMessage values, and be safe for concurrent use.
+type Codec interface {
+	// Marshal returns the JSON encoding of v.
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal decodes the JSON-encoded data into v.
+	Unmarshal(data []byte, v interface{}) error
+
+	// NewDecoder returns a decoder reading a JSON value from r.
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Decoder decodes a JSON value read from a stream. *json.Decoder implements it.
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// WithCodec returns a copy of the client that encodes requests and decodes responses with codec.
+// The client still uses encoding/json internally, e.g. to store cached responses, and to stream lists with QueryStream.
+// Requests are only as deterministic as codec: encoding/json sorts the keys of maps, e.g. of variables,
+// but some codecs write them in map iteration order, which varies across runs.
+//
+// By default, encoding/json is used, with buffers reused across requests.
+func (c *Client) WithCodec(codec Codec) *Client {
+	c2 := c.clone()
+	c2.codec = codec
+	return c2
+}
+
+// marshal returns the JSON encoding of v, with the codec of the client.
+func (c *Client) marshal(v interface{}) ([]byte, error) {
+	if c.codec == nil {
+		return encodeJSON(v)
+	}
+	return c.codec.Marshal(v)
+}
+
+// unmarshal decodes the JSON-encoded data of a response into v, with the codec of the client,
+// or with encoding/json and the options of the client.
+func (c *Client) unmarshal(data []byte, v interface{}) error {
+	if c.codec == nil {
+		return c.jsonOptions.unmarshal(data, v)
+	}
+	return c.codec.Unmarshal(data, v)
+}
+
+// unmarshalData decodes the JSON-encoded data of a response into v, as unmarshal does,
+// applying the decode hooks of the client, if any, and matching the aliases of graphql tags,
+// which encoding/json doesn't know about, unless a codec is set. If strict is true and v is a pointer to a struct,
+// fields are matched by their graphql tags only, see Client.Strict.
+//
+// Results with interface fields of union types, see WithUnionTypes, or GraphQLUnmarshaler values,
+// or gqlgen custom scalars with WithGQLGenModels, are always decoded generically, as neither codecs
+// nor strict decoding support them. Strict decoding doesn't apply to gqlgen models.
+func (c *Client) unmarshalData(data []byte, v interface{}, strict bool) error {
+	t := reflect.TypeOf(v)
+	if c.unionTypes.in(t) || hasUnmarshalers(t) || c.gqlgenModels && hasGQLGenScalars(t) {
+		return decodeWithHooks(data, v, &hookDecoder{hooks: c.hooks(), unions: c.unionTypes, gqlgen: c.gqlgenModels}, c.jsonOptions)
+	}
+	if strict && !c.gqlgenModels && t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		return jsonutil.UnmarshalGraphQL(data, v, true)
+	}
+	if c.codec == nil && (len(c.decodeHooks) > 0 || c.timeFormat != nil || hasAliases(t)) {
+		return decodeWithHooks(data, v, &hookDecoder{hooks: c.hooks()}, c.jsonOptions)
+	}
+	return c.unmarshal(data, v)
+}
+
+// decode reads a JSON value from r and decodes it into v, with the codec of the client.
+// The options of the client don't apply, as they are for the data of responses, decoded with unmarshal.
+func (c *Client) decode(r io.Reader, v interface{}) error {
+	if c.codec == nil {
+		return decodeJSON(r, v)
+	}
+	return c.codec.NewDecoder(r).Decode(v)
+}
+
+// WithUseNumber returns a copy of the client that decodes numbers in the data of responses into interface{} values
+// as json.Number, rather than as float64, so that large integers such as IDs and exact amounts don't lose precision.
+//
+// It applies to the default encoding/json decoding; a codec set with WithCodec must be configured to do so itself.
+func (c *Client) WithUseNumber() *Client {
+	c2 := c.clone()
+	c2.jsonOptions.useNumber = true
+	return c2
+}
+
+// WithDisallowUnknownFields returns a copy of the client that returns a *DecodeError when the data of a response
+// has a field that the result of the operation doesn't declare, as json.Decoder.DisallowUnknownFields does.
+// It catches mismatches between queries and their results, e.g. schema drift or typos in tests,
+// rather than silently dropping data.
+//
+// It applies to the default encoding/json decoding; a codec set with WithCodec must be configured to do so itself.
+func (c *Client) WithDisallowUnknownFields() *Client {
+	c2 := c.clone()
+	c2.jsonOptions.disallowUnknownFields = true
+	return c2
+}
+
+// jsonOptions configures the decoding of the data of responses with encoding/json, when the client has no codec.
+type jsonOptions struct {
+	// useNumber decodes numbers into interface{} values as json.Number.
+	useNumber bool
+	// disallowUnknownFields fails decoding objects with fields that their struct doesn't declare.
+	disallowUnknownFields bool
+}
+
+// unmarshal decodes the JSON-encoded data into v, as json.Unmarshal does, with the options.
+func (o jsonOptions) unmarshal(data []byte, v interface{}) error {
+	if o == (jsonOptions{}) {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if o.useNumber {
+		dec.UseNumber()
+	}
+	if o.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
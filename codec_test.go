@@ -0,0 +1,159 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+// countingCodec is a graphql.Codec counting its calls, backed by encoding/json.
+type countingCodec struct {
+	mu                            sync.Mutex
+	marshals, unmarshals, decodes int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.mu.Lock()
+	c.marshals++
+	c.mu.Unlock()
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.mu.Lock()
+	c.unmarshals++
+	c.mu.Unlock()
+	return json.Unmarshal(data, v)
+}
+
+func (c *countingCodec) NewDecoder(r io.Reader) graphql.Decoder {
+	c.mu.Lock()
+	c.decodes++
+	c.mu.Unlock()
+	return json.NewDecoder(r)
+}
+
+func TestClient_WithCodec(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := mustRead(req.Body), `{"query":"{viewer{login}}"}`; got != want {
+			t.Errorf("got body: %v, want: %v", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+	})
+	codec := &countingCodec{}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithCodec(codec)
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if q.Viewer.Login != "gopher" {
+		t.Errorf("got login: %q, want: %q", q.Viewer.Login, "gopher")
+	}
+	if codec.marshals != 1 || codec.decodes != 1 {
+		t.Errorf("got %d marshals and %d decodes, want 1 of each", codec.marshals, codec.decodes)
+	}
+}
+
+func TestClient_WithUseNumber(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"node": {"id": 9007199254740993, "amount": 12.30}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Node map[string]interface{}
+	}
+	if err := client.WithUseNumber().Query(context.Background(), graphql.ManualRequest{Query: "{node{id,amount}}", Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.Node["id"], json.Number("9007199254740993"); got != want {
+		t.Errorf("got id: %#v, want: %#v", got, want)
+	}
+	if got, want := q.Node["amount"], json.Number("12.30"); got != want {
+		t.Errorf("got amount: %#v, want: %#v", got, want)
+	}
+
+	q.Node = nil
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{node{id,amount}}", Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.Node["id"], float64(9007199254740992); got != want {
+		t.Errorf("got id: %#v, want: %#v", got, want)
+	}
+}
+
+func TestClient_WithDisallowUnknownFields(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher", "name": "Gopher"}}, "extensions": {"cost": 1}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login,name}}", Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	err := client.WithDisallowUnknownFields().Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login,name}}", Result: &q}, nil)
+	if !errors.Is(err, graphql.ErrDecode) || !strings.Contains(err.Error(), `json: unknown field "name"`) {
+		t.Errorf("got error: %v, want unknown field decoding error", err)
+	}
+}
+
+func TestClient_WithStrict(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"me": {"login": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	client.Strict = true
+
+	var strict struct {
+		Viewer struct {
+			Login graphql.String
+		} `graphql:"me: viewer"`
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{me: viewer{login}}", Result: &strict}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strict.Viewer.Login != "gopher" {
+		t.Errorf("got login: %q, want: %q", strict.Viewer.Login, "gopher")
+	}
+
+	var lenient struct {
+		Viewer struct {
+			Login graphql.String `json:"login"`
+		} `json:"me"`
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "{me: viewer{login}}", Result: &lenient}, nil)
+	if !errors.Is(err, graphql.ErrDecode) {
+		t.Errorf("got error: %v, want a decoding error in strict mode", err)
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{me: viewer{login}}", Result: &lenient}, nil, graphql.WithStrict(false)); err != nil {
+		t.Fatal(err)
+	}
+	if lenient.Viewer.Login != "gopher" {
+		t.Errorf("got login: %q, want: %q", lenient.Viewer.Login, "gopher")
+	}
+}
@@ -0,0 +1,535 @@
+// Package codegen generates Go code for the GraphQL operations of an application from the schema of the server,
+// as genqlient does: for every operation, the types of its response and variables, exactly as selected,
+// and a function executing it with a *graphql.Client. The types can't drift from the operations and the schema,
+// as query structs maintained by hand do for large APIs.
+//
+// It is typically run with the graphqlgen command and go:generate:
+//
+//	//go:generate go run github.com/darrensapalo/go-graphql-client/cmd/graphqlgen -schema schema.graphql -package api -o operations.go operations.graphql
+//
+// For the operation
+//
+//	query GetUser($id: ID!) {
+//		user(id: $id) {
+//			name
+//			friends(first: 10) { name }
+//		}
+//	}
+//
+// it generates
+//
+//	const GetUserDocument = `query GetUser($id: ID!) { ... }`
+//
+//	type GetUserResponse struct {
+//		User *GetUserUser `json:"user"`
+//	}
+//
+//	type GetUserUser struct {
+//		Name    string               `json:"name"`
+//		Friends []GetUserUserFriends `json:"friends"`
+//	}
+//
+//	type GetUserUserFriends struct {
+//		Name string `json:"name"`
+//	}
+//
+//	func GetUser(ctx context.Context, client *graphql.Client, id string) (*GetUserResponse, error)
+//
+// Nullable fields and variables are pointers, except lists, which are nil when null. Fields selected
+// under @include, @skip, or a type condition narrower than their parent type may be missing, and are
+// nullable too. The fields of inline fragments and fragment spreads are flattened into the type
+// of their parent selection set. Enums and input objects are declared once, after their names in
+// the schema; enums implement graphql.GraphQLEnum.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/darrensapalo/go-graphql-client/ident"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// clientPackage is the import path of the graphql package.
+const clientPackage = "github.com/darrensapalo/go-graphql-client"
+
+// Config configures the generation of code.
+type Config struct {
+	// Package is the name of the package of the generated code.
+	Package string
+
+	// Schema is the schema of the server, in the schema definition language.
+	Schema string
+
+	// Operations are the documents of the operations, and of the fragments they spread.
+	// Every operation must be named.
+	Operations []string
+
+	// Scalars maps the names of custom scalars to the Go types of their values, qualified by their import path
+	// if any, e.g. "DateTime": "time.Time" or "Decimal": "github.com/shopspring/decimal.Decimal".
+	// It can also override the Go types of the built-in scalars: string for ID and String, int for Int,
+	// float64 for Float and bool for Boolean.
+	Scalars map[string]string
+}
+
+// Generate returns the formatted Go source of the code generated for the operations of config.
+// It returns an error if the schema or the operations are invalid, or a custom scalar has no Go type.
+func Generate(config Config) ([]byte, error) {
+	schema, err := graphqlgo.ParseSchema(config.Schema, nil)
+	if err != nil {
+		return nil, fmt.Errorf("schema: %v", err)
+	}
+	doc, err := parse(strings.Join(config.Operations, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("operations: %v", err)
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("operations: no operations")
+	}
+	g := &generator{
+		config:   config,
+		schema:   schema.Inspect(),
+		doc:      doc,
+		types:    make(map[string]*introspection.Type),
+		fields:   make(map[string]map[string]*introspection.Field),
+		imports:  map[string]bool{"context": true, clientPackage: true},
+		declared: make(map[string]bool),
+		named:    make(map[string]bool),
+	}
+	for _, t := range g.schema.Types() {
+		g.types[*t.Name()] = t
+	}
+	for _, op := range doc.operations {
+		source := g.document(op)
+		for _, err := range schema.Validate(source) {
+			// The values of variables are only known when operations are executed.
+			if err.Rule != "VariablesOfCorrectType" {
+				return nil, fmt.Errorf("operation %s: %v", op.name, err)
+			}
+		}
+		g.operation(op, source)
+		if g.err != nil {
+			return nil, fmt.Errorf("operation %s: %v", op.name, g.err)
+		}
+	}
+	g.namedTypes()
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by graphqlgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", config.Package)
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		if imp == clientPackage {
+			fmt.Fprintf(&buf, "\tgraphql %q\n", imp)
+		} else {
+			fmt.Fprintf(&buf, "\t%q\n", imp)
+		}
+	}
+	buf.WriteString(")\n")
+	g.decls.WriteTo(&buf)
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+// generator generates the code of operations.
+type generator struct {
+	config Config
+	schema *introspection.Schema
+	doc    *document
+
+	// types are the types of the schema, by name.
+	types map[string]*introspection.Type
+	// fields are the fields of the object and interface types of the schema, by type name and field name.
+	fields map[string]map[string]*introspection.Field
+
+	// imports are the import paths of the generated code.
+	imports map[string]bool
+	// declared are the names declared by the generated code.
+	declared map[string]bool
+	// named are the enum and input object types of the schema to declare.
+	named map[string]bool
+	// decls are the declarations of the generated code.
+	decls bytes.Buffer
+
+	// err is the first error generating code.
+	err error
+}
+
+// fail records the first error generating code.
+func (g *generator) fail(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+}
+
+// document returns the document of op: its definition, followed by the definitions of the fragments it spreads,
+// directly or not, by name.
+func (g *generator) document(op *operation) string {
+	used := make(map[string]bool)
+	var spreads func(selections []*selection)
+	spreads = func(selections []*selection) {
+		for _, s := range selections {
+			if f, ok := g.doc.fragments[s.spread]; ok && !used[s.spread] {
+				used[s.spread] = true
+				spreads(f.selections)
+			}
+			spreads(s.selections)
+		}
+	}
+	spreads(op.selections)
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sources := []string{op.source}
+	for _, name := range names {
+		sources = append(sources, g.doc.fragments[name].source)
+	}
+	return strings.Join(sources, "\n\n")
+}
+
+// operation generates the document constant, the types and the function of op, whose document is source.
+func (g *generator) operation(op *operation, source string) {
+	var root *introspection.Type
+	var method string
+	switch op.kind {
+	case "query":
+		root, method = g.schema.QueryType(), "Query"
+	case "mutation":
+		root, method = g.schema.MutationType(), "Mutate"
+	default:
+		g.fail("%ss aren't supported", op.kind)
+		return
+	}
+	name := goName(op.name)
+	document := g.declare(name + "Document")
+	response := g.declare(name + "Response")
+	function := g.declare(name)
+
+	fmt.Fprintf(&g.decls, "\n// %s is the document of the %s %s.\nconst %s = %s\n", document, op.name, op.kind, document, goString(source))
+	decls := g.structType(response, name, fmt.Sprintf("the response of the %s %s", op.name, op.kind), root, op.selections)
+
+	var params, variables bytes.Buffer
+	for _, v := range op.variables {
+		param := goParam(v.name)
+		fmt.Fprintf(&params, ", %s %s", param, g.inputType(v.typ))
+		fmt.Fprintf(&variables, "\t\t%q: %s,\n", v.name, param)
+	}
+	vars := "nil"
+	if variables.Len() > 0 {
+		vars = "map[string]interface{}{\n" + variables.String() + "\t}"
+	}
+	fmt.Fprintf(&g.decls, `
+// %s executes the %s %s with client. The response is returned with errors too,
+// populated with the data of partial responses.
+func %s(ctx context.Context, client *graphql.Client%s) (*%s, error) {
+	var response %s
+	err := client.%s(ctx, graphql.ManualRequest{Query: %s, Result: &response}, %s)
+	return &response, err
+}
+`, function, op.name, op.kind, function, params.String(), response, response, method, document, vars)
+	g.decls.WriteString(decls)
+}
+
+// declare returns name, or name with a number if it's already declared, and declares it.
+func (g *generator) declare(name string) string {
+	declared := name
+	for i := 2; g.declared[declared] || g.types[declared] != nil; i++ {
+		declared = fmt.Sprintf("%s%d", name, i)
+	}
+	g.declared[declared] = true
+	return declared
+}
+
+// collectedField is a field of a selection set, with the selections of all its occurrences.
+type collectedField struct {
+	key        string
+	field      *introspection.Field
+	selections []*selection
+	// optional is whether the field may be missing from responses, under a condition.
+	optional bool
+}
+
+// collect returns the fields selected by selections on the type t, in order, flattening fragments.
+// optional is whether the selections are conditional.
+func (g *generator) collect(t *introspection.Type, selections []*selection, optional bool) []*collectedField {
+	var fields []*collectedField
+	add := func(f *collectedField) {
+		for _, existing := range fields {
+			if existing.key == f.key {
+				existing.selections = append(existing.selections, f.selections...)
+				existing.optional = existing.optional && f.optional
+				return
+			}
+		}
+		fields = append(fields, f)
+	}
+	fragment := func(on string, selections []*selection, conditional bool) {
+		condition := t
+		if on != "" {
+			condition = g.types[on]
+		}
+		narrower := on != "" && on != *t.Name()
+		for _, f := range g.collect(condition, selections, optional || conditional || narrower) {
+			add(f)
+		}
+	}
+	for _, s := range selections {
+		switch {
+		case s.spread != "":
+			f := g.doc.fragments[s.spread]
+			fragment(f.on, f.selections, s.conditional)
+		case s.inline:
+			fragment(s.on, s.selections, s.conditional)
+		default:
+			add(&collectedField{key: s.key(), field: g.field(t, s.name), selections: s.selections, optional: optional || s.conditional})
+		}
+	}
+	return fields
+}
+
+// field returns the field name of the type t, or nil for __typename.
+func (g *generator) field(t *introspection.Type, name string) *introspection.Field {
+	fields, ok := g.fields[*t.Name()]
+	if !ok {
+		fields = make(map[string]*introspection.Field)
+		if list := t.Fields(&struct{ IncludeDeprecated bool }{true}); list != nil {
+			for _, f := range *list {
+				fields[f.Name()] = f
+			}
+		}
+		g.fields[*t.Name()] = fields
+	}
+	return fields[name]
+}
+
+// structType returns the declarations of the struct type name, described by doc, for the selections on the type t,
+// followed by those of the types of its fields, named with prefix and their names, e.g. "GetUserUserFriends".
+func (g *generator) structType(name, prefix, doc string, t *introspection.Type, selections []*selection) string {
+	var fields, nested bytes.Buffer
+	names := make(map[string]bool)
+	for _, f := range g.collect(t, selections, false) {
+		fieldName := goName(f.key)
+		for i := 2; names[fieldName]; i++ {
+			fieldName = fmt.Sprintf("%s%d", goName(f.key), i)
+		}
+		names[fieldName] = true
+		var typ string
+		if f.field == nil {
+			// __typename.
+			typ = "string"
+			if f.optional {
+				typ = "*string"
+			}
+		} else {
+			fieldType := f.field.Type()
+			if f.optional && fieldType.Kind() == "NON_NULL" {
+				fieldType = fieldType.OfType()
+			}
+			typ = g.outputType(fieldType, prefix+fieldName, fmt.Sprintf("the %s field of %s", f.key, name), f.selections, false, &nested)
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:%q`\n", fieldName, typ, f.key)
+	}
+	return fmt.Sprintf("\n// %s is %s.\ntype %s struct {\n%s}\n", name, doc, name, fields.String()) + nested.String()
+}
+
+// outputType returns the Go type of the values of the type t of a field in responses. The types of selection sets
+// are named name and described by doc, and their declarations are written to decls.
+func (g *generator) outputType(t *introspection.Type, name, doc string, selections []*selection, nonNull bool, decls *bytes.Buffer) string {
+	var typ string
+	switch t.Kind() {
+	case "NON_NULL":
+		return g.outputType(t.OfType(), name, doc, selections, true, decls)
+	case "LIST":
+		return "[]" + g.outputType(t.OfType(), name, doc, selections, false, decls)
+	case "OBJECT", "INTERFACE", "UNION":
+		typ = g.declare(name)
+		decls.WriteString(g.structType(typ, typ, doc, t, selections))
+	default:
+		typ = g.namedType(t)
+	}
+	if !nonNull {
+		return "*" + typ
+	}
+	return typ
+}
+
+// inputType returns the Go type of the values of variables of type t.
+func (g *generator) inputType(t *typeRef) string {
+	var typ string
+	if t.elem != nil {
+		typ = "[]" + g.inputType(t.elem)
+	} else {
+		typ = g.namedType(g.types[t.name])
+	}
+	if t.nonNull || t.elem != nil {
+		return typ
+	}
+	return "*" + typ
+}
+
+// inputFieldType returns the Go type of the values of fields of input objects of type t.
+func (g *generator) inputFieldType(t *introspection.Type, nonNull bool) string {
+	switch t.Kind() {
+	case "NON_NULL":
+		return g.inputFieldType(t.OfType(), true)
+	case "LIST":
+		return "[]" + g.inputFieldType(t.OfType(), false)
+	}
+	typ := g.namedType(t)
+	if !nonNull {
+		return "*" + typ
+	}
+	return typ
+}
+
+// namedType returns the Go type of the scalar, enum or input object type t, which is declared if needed.
+func (g *generator) namedType(t *introspection.Type) string {
+	name := *t.Name()
+	if t.Kind() == "ENUM" || t.Kind() == "INPUT_OBJECT" {
+		g.named[name] = true
+		return name
+	}
+	if typ, ok := g.config.Scalars[name]; ok {
+		return g.importType(typ)
+	}
+	switch name {
+	case "ID", "String":
+		return "string"
+	case "Int":
+		return "int"
+	case "Float":
+		return "float64"
+	case "Boolean":
+		return "bool"
+	}
+	g.fail("no Go type for the scalar %s; set it in Config.Scalars", name)
+	return "interface{}"
+}
+
+// importType returns the Go type typ, qualified by its import path if any, with its package name,
+// and imports its package.
+func (g *generator) importType(typ string) string {
+	i := strings.LastIndex(typ, ".")
+	if i < 0 {
+		return typ
+	}
+	importPath := typ[:i]
+	g.imports[importPath] = true
+	if importPath == clientPackage {
+		return "graphql" + typ[i:]
+	}
+	return path.Base(importPath) + typ[i:]
+}
+
+// namedTypes writes the declarations of the enum and input object types used, by name, including
+// those used by the input objects declared.
+func (g *generator) namedTypes() {
+	declared := make(map[string]bool)
+	for len(declared) < len(g.named) {
+		names := make([]string, 0, len(g.named))
+		for name := range g.named {
+			if !declared[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			declared[name] = true
+			t := g.types[name]
+			if t.Kind() == "ENUM" {
+				g.enumType(t)
+			} else {
+				g.inputObjectType(t)
+			}
+		}
+	}
+}
+
+// enumType writes the declaration of the enum type t.
+func (g *generator) enumType(t *introspection.Type) {
+	name := *t.Name()
+	var consts, cases []string
+	for _, v := range *t.EnumValues(&struct{ IncludeDeprecated bool }{true}) {
+		value := name + ident.ParseScreamingSnakeCase(v.Name()).ToMixedCaps()
+		consts = append(consts, fmt.Sprintf("\t%s %s = %q\n", value, name, v.Name()))
+		cases = append(cases, value)
+	}
+	fmt.Fprintf(&g.decls, `
+// %s is the %s enum.
+type %s string
+
+const (
+%s)
+
+// IsValid reports whether e is a value of %s known to the client, see graphql.GraphQLEnum.
+func (e %s) IsValid() bool {
+	switch e {
+	case %s:
+		return true
+	}
+	return false
+}
+`, name, name, name, strings.Join(consts, ""), name, name, strings.Join(cases, ", "))
+}
+
+// inputObjectType writes the declaration of the input object type t.
+func (g *generator) inputObjectType(t *introspection.Type) {
+	name := *t.Name()
+	var fields bytes.Buffer
+	for _, f := range *t.InputFields() {
+		typ := g.inputFieldType(f.Type(), false)
+		tag := f.Name()
+		if f.Type().Kind() != "NON_NULL" {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:%q`\n", goName(f.Name()), typ, tag)
+	}
+	fmt.Fprintf(&g.decls, "\n// %s is the %s input object.\ntype %s struct {\n%s}\n", name, name, name, fields.String())
+}
+
+// goName returns the exported Go name of the GraphQL name, e.g. "AvatarURL" for "avatarUrl",
+// or "CreatedAt" for "created_at".
+func goName(name string) string {
+	var words ident.Name
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			words = append(words, ident.ParseLowerCamelCase(part)...)
+		}
+	}
+	return words.ToMixedCaps()
+}
+
+// goParam returns the name of the parameter of the variable name, renamed if it's a Go keyword
+// or conflicts with the other names of generated functions.
+func goParam(name string) string {
+	switch {
+	case token.IsKeyword(name), name == "ctx", name == "client", name == "response", name == "err", name == "graphql":
+		return name + "Arg"
+	}
+	return name
+}
+
+// goString returns s as a Go string literal, raw if possible.
+func goString(s string) string {
+	if strings.Contains(s, "`") || strings.Contains(s, "\r") {
+		return fmt.Sprintf("%q", s)
+	}
+	return "`" + s + "`"
+}
@@ -0,0 +1,200 @@
+package codegen_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client/codegen"
+)
+
+const schema = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+scalar DateTime
+
+enum Role {
+	ADMIN
+	READ_ONLY
+}
+
+interface Node {
+	id: ID!
+}
+
+type User implements Node {
+	id: ID!
+	name: String!
+	bio: String
+	role: Role!
+	createdAt: DateTime!
+	friends(first: Int): [User!]!
+}
+
+type Bot implements Node {
+	id: ID!
+	owner: User
+}
+
+input UpdateUserInput {
+	id: ID!
+	bio: String
+	role: Role
+}
+
+type Query {
+	user(id: ID!): User
+	node(id: ID!): Node
+	search(text: String!, limit: Int): [Node]
+}
+
+type Mutation {
+	updateUser(input: UpdateUserInput!): User!
+}
+`
+
+const operations = `
+query GetUser($id: ID!, $first: Int) {
+	user(id: $id) {
+		...UserFields
+		friends(first: $first) {
+			name
+		}
+	}
+}
+
+# Searches users and bots.
+query Search($text: String!) {
+	search(text: $text) {
+		__typename
+		id
+		... on User {
+			handle: name
+		}
+		... on Bot {
+			owner @include(if: true) {
+				name
+			}
+		}
+	}
+}
+
+mutation UpdateUser($input: UpdateUserInput!) {
+	updateUser(input: $input) {
+		id
+		bio
+	}
+}
+
+fragment UserFields on User {
+	id
+	name
+	role
+	createdAt
+}
+`
+
+func TestGenerate(t *testing.T) {
+	src, err := codegen.Generate(codegen.Config{
+		Package:    "api",
+		Schema:     schema,
+		Operations: []string{operations},
+		Scalars:    map[string]string{"DateTime": "time.Time"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(src)
+	for _, want := range []string{
+		"// Code generated by graphqlgen. DO NOT EDIT.\n\npackage api\n",
+		`import (
+	"context"
+	graphql "github.com/darrensapalo/go-graphql-client"
+	"time"
+)`,
+		"const GetUserDocument = `query GetUser($id: ID!, $first: Int) {",
+		// Fragments are appended to the documents of the operations that spread them.
+		"}\n\nfragment UserFields on User {\n\tid\n\tname\n\trole\n\tcreatedAt\n}`",
+		`type GetUserResponse struct {
+	User *GetUserUser ` + "`json:\"user\"`" + `
+}`,
+		`type GetUserUser struct {
+	ID        string               ` + "`json:\"id\"`" + `
+	Name      string               ` + "`json:\"name\"`" + `
+	Role      Role                 ` + "`json:\"role\"`" + `
+	CreatedAt time.Time            ` + "`json:\"createdAt\"`" + `
+	Friends   []GetUserUserFriends ` + "`json:\"friends\"`" + `
+}`,
+		`func GetUser(ctx context.Context, client *graphql.Client, id string, first *int) (*GetUserResponse, error) {
+	var response GetUserResponse
+	err := client.Query(ctx, graphql.ManualRequest{Query: GetUserDocument, Result: &response}, map[string]interface{}{
+		"id":    id,
+		"first": first,
+	})
+	return &response, err
+}`,
+		// Fields of fragments on narrower types may be missing.
+		`type SearchSearch struct {
+	Typename string             ` + "`json:\"__typename\"`" + `
+	ID       string             ` + "`json:\"id\"`" + `
+	Handle   *string            ` + "`json:\"handle\"`" + `
+	Owner    *SearchSearchOwner ` + "`json:\"owner\"`" + `
+}`,
+		"Search []*SearchSearch `json:\"search\"`",
+		`func Search(ctx context.Context, client *graphql.Client, text string) (*SearchResponse, error) {`,
+		`err := client.Mutate(ctx, graphql.ManualRequest{Query: UpdateUserDocument, Result: &response}, map[string]interface{}{`,
+		`type UpdateUserInput struct {
+	ID   string  ` + "`json:\"id\"`" + `
+	Bio  *string ` + "`json:\"bio,omitempty\"`" + `
+	Role *Role   ` + "`json:\"role,omitempty\"`" + `
+}`,
+		`const (
+	RoleAdmin    Role = "ADMIN"
+	RoleReadOnly Role = "READ_ONLY"
+)`,
+		`func (e Role) IsValid() bool {
+	switch e {
+	case RoleAdmin, RoleReadOnly:
+		return true
+	}
+	return false
+}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("generated code doesn't contain:\n%s\n\ngenerated code:\n%s", want, got)
+		}
+	}
+}
+
+func TestGenerate_errors(t *testing.T) {
+	tests := []struct {
+		operations string
+		scalars    map[string]string
+		want       string
+	}{
+		{
+			operations: `{ user(id: "1") { name } }`,
+			want:       "operations must be named",
+		},
+		{
+			operations: `query GetUser { user(id: "1") { nickname } }`,
+			want:       `operation GetUser: graphql: Cannot query field "nickname" on type "User".`,
+		},
+		{
+			operations: `query GetUser { user(id: "1") { createdAt } }`,
+			want:       "operation GetUser: no Go type for the scalar DateTime; set it in Config.Scalars",
+		},
+		{
+			operations: `query GetUser { user(id: "1") { name }`,
+			want:       "operations: unexpected end of document, want name",
+		},
+	}
+	for _, tc := range tests {
+		_, err := codegen.Generate(codegen.Config{Package: "api", Schema: schema, Operations: []string{tc.operations}, Scalars: tc.scalars})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got error: %v, want: %s", tc.operations, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// document is an executable GraphQL document: operations and fragments.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation is an operation definition, e.g. "query GetUser($id: ID!) {user(id: $id) {name}}".
+type operation struct {
+	// kind is "query", "mutation" or "subscription".
+	kind       string
+	name       string
+	variables  []*variableDefinition
+	selections []*selection
+	// source is the text of the operation in its document.
+	source string
+}
+
+// variableDefinition is the definition of a variable of an operation, e.g. "$id: ID!".
+type variableDefinition struct {
+	name string
+	typ  *typeRef
+}
+
+// typeRef is a reference to a type of the schema, e.g. "[String!]".
+type typeRef struct {
+	// name is the name of a named type, or "" for a list.
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+// fragment is a fragment definition, e.g. "fragment UserFields on User {name}".
+type fragment struct {
+	name       string
+	on         string
+	selections []*selection
+	// source is the text of the fragment in its document.
+	source string
+}
+
+// selection is a field, an inline fragment or a fragment spread.
+type selection struct {
+	// alias and name are those of a field; alias is "" if the field isn't aliased.
+	alias string
+	name  string
+	// on is the type condition of an inline fragment, or "" if it has none.
+	on     string
+	inline bool
+	// spread is the name of a spread fragment.
+	spread string
+	// conditional is whether the selection has an @include or @skip directive, and may be missing from responses.
+	conditional bool
+	selections  []*selection
+}
+
+// key returns the response key of the field s.
+func (s *selection) key() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// lexeme is a lexical token of a document.
+type lexeme struct {
+	// kind is one of the token kinds below.
+	kind  int
+	value string
+	// offset is the offset of the token in the document.
+	offset int
+}
+
+// Kinds of tokens.
+const (
+	eofToken = iota
+	punctuatorToken
+	nameToken
+	valueToken // Numbers and strings.
+)
+
+// lex returns the tokens of source, without its insignificant characters: white space, commas and comments.
+func lex(source string) ([]lexeme, error) {
+	var tokens []lexeme
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(source) && source[i] != '\n' && source[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], "\ufeff"):
+			i += len("\ufeff")
+		case c == '"':
+			end, err := stringEnd(source, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, lexeme{kind: valueToken, value: source[i:end], offset: i})
+			i = end
+		case isNameStart(c):
+			start := i
+			for i < len(source) && (isNameStart(source[i]) || isDigit(source[i])) {
+				i++
+			}
+			tokens = append(tokens, lexeme{kind: nameToken, value: source[start:i], offset: start})
+		case isDigit(c) || c == '-':
+			start := i
+			for i++; i < len(source) && (isDigit(source[i]) || strings.IndexByte(".eE+-", source[i]) >= 0); i++ {
+			}
+			tokens = append(tokens, lexeme{kind: valueToken, value: source[start:i], offset: start})
+		case strings.HasPrefix(source[i:], "..."):
+			tokens = append(tokens, lexeme{kind: punctuatorToken, value: "...", offset: i})
+			i += len("...")
+		case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+			tokens = append(tokens, lexeme{kind: punctuatorToken, value: source[i : i+1], offset: i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+		}
+	}
+	return append(tokens, lexeme{kind: eofToken, offset: len(source)}), nil
+}
+
+// stringEnd returns the offset just after the string or block string starting at source[start].
+func stringEnd(source string, start int) (int, error) {
+	if strings.HasPrefix(source[start:], `"""`) {
+		for i := start + 3; i < len(source); i++ {
+			switch {
+			case strings.HasPrefix(source[i:], `\"""`):
+				i += 3
+			case strings.HasPrefix(source[i:], `"""`):
+				return i + 3, nil
+			}
+		}
+	} else {
+		for i := start + 1; i < len(source); i++ {
+			switch source[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, nil
+			case '\n', '\r':
+				i = len(source)
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// parser parses executable documents, which must be valid.
+type parser struct {
+	source string
+	tokens []lexeme
+	pos    int
+
+	// err is the first error parsing the document.
+	err error
+}
+
+// parse parses the executable document source.
+func parse(source string) (*document, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{source: source, tokens: tokens}
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.peek().kind != eofToken {
+		start := p.peek().offset
+		switch p.peek().value {
+		case "fragment":
+			p.next()
+			f := &fragment{name: p.name()}
+			p.expect("on")
+			f.on = p.name()
+			p.directives()
+			f.selections = p.selectionSet()
+			f.source = p.text(start)
+			doc.fragments[f.name] = f
+		case "{":
+			return nil, fmt.Errorf("anonymous operation at offset %d: operations must be named", start)
+		default:
+			op := &operation{kind: p.name()}
+			if op.kind != "query" && op.kind != "mutation" && op.kind != "subscription" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", op.kind, start)
+			}
+			if p.peek().kind != nameToken {
+				return nil, fmt.Errorf("anonymous %s at offset %d: operations must be named", op.kind, start)
+			}
+			op.name = p.name()
+			op.variables = p.variableDefinitions()
+			p.directives()
+			op.selections = p.selectionSet()
+			op.source = p.text(start)
+			doc.operations = append(doc.operations, op)
+		}
+		if p.err != nil {
+			return nil, p.err
+		}
+	}
+	return doc, p.err
+}
+
+// text returns the source text from start to the end of the last token read.
+func (p *parser) text(start int) string {
+	last := p.tokens[p.pos-1]
+	return p.source[start : last.offset+len(last.value)]
+}
+
+func (p *parser) peek() lexeme {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() lexeme {
+	t := p.tokens[p.pos]
+	if t.kind != eofToken {
+		p.pos++
+	}
+	return t
+}
+
+// expect reads the token value, recording an error if the next token is another one.
+func (p *parser) expect(value string) {
+	if t := p.next(); t.value != value {
+		p.fail(t, value)
+	}
+}
+
+// name reads a name.
+func (p *parser) name() string {
+	t := p.next()
+	if t.kind != nameToken {
+		p.fail(t, "name")
+	}
+	return t.value
+}
+
+// fail records an unexpected token t, when want was expected, and skips to the end of the document.
+func (p *parser) fail(t lexeme, want string) {
+	if p.err == nil && t.kind == eofToken {
+		p.err = fmt.Errorf("unexpected end of document, want %s", want)
+	} else if p.err == nil {
+		p.err = fmt.Errorf("unexpected %q at offset %d, want %s", t.value, t.offset, want)
+	}
+	p.pos = len(p.tokens) - 1
+}
+
+// variableDefinitions reads variable definitions, if any.
+func (p *parser) variableDefinitions() []*variableDefinition {
+	if p.peek().value != "(" {
+		return nil
+	}
+	p.next()
+	var variables []*variableDefinition
+	for p.peek().value != ")" && p.err == nil {
+		p.expect("$")
+		v := &variableDefinition{name: p.name()}
+		p.expect(":")
+		v.typ = p.typeRef()
+		if p.peek().value == "=" {
+			p.next()
+			p.value()
+		}
+		p.directives()
+		variables = append(variables, v)
+	}
+	p.expect(")")
+	return variables
+}
+
+// typeRef reads a type reference.
+func (p *parser) typeRef() *typeRef {
+	var t *typeRef
+	if p.peek().value == "[" {
+		p.next()
+		t = &typeRef{elem: p.typeRef()}
+		p.expect("]")
+	} else {
+		t = &typeRef{name: p.name()}
+	}
+	if p.peek().value == "!" {
+		p.next()
+		t.nonNull = true
+	}
+	return t
+}
+
+// value skips a value, e.g. an argument or a default value.
+func (p *parser) value() {
+	t := p.next()
+	switch t.value {
+	case "$":
+		p.name()
+	case "[":
+		for p.peek().value != "]" && p.err == nil {
+			p.value()
+		}
+		p.expect("]")
+	case "{":
+		for p.peek().value != "}" && p.err == nil {
+			p.name()
+			p.expect(":")
+			p.value()
+		}
+		p.expect("}")
+	default:
+		if t.kind != nameToken && t.kind != valueToken {
+			p.fail(t, "value")
+		}
+	}
+}
+
+// arguments skips arguments, if any.
+func (p *parser) arguments() {
+	if p.peek().value != "(" {
+		return
+	}
+	p.next()
+	for p.peek().value != ")" && p.err == nil {
+		p.name()
+		p.expect(":")
+		p.value()
+	}
+	p.expect(")")
+}
+
+// directives skips directives, if any, and reports whether they include @include or @skip.
+func (p *parser) directives() bool {
+	conditional := false
+	for p.peek().value == "@" {
+		p.next()
+		if name := p.name(); name == "include" || name == "skip" {
+			conditional = true
+		}
+		p.arguments()
+	}
+	return conditional
+}
+
+// selectionSet reads a selection set.
+func (p *parser) selectionSet() []*selection {
+	p.expect("{")
+	var selections []*selection
+	for p.peek().value != "}" && p.err == nil {
+		selections = append(selections, p.selection())
+	}
+	p.expect("}")
+	return selections
+}
+
+// selection reads a field, an inline fragment or a fragment spread.
+func (p *parser) selection() *selection {
+	s := &selection{}
+	if p.peek().value == "..." {
+		p.next()
+		switch {
+		case p.peek().value == "on":
+			p.next()
+			s.inline, s.on = true, p.name()
+		case p.peek().kind == nameToken:
+			s.spread = p.name()
+			s.conditional = p.directives()
+			return s
+		default:
+			s.inline = true
+		}
+		s.conditional = p.directives()
+		s.selections = p.selectionSet()
+		return s
+	}
+	s.name = p.name()
+	if p.peek().value == ":" {
+		p.next()
+		s.alias, s.name = s.name, p.name()
+	}
+	p.arguments()
+	s.conditional = p.directives()
+	if p.peek().value == "{" {
+		s.selections = p.selectionSet()
+	}
+	return s
+}
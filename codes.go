@@ -0,0 +1,105 @@
+package graphql
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error codes, in the "code" extension of GraphQL errors, used by Apollo Server and other servers.
+//
+// Reference: https://www.apollographql.com/docs/apollo-server/data/errors/#built-in-error-codes.
+const (
+	// CodeGraphQLParseFailed is the code of errors for queries that aren't valid GraphQL syntax.
+	CodeGraphQLParseFailed = "GRAPHQL_PARSE_FAILED"
+
+	// CodeGraphQLValidationFailed is the code of errors for queries that aren't valid against the schema.
+	CodeGraphQLValidationFailed = "GRAPHQL_VALIDATION_FAILED"
+
+	// CodeBadUserInput is the code of errors for invalid values of arguments or variables.
+	CodeBadUserInput = "BAD_USER_INPUT"
+
+	// CodeUnauthenticated is the code of errors for requests without valid credentials.
+	CodeUnauthenticated = "UNAUTHENTICATED"
+
+	// CodeForbidden is the code of errors for requests not allowed for their credentials.
+	CodeForbidden = "FORBIDDEN"
+
+	// CodePersistedQueryNotFound is the code of errors for persisted queries unknown to the server.
+	CodePersistedQueryNotFound = "PERSISTED_QUERY_NOT_FOUND"
+
+	// CodePersistedQueryNotSupported is the code of errors for servers that don't support persisted queries.
+	CodePersistedQueryNotSupported = "PERSISTED_QUERY_NOT_SUPPORTED"
+
+	// CodeOperationResolutionFailure is the code of errors for operations that can't be determined,
+	// e.g. when a document has several operations and no operation name is given.
+	CodeOperationResolutionFailure = "OPERATION_RESOLUTION_FAILURE"
+
+	// CodeBadRequest is the code of errors for requests that can't be parsed.
+	CodeBadRequest = "BAD_REQUEST"
+
+	// CodeInternalServerError is the code of unexpected errors of the server.
+	CodeInternalServerError = "INTERNAL_SERVER_ERROR"
+
+	// CodeRateLimited is the code of errors for requests rejected by a rate limit.
+	// It isn't built into Apollo Server, but is commonly used.
+	CodeRateLimited = "RATE_LIMITED"
+)
+
+// Code returns the "code" extension of the error, or "" if it has none.
+func (e *GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// Code returns the code of the first GraphQL error in err that has one, or "" if there is none.
+// GraphQL errors are found with errors.As, including those in the body of an *HTTPError.
+func Code(err error) string {
+	var errs GraphQLErrors
+	if !errors.As(err, &errs) {
+		return ""
+	}
+	for i := range errs {
+		if code := errs[i].Code(); code != "" {
+			return code
+		}
+	}
+	return ""
+}
+
+// HasCode reports whether any GraphQL error in err has the given code.
+func HasCode(err error, code string) bool {
+	var errs GraphQLErrors
+	if !errors.As(err, &errs) {
+		return false
+	}
+	for i := range errs {
+		if errs[i].Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUnauthenticated reports whether err is a GraphQL error with the code CodeUnauthenticated,
+// or an *HTTPError with the status code 401 Unauthorized.
+func IsUnauthenticated(err error) bool {
+	return HasCode(err, CodeUnauthenticated) || hasStatusCode(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is a GraphQL error with the code CodeForbidden,
+// or an *HTTPError with the status code 403 Forbidden.
+func IsForbidden(err error) bool {
+	return HasCode(err, CodeForbidden) || hasStatusCode(err, http.StatusForbidden)
+}
+
+// IsRateLimited reports whether err is a GraphQL error with the code CodeRateLimited,
+// or an *HTTPError with the status code 429 Too Many Requests.
+func IsRateLimited(err error) bool {
+	return HasCode(err, CodeRateLimited) || hasStatusCode(err, http.StatusTooManyRequests)
+}
+
+// hasStatusCode reports whether err is an *HTTPError with the given status code.
+func hasStatusCode(err error, statusCode int) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == statusCode
+}
@@ -0,0 +1,56 @@
+package graphql_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestCode(t *testing.T) {
+	unauthenticated := graphql.GraphQLErrors{
+		{Message: "no code"},
+		{Message: "not logged in", Extensions: map[string]interface{}{"code": graphql.CodeUnauthenticated}},
+	}
+	rateLimited := &graphql.HTTPError{StatusCode: http.StatusBadRequest, Errors: graphql.GraphQLErrors{
+		{Message: "slow down", Extensions: map[string]interface{}{"code": graphql.CodeRateLimited}},
+	}}
+	tests := []struct {
+		name            string
+		err             error
+		wantCode        string
+		wantUnauth      bool
+		wantForbidden   bool
+		wantRateLimited bool
+	}{
+		{"nil", nil, "", false, false, false},
+		{"other", errors.New("boom"), "", false, false, false},
+		{"GraphQL errors", unauthenticated, graphql.CodeUnauthenticated, true, false, false},
+		{"wrapped GraphQL errors", fmt.Errorf("query viewer: %w", unauthenticated), graphql.CodeUnauthenticated, true, false, false},
+		{"HTTP error with GraphQL errors", rateLimited, graphql.CodeRateLimited, false, false, true},
+		{"401", &graphql.HTTPError{StatusCode: http.StatusUnauthorized}, "", true, false, false},
+		{"403", &graphql.HTTPError{StatusCode: http.StatusForbidden}, "", false, true, false},
+		{"429", &graphql.HTTPError{StatusCode: http.StatusTooManyRequests}, "", false, false, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := graphql.Code(tc.err); got != tc.wantCode {
+				t.Errorf("got Code: %q, want: %q", got, tc.wantCode)
+			}
+			if got := graphql.IsUnauthenticated(tc.err); got != tc.wantUnauth {
+				t.Errorf("got IsUnauthenticated: %v, want: %v", got, tc.wantUnauth)
+			}
+			if got := graphql.IsForbidden(tc.err); got != tc.wantForbidden {
+				t.Errorf("got IsForbidden: %v, want: %v", got, tc.wantForbidden)
+			}
+			if got := graphql.IsRateLimited(tc.err); got != tc.wantRateLimited {
+				t.Errorf("got IsRateLimited: %v, want: %v", got, tc.wantRateLimited)
+			}
+		})
+	}
+	if !graphql.HasCode(unauthenticated, graphql.CodeUnauthenticated) || graphql.HasCode(unauthenticated, graphql.CodeForbidden) {
+		t.Error("HasCode didn't find the code of the second error only")
+	}
+}
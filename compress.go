@@ -0,0 +1,98 @@
+package graphql
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WithRequestCompression returns a copy of the client that compresses request bodies of at least minSize bytes
+// with gzip, and sends them with the Content-Encoding: gzip header. The server must accept compressed requests.
+//
+// Streamed bodies, such as those of file uploads, are not compressed.
+func (c *Client) WithRequestCompression(minSize int) *Client {
+	c2 := c.clone()
+	c2.compressRequests = true
+	c2.compressMinSize = minSize
+	return c2
+}
+
+// compress returns body compressed with gzip, if the client compresses requests and body is large enough.
+func (c *Client) compress(body requestBody) (requestBody, error) {
+	if !c.compressRequests || body.open != nil || body.contentEncoding != "" || len(body.data) < c.compressMinSize {
+		return body, nil
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body.data); err != nil {
+		return requestBody{}, err
+	}
+	if err := w.Close(); err != nil {
+		return requestBody{}, err
+	}
+	body.data = buf.Bytes()
+	body.contentEncoding = "gzip"
+	return body, nil
+}
+
+// acceptEncoding is the built-in Accept-Encoding header, listing the response encodings decompressed by the client.
+const acceptEncoding = "gzip, deflate"
+
+// decompressResponse replaces the body of a compressed response with a reader decompressing it as it is read,
+// so that large responses are never held in memory in full.
+func decompressResponse(resp *http.Response) error {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if resp.Uncompressed || (encoding != "gzip" && encoding != "deflate") {
+		return nil
+	}
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("decompressing response: %v", err)
+		}
+		r = zr
+	case "deflate":
+		// "deflate" is meant to be zlib-wrapped, but some servers send raw DEFLATE data.
+		br := bufio.NewReader(resp.Body)
+		header, err := br.Peek(2)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("decompressing response: %v", err)
+		}
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return fmt.Errorf("decompressing response: %v", err)
+			}
+			r = zr
+		} else {
+			r = flate.NewReader(br)
+		}
+	}
+	resp.Body = decompressedBody{Reader: r, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// decompressedBody reads the decompressed content of a response body, and closes the body.
+type decompressedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (d decompressedBody) Close() error {
+	if c, ok := d.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return d.body.Close()
+}
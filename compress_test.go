@@ -0,0 +1,104 @@
+package graphql_test
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_WithRequestCompression(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		body := req.Body
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = zr
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"echo": `+strings.TrimSpace(mustRead(body))+`}}`)
+	})
+
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{query: "{a}", want: ""},
+		{query: "{" + strings.Repeat("a,", 100) + "a}", want: "gzip"},
+	} {
+		var gotEncoding string
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+			WithRequestCompression(100).
+			WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+				return graphql.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					gotEncoding = req.Header.Get("Content-Encoding")
+					return next.RoundTrip(req)
+				})
+			})
+		var q struct {
+			Echo struct {
+				Query string
+			}
+		}
+		err := client.Query(context.Background(), graphql.ManualRequest{Query: tc.query, Result: &q}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotEncoding != tc.want {
+			t.Errorf("got Content-Encoding: %q, want: %q", gotEncoding, tc.want)
+		}
+		if q.Echo.Query != tc.query {
+			t.Errorf("got query: %q, want: %q", q.Echo.Query, tc.query)
+		}
+	}
+}
+
+func TestClient_Query_compressedResponse(t *testing.T) {
+	const body = `{"data": {"viewer": {"login": "gopher"}}}`
+	for _, tc := range []struct {
+		encoding string
+		compress func(w io.Writer) io.WriteCloser
+	}{
+		{encoding: "gzip", compress: func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }},
+		{encoding: "deflate", compress: func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }},
+		{encoding: "deflate", compress: func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		}},
+	} {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+			if got, want := req.Header.Get("Accept-Encoding"), "gzip, deflate"; got != want {
+				t.Errorf("got Accept-Encoding: %q, want: %q", got, want)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", tc.encoding)
+			zw := tc.compress(w)
+			mustWrite(zw, body)
+			zw.Close()
+		})
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+		var q struct {
+			Viewer struct {
+				Login graphql.String
+			}
+		}
+		err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.encoding, err)
+		}
+		if q.Viewer.Login != "gopher" {
+			t.Errorf("%s: got login: %q, want: %q", tc.encoding, q.Viewer.Login, "gopher")
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Credentials are the secrets used to authenticate requests.
+type Credentials struct {
+	// Token, if set, is sent as a bearer token in the Authorization header.
+	Token string
+
+	// Header holds other authentication headers, such as API keys.
+	Header http.Header
+
+	// Expiry is when the credentials must be fetched again, so that rotated secrets are picked up.
+	// The zero value means the credentials never expire.
+	Expiry time.Time
+}
+
+// CredentialsProvider fetches credentials, typically from a secret store.
+// See package github.com/darrensapalo/go-graphql-client/secrets for adapters
+// to HashiCorp Vault and AWS Secrets Manager.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// WithCredentialsProvider returns a copy of the client that authenticates requests with credentials from p.
+// Credentials are cached until their expiry, then fetched again.
+//
+// The provider replaces any other credentials configured on the client.
+func (c *Client) WithCredentialsProvider(p CredentialsProvider) *Client {
+	cache := &credentialsCache{provider: p}
+	c2 := c.clone()
+	c2.credentials = func(ctx context.Context, req *http.Request) error {
+		creds, err := cache.get(ctx)
+		if err != nil {
+			return err
+		}
+		for key, values := range creds.Header {
+			req.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+		if creds.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+creds.Token)
+		}
+		return nil
+	}
+	return c2
+}
+
+// credentialsCache caches the credentials of a provider until they expire.
+type credentialsCache struct {
+	provider CredentialsProvider
+
+	mu    sync.Mutex
+	creds *Credentials
+}
+
+func (c *credentialsCache) get(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.creds != nil && (c.creds.Expiry.IsZero() || time.Now().Before(c.creds.Expiry)) {
+		return *c.creds, nil
+	}
+	creds, err := c.provider.Credentials(ctx)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("fetching credentials: %v", err)
+	}
+	c.creds = &creds
+	return creds, nil
+}
@@ -0,0 +1,73 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+// countingProvider is a CredentialsProvider returning a new API key on every fetch.
+type countingProvider struct {
+	fetches int
+	expiry  time.Duration
+}
+
+func (p *countingProvider) Credentials(context.Context) (graphql.Credentials, error) {
+	p.fetches++
+	creds := graphql.Credentials{
+		Token:  "token",
+		Header: http.Header{"X-Api-Key": {string(rune('0' + p.fetches))}},
+	}
+	if p.expiry != 0 {
+		creds.Expiry = time.Now().Add(p.expiry)
+	}
+	return creds, nil
+}
+
+func TestClient_WithCredentialsProvider(t *testing.T) {
+	var gotKeys []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.Header.Get("Authorization"), "Bearer token"; got != want {
+			t.Errorf("got Authorization: %q, want: %q", got, want)
+		}
+		gotKeys = append(gotKeys, req.Header.Get("X-Api-Key"))
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+	})
+
+	for _, tc := range []struct {
+		expiry time.Duration
+		want   string
+	}{
+		{expiry: 0, want: "11"},
+		{expiry: time.Hour, want: "11"},
+		{expiry: -time.Second, want: "12"},
+	} {
+		gotKeys = nil
+		p := &countingProvider{expiry: tc.expiry}
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+			WithCredentialsProvider(p)
+		for i := 0; i < 2; i++ {
+			var q struct {
+				Viewer struct {
+					Login graphql.String
+				}
+			}
+			err := client.Query(context.Background(), graphql.ManualRequest{
+				Query:     "{viewer{login}}",
+				Variables: map[string]interface{}{},
+				Result:    &q,
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := gotKeys[0] + gotKeys[1]; got != tc.want {
+			t.Errorf("expiry %v: got API keys %q, want: %q", tc.expiry, got, tc.want)
+		}
+	}
+}
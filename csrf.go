@@ -0,0 +1,130 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// CSRFConfig configures how the client obtains a CSRF token
+// and attaches it to mutations, as required by some session-authenticated servers.
+type CSRFConfig struct {
+	// CookieName is the name of the cookie holding the CSRF token.
+	// The cookie is looked up in the client's cookie jar for the URL each mutation is sent to,
+	// which differs from the GraphQL server URL after a failover to another endpoint, see WithEndpoints.
+	CookieName string
+
+	// HeaderName is the request header the token is sent in.
+	// If a response carries this header, its value replaces the current token.
+	//
+	// Defaults to "X-CSRF-Token".
+	HeaderName string
+
+	// PreflightURL, if set, is requested with GET when no token is known yet.
+	// The token is read from the HeaderName response header, or from the CookieName cookie it sets.
+	PreflightURL string
+}
+
+// csrfState holds the CSRF configuration and the last token seen.
+// It is shared by a client and the clients derived from it.
+type csrfState struct {
+	config CSRFConfig
+
+	mu    sync.Mutex
+	token string
+}
+
+// WithCookieJar returns a copy of the client whose HTTP client stores and sends cookies using jar.
+// The original http.Client is left untouched.
+func (c *Client) WithCookieJar(jar http.CookieJar) *Client {
+	c2 := c.clone()
+	httpClient := *c.httpClient
+	httpClient.Jar = jar
+	c2.httpClient = &httpClient
+	return c2
+}
+
+// WithCSRF returns a copy of the client that attaches a CSRF token to every mutation.
+// Reading the token from a cookie requires a cookie jar, see WithCookieJar.
+func (c *Client) WithCSRF(config CSRFConfig) *Client {
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	c2 := c.clone()
+	c2.csrf = &csrfState{config: config}
+	return c2
+}
+
+// apply sets the CSRF header on req, fetching the token first if needed.
+func (s *csrfState) apply(ctx context.Context, c *Client, req *http.Request) error {
+	token, err := s.get(ctx, c, req.URL)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set(s.config.HeaderName, token)
+	}
+	return nil
+}
+
+// get returns the current CSRF token. The cookie, when present, takes precedence
+// over a token seen in a response header. If neither is available,
+// the preflight request is made. An empty token is returned if no source yields one.
+// The cookie is the one sent to u.
+func (s *csrfState) get(ctx context.Context, c *Client, u *url.URL) (string, error) {
+	if token := s.cookie(c, u); token != "" {
+		return token, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" || s.config.PreflightURL == "" {
+		return s.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.config.PreflightURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.doHTTP(req)
+	if err != nil {
+		return "", fmt.Errorf("CSRF preflight: %v", err)
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("CSRF preflight: non-200 OK status code: %v", resp.Status)
+	}
+
+	if token := resp.Header.Get(s.config.HeaderName); token != "" {
+		s.token = token
+		return token, nil
+	}
+	return s.cookie(c, u), nil
+}
+
+// cookie looks up the CSRF cookie sent to u in the client's cookie jar.
+func (s *csrfState) cookie(c *Client, u *url.URL) string {
+	if s.config.CookieName == "" || c.httpClient.Jar == nil {
+		return ""
+	}
+	for _, cookie := range c.httpClient.Jar.Cookies(u) {
+		if cookie.Name == s.config.CookieName {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// observe records a token rotated by the server through the response header.
+func (s *csrfState) observe(resp *http.Response) {
+	if token := resp.Header.Get(s.config.HeaderName); token != "" {
+		s.mu.Lock()
+		s.token = token
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,90 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_WithCSRF_preflight(t *testing.T) {
+	preflights := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/csrf", func(w http.ResponseWriter, req *http.Request) {
+		preflights++
+		w.Header().Set("X-CSRF-Token", "token-1")
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.Header.Get("X-CSRF-Token"), "token-1"; got != want {
+			t.Errorf("got X-CSRF-Token: %q, want: %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"addStar": {"starred": true}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithCSRF(graphql.CSRFConfig{PreflightURL: "/csrf"})
+
+	for i := 0; i < 2; i++ {
+		var m struct {
+			AddStar struct {
+				Starred graphql.Boolean
+			}
+		}
+		err := client.Mutate(context.Background(), graphql.ManualRequest{
+			Query:  "mutation { addStar { starred } }",
+			Result: &m,
+		}, nil)
+		if err != nil {
+			t.Fatalf("got error: %v, want: nil", err)
+		}
+		if !m.AddStar.Starred {
+			t.Errorf("got m.AddStar.Starred: false, want: true")
+		}
+	}
+	if preflights != 1 {
+		t.Errorf("got %d preflight requests, want: 1", preflights)
+	}
+}
+
+func TestClient_WithCSRF_cookie(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		token := req.Header.Get("X-XSRF-TOKEN")
+		if req.Header.Get("X-Operation") == "query" {
+			if token != "" {
+				t.Errorf("got X-XSRF-TOKEN: %q on query, want: none", token)
+			}
+			http.SetCookie(w, &http.Cookie{Name: "XSRF-TOKEN", Value: "from-cookie", Path: "/"})
+		} else if token != "from-cookie" {
+			t.Errorf("got X-XSRF-TOKEN: %q, want: %q", token, "from-cookie")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {}}`)
+	})
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := graphql.NewClient("http://example.com/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithCookieJar(jar).
+		WithCSRF(graphql.CSRFConfig{CookieName: "XSRF-TOKEN", HeaderName: "X-XSRF-TOKEN"})
+
+	var q struct{}
+	err = client.Query(context.Background(), graphql.ManualRequest{
+		Query:   "{ viewer { login } }",
+		Result:  &q,
+		Headers: http.Header{"X-Operation": {"query"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+	err = client.Mutate(context.Background(), graphql.ManualRequest{
+		Query:  "mutation { addStar { starred } }",
+		Result: &q,
+	}, nil)
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+}
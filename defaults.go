@@ -0,0 +1,127 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// defaultTypes maps types to whether they have fields with defaults, as reported by hasDefaults.
+var defaultTypes sync.Map // map[reflect.Type]bool
+
+// hasDefaults reports whether t has fields with a default tag, directly or in nested types.
+func hasDefaults(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if found, ok := defaultTypes.Load(t); ok {
+		return found.(bool)
+	}
+	found := findDefaults(t, map[reflect.Type]bool{})
+	defaultTypes.Store(t, found)
+	return found
+}
+
+// findDefaults reports whether t has fields with a default tag, skipping the types in seen.
+func findDefaults(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return findDefaults(t.Elem(), seen)
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+			return false
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if _, ok := f.Tag.Lookup("default"); ok || findDefaults(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyDefaults sets the fields of v with a default tag, e.g. `default:"42"`, that are null or missing in value,
+// the data of the response decoded into v, to their default.
+func applyDefaults(v reflect.Value, value interface{}) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return applyDefaults(v.Elem(), value)
+	case reflect.Slice, reflect.Array:
+		list, _ := value.([]interface{})
+		for i := 0; i < v.Len() && i < len(list); i++ {
+			if err := applyDefaults(v.Index(i), list[i]); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if reflect.PtrTo(v.Type()).Implements(jsonUnmarshaler) {
+			return nil
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, hasName := jsonName(f)
+			if f.Anonymous && !hasName {
+				// Embedded structs are inlined by encoding/json. Fields of fragments are missing from objects of other types.
+				if tag, ok := f.Tag.Lookup("graphql"); ok && strings.HasPrefix(strings.TrimSpace(tag), "...") {
+					continue
+				}
+				if err := applyDefaults(v.Field(i), value); err != nil {
+					return err
+				}
+				continue
+			}
+			if f.PkgPath != "" || name == "-" {
+				continue
+			}
+			_, fieldValue := lookupField(object, name)
+			if fieldValue != nil {
+				if err := applyDefaults(v.Field(i), fieldValue); err != nil {
+					return err
+				}
+				continue
+			}
+			if def, ok := f.Tag.Lookup("default"); ok {
+				if err := setDefault(v.Field(i), def); err != nil {
+					return fmt.Errorf("default of field %s: %w", f.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setDefault sets v to def. Strings, and pointers to strings, are set to def as is;
+// other types are decoded from def as JSON, e.g. "42", "true" or "[1,2]".
+func setDefault(v reflect.Value, def string) error {
+	t := v.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.String {
+		s := reflect.New(t).Elem()
+		s.SetString(def)
+		if v.Kind() == reflect.Ptr {
+			v.Set(reflect.New(t))
+			v.Elem().Set(s)
+		} else {
+			v.Set(s)
+		}
+		return nil
+	}
+	return json.Unmarshal([]byte(def), v.Addr().Interface())
+}
@@ -0,0 +1,39 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_Query_defaults(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"repositories": [
+			{"name": "go", "visibility": "PRIVATE", "stars": 10, "topics": ["lang"]},
+			{"name": "tools", "visibility": null, "stars": null}
+		]}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Repositories []struct {
+			Name       graphql.String
+			Visibility *graphql.String `default:"PUBLIC"`
+			Stars      graphql.Int     `default:"-1"`
+			Topics     []string        `default:"[\"none\"]"`
+		}
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{repositories{name,visibility,stars,topics}}", Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := q.Repositories[0]; *got.Visibility != "PRIVATE" || got.Stars != 10 || len(got.Topics) != 1 || got.Topics[0] != "lang" {
+		t.Errorf("got present fields: %v %v %v, want them unchanged", *got.Visibility, got.Stars, got.Topics)
+	}
+	if got := q.Repositories[1]; got.Visibility == nil || *got.Visibility != "PUBLIC" || got.Stars != -1 || len(got.Topics) != 1 || got.Topics[0] != "none" {
+		t.Errorf("got null and missing fields: %v %v %v, want their defaults", got.Visibility, got.Stars, got.Topics)
+	}
+}
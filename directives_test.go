@@ -0,0 +1,129 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_Query_directives(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Variables struct {
+				WithDetails bool
+			}
+		}
+		if err := json.Unmarshal([]byte(mustRead(req.Body)), &in); err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if in.Variables.WithDetails {
+			mustWrite(w, `{"data": {"repository": {"name": "go", "details": {"description": "The Go language"}}}}`)
+		} else {
+			mustWrite(w, `{"data": {"repository": {"name": "go"}}}`)
+		}
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	type query struct {
+		Repository struct {
+			Name    graphql.String
+			Details struct {
+				Description graphql.String
+			} `graphql:"details @include(if: $withDetails),required"`
+		} `graphql:"repository(name: \"go\")"`
+	}
+	for _, withDetails := range []bool{false, true} {
+		var q query
+		variables := map[string]interface{}{"withDetails": withDetails}
+		document, err := graphql.ConstructQuery(&q, variables, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `query ($withDetails:Boolean!){repository(name: "go"){name,details @include(if: $withDetails){description}}}`; document != want {
+			t.Errorf("got query: %v, want: %v", document, want)
+		}
+		if err := client.Query(context.Background(), graphql.ManualRequest{Query: document, Result: &q}, variables); err != nil {
+			t.Fatal(err)
+		}
+		want := graphql.String("")
+		if withDetails {
+			want = "The Go language"
+		}
+		if got := q.Repository.Details.Description; got != want {
+			t.Errorf("got description with details %v: %q, want: %q", withDetails, got, want)
+		}
+	}
+}
+
+func TestClient_Query_fieldDirectives(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"repository": {"latest": [{"title": "a"}], "droid": "astromech"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Repository struct {
+			Issues []struct {
+				Title graphql.String
+			} `graphql:"latest: issues(last: 1) @stream(initialCount: 10) @cached(ttl: 60)"`
+			Droid struct {
+				PrimaryFunction graphql.String `graphql:"droid"`
+			} `graphql:"... on Droid @defer(label: \"droid\")"`
+		}
+	}
+	document, err := graphql.ConstructQuery(&q, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{repository{latest: issues(last: 1) @stream(initialCount: 10) @cached(ttl: 60){title},... on Droid @defer(label: "droid"){droid}}}`; document != want {
+		t.Errorf("got query: %v, want: %v", document, want)
+	}
+	client.Strict = true
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: document, Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Repository.Issues) != 1 || q.Repository.Issues[0].Title != "a" || q.Repository.Droid.PrimaryFunction != "astromech" {
+		t.Errorf("got repository: %+v, want decoded fields", q.Repository)
+	}
+}
+
+func TestClient_WithOperationDirectives(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{query: `{viewer{login}}`, want: `query @cached(ttl: 60) {viewer{login}}`},
+		{query: `query Viewer { viewer { login } }`, want: `query Viewer @cached(ttl: 60) { viewer { login } }`},
+		{query: `query ($input: In = {a: "{"}){viewer{login}}`, want: `query ($input: In = {a: "{"}) @cached(ttl: 60) {viewer{login}}`},
+	} {
+		var q struct {
+			Viewer struct{ Login graphql.String }
+		}
+		if err := client.Query(context.Background(), graphql.ManualRequest{Query: tc.query, Result: &q}, nil, graphql.WithOperationDirectives("@cached(ttl: 60)")); err != nil {
+			t.Fatal(err)
+		}
+		var in struct{ Query string }
+		if err := json.Unmarshal([]byte(gotBody), &in); err != nil {
+			t.Fatal(err)
+		}
+		if in.Query != tc.want {
+			t.Errorf("got query: %v, want: %v", in.Query, tc.want)
+		}
+	}
+}
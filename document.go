@@ -0,0 +1,224 @@
+package graphql
+
+import (
+	"strings"
+)
+
+// WithMinifiedDocuments returns a copy of the client that minifies the documents it sends, both constructed
+// and those of ManualRequest, removing the white space, commas and comments that GraphQL ignores,
+// e.g. "query { viewer { login, name } }" is sent as "query{viewer{login name}}".
+// It reduces the size of the payloads of large operations sent at high volume.
+//
+// Minification doesn't change the meaning of documents: string literals, including block strings, are kept as is.
+func (c *Client) WithMinifiedDocuments() *Client {
+	c2 := c.clone()
+	c2.minifyDocuments = true
+	return c2
+}
+
+// minifiedDocument returns query minified if the client is configured to, see WithMinifiedDocuments.
+func (c *Client) minifiedDocument(query string, err error) (string, error) {
+	if err != nil || !c.minifyDocuments {
+		return query, err
+	}
+	return normalizeDocument(query), nil
+}
+
+// normalizeDocument returns query with its insignificant characters removed:
+// white space, commas and comments outside of strings. Documents differing only by these
+// characters normalize to the same string.
+//
+// Specification: https://spec.graphql.org/October2021/#sec-Language.Source-Text.Ignored-Tokens.
+func normalizeDocument(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	// wordEnd records whether the last token written ends with a name or number character,
+	// in which case a space must separate it from a following name or number.
+	wordEnd := false
+	for _, token := range documentTokens(query) {
+		word := isWord(token)
+		if wordEnd && word {
+			b.WriteByte(' ')
+		}
+		b.WriteString(token)
+		wordEnd = word
+	}
+	return b.String()
+}
+
+// FormatDocument returns query formatted for reading, e.g. in logs, diffs and golden files of tests:
+// one selection per line, indented by two spaces per level, with the comments, commas and white space
+// of query replaced by the standard spacing of GraphQL, e.g. "{viewer{login,name}}" is formatted as
+//
+//	{
+//	  viewer {
+//	    login
+//	    name
+//	  }
+//	}
+//
+// String literals are kept as is. Documents differing only by their insignificant characters format the same.
+func FormatDocument(query string) string {
+	var b strings.Builder
+	b.Grow(2 * len(query))
+	indent := 0
+	parens := 0
+	// selections records, for each open brace, whether it opens a selection set rather than an object value.
+	var selections []bool
+	inSelection := func() bool {
+		return parens == 0 && len(selections) > 0 && selections[len(selections)-1]
+	}
+	var prev, prev2 string
+	newline := false
+	for _, token := range documentTokens(query) {
+		closing := token == "}" && len(selections) > 0 && selections[len(selections)-1]
+		if closing {
+			indent--
+		}
+		switch {
+		case prev == "":
+		case closing:
+			b.WriteString("\n" + strings.Repeat("  ", indent))
+		case newline && indent == 0:
+			b.WriteString("\n\n")
+		case newline || inSelection() && (isName(token) || token == "...") &&
+			prev != ":" && prev != "@" && prev != "..." && !(prev == "on" && prev2 == "..."):
+			b.WriteString("\n" + strings.Repeat("  ", indent))
+		case token == ")" || token == "]" || token == "}" || token == "!" || token == ":" || token == "(" ||
+			prev == "(" || prev == "[" || prev == "{" || prev == "$" || prev == "@" ||
+			prev == "..." && token != "on" && token != "@" && token != "{":
+		case parens > 0 && isValueEnd(prev) && isValueStart(token):
+			b.WriteString(", ")
+		default:
+			b.WriteByte(' ')
+		}
+		b.WriteString(token)
+		newline = false
+		switch token {
+		case "(":
+			parens++
+		case ")":
+			parens--
+		case "{":
+			selections = append(selections, parens == 0)
+			if parens == 0 {
+				indent++
+				newline = true
+			}
+		case "}":
+			if len(selections) > 0 {
+				selections = selections[:len(selections)-1]
+			}
+			newline = closing && indent == 0
+		}
+		prev2, prev = prev, token
+	}
+	return b.String()
+}
+
+// isValueEnd reports whether token can end a value or a name in an argument list.
+func isValueEnd(token string) bool {
+	switch token[0] {
+	case ')', ']', '}', '!', '"':
+		return true
+	}
+	return isWord(token)
+}
+
+// isValueStart reports whether token can start a value or a name in an argument list.
+func isValueStart(token string) bool {
+	switch token[0] {
+	case '$', '[', '{', '"':
+		return true
+	}
+	return isWord(token)
+}
+
+// isWord reports whether token is a name or a number.
+func isWord(token string) bool {
+	return isNameOrNumberChar(token[0]) || len(token) > 1 && token[0] == '-'
+}
+
+// documentTokens returns the lexical tokens of query, without its insignificant characters:
+// white space, commas and comments outside of strings.
+func documentTokens(query string) []string {
+	var tokens []string
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], "\ufeff"):
+			i += len("\ufeff")
+		case c == '"':
+			end := stringEnd(query, i)
+			tokens = append(tokens, query[i:end])
+			i = end
+		case isNameOrNumberChar(c) || (c == '-' && i+1 < len(query) && isDigit(query[i+1])):
+			start := i
+			i++
+			for i < len(query) && (isNameOrNumberChar(query[i]) || query[i] == '.' && isNumber(query[start:i]) ||
+				(query[i] == '+' || query[i] == '-') && (query[i-1] == 'e' || query[i-1] == 'E') && isNumber(query[start:i-1])) {
+				i++
+			}
+			tokens = append(tokens, query[start:i])
+		case strings.HasPrefix(query[i:], "..."):
+			tokens = append(tokens, "...")
+			i += len("...")
+		default:
+			tokens = append(tokens, query[i:i+1])
+			i++
+		}
+	}
+	return tokens
+}
+
+// stringEnd returns the index just after the string or block string starting at query[start].
+func stringEnd(query string, start int) int {
+	if strings.HasPrefix(query[start:], `"""`) {
+		for i := start + 3; i < len(query); i++ {
+			switch {
+			case strings.HasPrefix(query[i:], `\"""`):
+				i += 3
+			case strings.HasPrefix(query[i:], `"""`):
+				return i + 3
+			}
+		}
+		return len(query)
+	}
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			i++
+		case '"', '\n', '\r':
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+func isNameOrNumberChar(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// isNumber reports whether s is an integer or float literal, e.g. "-12" or "1.5e3".
+func isNumber(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" || !isDigit(s[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) && s[i] != '.' && s[i] != 'e' && s[i] != 'E' && s[i] != '+' && s[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestFormatDocument(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{
+			in: `{viewer{login,name}}`,
+			want: `{
+  viewer {
+    login
+    name
+  }
+}`,
+		},
+		{
+			in: `query GetRepository($name:String!$owner:String! = "golang" $states:[IssueState!]){repository(owner:$owner,name:$name){name,issues(first:10,filterBy:{states:$states,labels:["bug"]}) @include(if:true){nodes{...IssueFields,... on Issue{title},author{me:login}}}}}fragment IssueFields on Issue{number}`,
+			want: `query GetRepository($name: String!, $owner: String! = "golang", $states: [IssueState!]) {
+  repository(owner: $owner, name: $name) {
+    name
+    issues(first: 10, filterBy: {states: $states, labels: ["bug"]}) @include(if: true) {
+      nodes {
+        ...IssueFields
+        ... on Issue {
+          title
+        }
+        author {
+          me: login
+        }
+      }
+    }
+  }
+}
+
+fragment IssueFields on Issue {
+  number
+}`,
+		},
+		{
+			in: `
+# Stars a repository.
+mutation   AddStar @cached(ttl: 60)
+{ addStar(input: {starrableId: "a,  b"}) { clientMutationId } }`,
+			want: `mutation AddStar @cached(ttl: 60) {
+  addStar(input: {starrableId: "a,  b"}) {
+    clientMutationId
+  }
+}`,
+		},
+	}
+	for _, tc := range tests {
+		if got := graphql.FormatDocument(tc.in); got != tc.want {
+			t.Errorf("\ngot:\n%s\nwant:\n%s", got, tc.want)
+		}
+	}
+}
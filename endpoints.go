@@ -0,0 +1,139 @@
+package graphql
+
+import (
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// EndpointsConfig describes a set of equivalent GraphQL servers that requests are balanced across.
+//
+// Requests rotate through the endpoints. If an endpoint can't be reached, or answers
+// 502 Bad Gateway, 503 Service Unavailable or 504 Gateway Timeout, the next one is tried.
+type EndpointsConfig struct {
+	// URLs are the GraphQL server URLs.
+	URLs []string
+
+	// StickyCookie is the name of a session affinity cookie set by stateful gateways,
+	// such as "AWSALB" or "SERVERID". Once an endpoint sets it, requests keep going
+	// to that endpoint for as long as it is available.
+	// A cookie jar is needed for the cookie itself to be sent back, see WithCookieJar.
+	StickyCookie string
+}
+
+// WithEndpoints returns a copy of the client that balances requests across several endpoints,
+// failing over from one to the next. It replaces the URL the client was created with.
+//
+// Requests made with WithAffinityKey always prefer the same endpoint for the same key.
+func (c *Client) WithEndpoints(config EndpointsConfig) *Client {
+	c2 := c.clone()
+	if len(config.URLs) == 0 {
+		c2.endpoints = nil
+		return c2
+	}
+	c2.url = config.URLs[0]
+	c2.endpoints = &endpointSet{
+		urls:         append([]string(nil), config.URLs...),
+		stickyCookie: config.StickyCookie,
+	}
+	return c2
+}
+
+// WithAffinityKey routes the request by consistent hashing of key over the client's endpoints,
+// so that requests with the same key reach the same endpoint while it is available.
+// Only endpoints configured with WithEndpoints are affected.
+func WithAffinityKey(key string) RequestOption {
+	return func(opts *requestOptions) {
+		opts.affinityKey = key
+	}
+}
+
+// endpointSet balances requests across endpoints. It is shared by a client and the clients derived from it.
+type endpointSet struct {
+	urls         []string
+	stickyCookie string
+	next         uint32 // Accessed atomically.
+
+	mu     sync.Mutex
+	pinned string // Endpoint that set the sticky cookie, if any.
+}
+
+// order returns the endpoints in the order they should be tried.
+func (s *endpointSet) order(affinityKey string) []string {
+	urls := make([]string, len(s.urls))
+	if affinityKey != "" {
+		// Rendezvous hashing: adding or removing an endpoint only moves the keys mapped to it.
+		copy(urls, s.urls)
+		scores := make(map[string]uint64, len(urls))
+		for _, url := range urls {
+			h := fnv.New64a()
+			h.Write([]byte(affinityKey))
+			h.Write([]byte{0})
+			h.Write([]byte(url))
+			scores[url] = h.Sum64()
+		}
+		sort.SliceStable(urls, func(i, j int) bool { return scores[urls[i]] > scores[urls[j]] })
+		return urls
+	}
+
+	start := int((atomic.AddUint32(&s.next, 1) - 1) % uint32(len(s.urls)))
+	for i := range urls {
+		urls[i] = s.urls[(start+i)%len(s.urls)]
+	}
+
+	s.mu.Lock()
+	pinned := s.pinned
+	s.mu.Unlock()
+	if pinned != "" {
+		for i, url := range urls {
+			if url == pinned {
+				copy(urls[1:i+1], urls[:i])
+				urls[0] = pinned
+				break
+			}
+		}
+	}
+	return urls
+}
+
+// failed records that url is unavailable, releasing the sticky session bound to it.
+func (s *endpointSet) failed(url string) {
+	s.mu.Lock()
+	if s.pinned == url {
+		s.pinned = ""
+	}
+	s.mu.Unlock()
+}
+
+// succeeded records a response from url, pinning the session to it if it set the sticky cookie.
+func (s *endpointSet) succeeded(url string, resp *http.Response) {
+	if s.stickyCookie == "" {
+		return
+	}
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name != s.stickyCookie {
+			continue
+		}
+		s.mu.Lock()
+		if cookie.MaxAge < 0 {
+			// The gateway deleted the cookie.
+			if s.pinned == url {
+				s.pinned = ""
+			}
+		} else {
+			s.pinned = url
+		}
+		s.mu.Unlock()
+	}
+}
+
+// isUnavailable reports whether statusCode means the endpoint is temporarily unable to serve requests.
+func isUnavailable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
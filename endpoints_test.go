@@ -0,0 +1,137 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+// newEndpointsClient returns a client balancing across hosts a, b and c,
+// where each host answers with its own name, or with the status set in down.
+func newEndpointsClient(down map[string]int, sticky string) *graphql.Client {
+	transport := graphql.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		w := httptest.NewRecorder()
+		host := req.URL.Host
+		if status := down[host]; status != 0 {
+			w.WriteHeader(status)
+			return w.Result(), nil
+		}
+		if sticky == host {
+			http.SetCookie(w, &http.Cookie{Name: "SERVERID", Value: host})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"host": "`+host+`"}}`)
+		return w.Result(), nil
+	})
+	return graphql.NewClient("", &http.Client{Transport: transport}).WithEndpoints(graphql.EndpointsConfig{
+		URLs:         []string{"http://a/graphql", "http://b/graphql", "http://c/graphql"},
+		StickyCookie: "SERVERID",
+	})
+}
+
+func queryHost(t *testing.T, client *graphql.Client, options ...graphql.RequestOption) string {
+	var q struct {
+		Host string
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "{host}", Result: &q}, nil, options...)
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+	return q.Host
+}
+
+func TestClient_WithEndpoints_failover(t *testing.T) {
+	client := newEndpointsClient(map[string]int{"b": http.StatusServiceUnavailable}, "")
+	got := map[string]int{}
+	for i := 0; i < 6; i++ {
+		got[queryHost(t, client)]++
+	}
+	if got["b"] != 0 || got["a"] == 0 || got["c"] == 0 {
+		t.Errorf("got requests per host: %v, want requests to a and c only", got)
+	}
+}
+
+func TestClient_WithEndpoints_affinityKey(t *testing.T) {
+	client := newEndpointsClient(nil, "")
+	hosts := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprint("user-", i)
+		host := queryHost(t, client, graphql.WithAffinityKey(key))
+		for j := 0; j < 3; j++ {
+			if again := queryHost(t, client, graphql.WithAffinityKey(key)); again != host {
+				t.Fatalf("key %q: got host %q, then %q, want the same host", key, host, again)
+			}
+		}
+		hosts[host] = true
+	}
+	if len(hosts) < 2 {
+		t.Errorf("got all keys mapped to hosts %v, want them spread out", hosts)
+	}
+
+	// When the preferred endpoint is down, the key fails over to another one.
+	host := queryHost(t, client, graphql.WithAffinityKey("user-0"))
+	down := newEndpointsClient(map[string]int{host: http.StatusBadGateway}, "")
+	if got := queryHost(t, down, graphql.WithAffinityKey("user-0")); got == host {
+		t.Errorf("got unavailable host %q, want another one", got)
+	}
+}
+
+func TestClient_WithEndpoints_stickyCookie(t *testing.T) {
+	client := newEndpointsClient(nil, "c")
+	// Round-robin reaches c within one rotation.
+	for i := 0; i < 3 && queryHost(t, client) != "c"; i++ {
+	}
+	for i := 0; i < 5; i++ {
+		if got := queryHost(t, client); got != "c" {
+			t.Errorf("got host %q after sticky cookie was set by c, want c", got)
+		}
+	}
+}
+
+func TestClient_WithEndpoints_csrfCookie(t *testing.T) {
+	// Each host sets its own CSRF cookie; a is down, so mutations fail over to b and c.
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, host := range []string{"a", "b", "c"} {
+		jar.SetCookies(&url.URL{Scheme: "http", Host: host}, []*http.Cookie{{Name: "csrftoken", Value: "token-" + host}})
+	}
+	transport := graphql.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		w := httptest.NewRecorder()
+		if req.URL.Host == "a" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return w.Result(), nil
+		}
+		if got, want := req.Header.Get("X-CSRF-Token"), "token-"+req.URL.Host; got != want {
+			t.Errorf("got CSRF token %q sent to %s, want %q", got, req.URL.Host, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"host": "`+req.URL.Host+`"}}`)
+		return w.Result(), nil
+	})
+	client := graphql.NewClient("", &http.Client{Transport: transport}).
+		WithEndpoints(graphql.EndpointsConfig{URLs: []string{"http://a/graphql", "http://b/graphql", "http://c/graphql"}}).
+		WithCookieJar(jar).
+		WithCSRF(graphql.CSRFConfig{CookieName: "csrftoken"})
+
+	got := map[string]int{}
+	for i := 0; i < 6; i++ {
+		var m struct {
+			Host string
+		}
+		if err := client.Mutate(context.Background(), graphql.ManualRequest{Query: "mutation{host}", Result: &m}, nil); err != nil {
+			t.Fatalf("got error: %v, want: nil", err)
+		}
+		got[m.Host]++
+	}
+	if got["a"] != 0 || got["b"] == 0 || got["c"] == 0 {
+		t.Errorf("got mutations per host: %v, want mutations to b and c only", got)
+	}
+}
@@ -0,0 +1,141 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// GraphQLEnum is implemented by the Go types of GraphQL enums, typically string types with a constant per value,
+// to validate their values:
+//
+//	type IssueState string
+//
+//	const (
+//		IssueStateOpen   IssueState = "OPEN"
+//		IssueStateClosed IssueState = "CLOSED"
+//	)
+//
+//	func (s IssueState) IsValid() bool {
+//		return s == IssueStateOpen || s == IssueStateClosed
+//	}
+//
+// Variables with invalid values fail before requests are sent. Values returned by servers that the client
+// doesn't know, e.g. added to the schema after the client was built, are handled as set with WithUnknownEnums.
+type GraphQLEnum interface {
+	// IsValid reports whether the value is one of the values of the enum known to the client.
+	IsValid() bool
+}
+
+var graphQLEnumType = reflect.TypeOf((*GraphQLEnum)(nil)).Elem()
+
+// UnknownEnumPolicy is how values of enums unknown to the client are decoded, see WithUnknownEnums.
+type UnknownEnumPolicy int
+
+const (
+	// KeepUnknownEnums decodes unknown values as they are, so that clients keep working when servers add values
+	// to enums; IsValid reports them as invalid. It is the default.
+	KeepUnknownEnums UnknownEnumPolicy = iota
+
+	// RejectUnknownEnums fails decoding responses with unknown values with an *UnknownEnumError.
+	RejectUnknownEnums
+)
+
+// WithUnknownEnums returns a copy of the client that handles the values of GraphQLEnum types unknown to the
+// client, in the data of responses, with policy. Zero values, e.g. of null fields, are never unknown.
+func (c *Client) WithUnknownEnums(policy UnknownEnumPolicy) *Client {
+	c2 := c.clone()
+	c2.unknownEnums = policy
+	return c2
+}
+
+// UnknownEnumError is returned for values of GraphQLEnum types that aren't valid, in variables,
+// or in the data of responses with RejectUnknownEnums.
+type UnknownEnumError struct {
+	// Value is the value, e.g. IssueState("DRAFT").
+	Value interface{}
+}
+
+// Error implements error interface.
+func (e *UnknownEnumError) Error() string {
+	return fmt.Sprintf("unknown value %v of enum %T", e.Value, e.Value)
+}
+
+// enumTypes maps types to whether they have GraphQLEnum values, as reported by hasEnums.
+var enumTypes sync.Map // map[reflect.Type]bool
+
+// hasEnums reports whether t, or a type nested in it, implements GraphQLEnum.
+func hasEnums(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if found, ok := enumTypes.Load(t); ok {
+		return found.(bool)
+	}
+	found := findEnums(t, map[reflect.Type]bool{})
+	enumTypes.Store(t, found)
+	return found
+}
+
+// findEnums reports whether t has GraphQLEnum values, skipping the types in seen.
+func findEnums(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	if t.Implements(graphQLEnumType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return findEnums(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if findEnums(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkEnums returns an *UnknownEnumError for the first value of a GraphQLEnum type in v that isn't valid.
+func checkEnums(v reflect.Value) error {
+	if !v.IsValid() || !hasEnums(v.Type()) {
+		return nil
+	}
+	if v.Type().Implements(graphQLEnumType) && !(v.Kind() == reflect.Ptr && v.IsNil()) {
+		if !v.IsZero() && !v.Interface().(GraphQLEnum).IsValid() {
+			return &UnknownEnumError{Value: v.Interface()}
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return checkEnums(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkEnums(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for iter := v.MapRange(); iter.Next(); {
+			if err := checkEnums(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if err := checkEnums(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
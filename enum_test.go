@@ -0,0 +1,60 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+type issueState string
+
+const (
+	issueStateOpen   issueState = "OPEN"
+	issueStateClosed issueState = "CLOSED"
+)
+
+func (s issueState) IsValid() bool {
+	return s == issueStateOpen || s == issueStateClosed
+}
+
+func TestClient_WithUnknownEnums(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"issues": [{"state": "OPEN"}, {"state": "DRAFT"}, {"state": null}]}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Issues []struct {
+			State issueState
+		}
+	}
+	request := graphql.ManualRequest{Query: "{issues{state}}", Result: &q}
+	if err := client.Query(context.Background(), request, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Issues) != 3 || q.Issues[1].State != "DRAFT" || q.Issues[1].State.IsValid() {
+		t.Errorf("got issues: %+v", q.Issues)
+	}
+
+	err := client.WithUnknownEnums(graphql.RejectUnknownEnums).Query(context.Background(), request, nil)
+	var enumErr *graphql.UnknownEnumError
+	if !errors.As(err, &enumErr) || enumErr.Value != issueState("DRAFT") {
+		t.Errorf("got error: %v, want unknown enum DRAFT", err)
+	}
+
+	gotBody = ""
+	err = client.Query(context.Background(), request, map[string]interface{}{"states": []issueState{issueStateOpen, "MERGED"}})
+	if !errors.As(err, &enumErr) || enumErr.Value != issueState("MERGED") {
+		t.Errorf("got error: %v, want unknown enum MERGED", err)
+	}
+	if gotBody != "" {
+		t.Errorf("got request body: %v, want no request", gotBody)
+	}
+}
@@ -0,0 +1,129 @@
+package graphql
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// EnvelopeConfig configures where the data, errors and extensions of responses are read from,
+// for gateways that nest the GraphQL response under other keys, e.g. {"result": {"data": ...}}.
+// Each is a path of object keys from the root of the response body; a nil path keeps the standard key.
+type EnvelopeConfig struct {
+	// Data is the path of the data, e.g. []string{"result", "data"}. Defaults to []string{"data"}.
+	Data []string
+
+	// Errors is the path of the errors. Defaults to []string{"errors"}.
+	Errors []string
+
+	// Extensions is the path of the extensions. Defaults to []string{"extensions"}.
+	Extensions []string
+}
+
+// WithEnvelope returns a copy of the client that reads the data, errors and extensions of responses
+// from the paths in config.
+//
+// Features reading responses on their own expect the standard envelope: cache hints, automatic persisted queries,
+// the normalized cache, QueryStream, and the GraphQL errors of responses with a status code other than 200 OK.
+func (c *Client) WithEnvelope(config EnvelopeConfig) *Client {
+	if config.Data == nil {
+		config.Data = []string{"data"}
+	}
+	if config.Errors == nil {
+		config.Errors = []string{"errors"}
+	}
+	if config.Extensions == nil {
+		config.Extensions = []string{"extensions"}
+	}
+	c2 := c.clone()
+	c2.envelope = &config
+	return c2
+}
+
+// responseEnvelope is the standard envelope of a GraphQL response.
+type responseEnvelope struct {
+	Data       responseData
+	Errors     responseErrors
+	Extensions json.RawMessage
+}
+
+// decodeEnvelope reads the response body from r, and decodes it into out from the paths in config.
+func (c *Client) decodeEnvelope(r io.Reader, config *EnvelopeConfig, out *responseEnvelope) error {
+	body, err := readAll(r)
+	if err != nil {
+		return err
+	}
+	data, err := lookupPath(body, config.Data)
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		if err := out.Data.UnmarshalJSON(data); err != nil {
+			return err
+		}
+	}
+	errs, err := lookupPath(body, config.Errors)
+	if err != nil {
+		return err
+	}
+	if errs != nil {
+		if err := out.Errors.UnmarshalJSON(errs); err != nil {
+			return err
+		}
+	}
+	out.Extensions, err = lookupPath(body, config.Extensions)
+	return err
+}
+
+// lookupPath returns the JSON value at path in the JSON object body, or nil if there is none.
+func lookupPath(body []byte, path []string) (json.RawMessage, error) {
+	value := json.RawMessage(body)
+	for _, key := range path {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(value, &object); err != nil {
+			return nil, err
+		}
+		value = object[key]
+		if value == nil {
+			return nil, nil
+		}
+	}
+	return value, nil
+}
+
+// ErrorDecoder decodes the errors of a response, as found in its "errors" key or at the path configured
+// with WithEnvelope, into GraphQLErrors. It is given the JSON value as is, whatever its shape.
+type ErrorDecoder func(data json.RawMessage) (GraphQLErrors, error)
+
+// WithErrorDecoder returns a copy of the client that decodes the errors of responses with decode,
+// so that server-specific error formats, such as Hasura's extensions.internal or legacy error wrappers,
+// are seen by applications as standard GraphQLErrors. It also applies to the errors in the body of an HTTPError.
+//
+// By default, errors are decoded as specified by GraphQL.
+func (c *Client) WithErrorDecoder(decode ErrorDecoder) *Client {
+	c2 := c.clone()
+	c2.errorDecoder = decode
+	return c2
+}
+
+// responseErrors decodes the errors of a response into errs, with the error decoder of the client, if any.
+type responseErrors struct {
+	client *Client
+	errs   GraphQLErrors
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *responseErrors) UnmarshalJSON(data []byte) error {
+	if e.client.errorDecoder == nil {
+		return json.Unmarshal(data, &e.errs)
+	}
+	if string(data) == "null" {
+		return nil
+	}
+	// The data may be reused by the decoder once this returns, so the error decoder gets a copy to keep.
+	errs, err := e.client.errorDecoder(append(json.RawMessage(nil), data...))
+	if err != nil {
+		return err
+	}
+	e.errs = errs
+	return nil
+}
@@ -0,0 +1,149 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_WithEnvelope(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"status": "ok", "result": {"data": {"viewer": {"login": "gopher"}}, "meta": {"cost": 3}}, "problems": [{"message": "deprecated field"}]}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithEnvelope(graphql.EnvelopeConfig{
+		Data:       []string{"result", "data"},
+		Errors:     []string{"problems"},
+		Extensions: []string{"result", "meta"},
+	})
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	var extensions struct {
+		Cost int
+	}
+	result, err := client.QueryPartial(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil, graphql.WithExtensions(&extensions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.HasData || q.Viewer.Login != "gopher" {
+		t.Errorf("got result: %+v, login: %q, want login: %q", result, q.Viewer.Login, "gopher")
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Message != "deprecated field" {
+		t.Errorf("got errors: %v", result.Errors)
+	}
+	if extensions.Cost != 3 {
+		t.Errorf("got cost: %d, want: 3", extensions.Cost)
+	}
+}
+
+func TestClient_WithEnvelope_missing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"result": {"errors": [{"message": "boom"}]}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithEnvelope(graphql.EnvelopeConfig{
+		Data:   []string{"result", "data"},
+		Errors: []string{"result", "errors"},
+	})
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	result, err := client.QueryPartial(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.HasData || len(result.Errors) != 1 || result.Errors[0].Message != "boom" {
+		t.Errorf("got result: %+v, want only the error boom", result)
+	}
+}
+
+func TestClient_WithErrorDecoder(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": null, "error": {"status": 400, "reason": "unknown field login"}}`)
+	})
+	legacy := func(data json.RawMessage) (graphql.GraphQLErrors, error) {
+		var e struct {
+			Status int
+			Reason string
+		}
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		return graphql.GraphQLErrors{{Message: e.Reason, Extensions: map[string]interface{}{"code": graphql.CodeBadRequest}}}, nil
+	}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithEnvelope(graphql.EnvelopeConfig{Errors: []string{"error"}}).
+		WithErrorDecoder(legacy)
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+	if got, want := graphql.Code(err), graphql.CodeBadRequest; got != want {
+		t.Errorf("got code: %q, want: %q", got, want)
+	}
+	var errs graphql.GraphQLErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Message != "unknown field login" {
+		t.Errorf("got error: %v, want the decoded legacy error", err)
+	}
+}
+
+func TestClient_WithErrorDecoder_statusError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		mustWrite(w, `{"errors": [{"message": "database query error", "extensions": {"code": "constraint-violation", "internal": {"error": {"message": "duplicate key"}}}}]}`)
+	})
+	hasura := func(data json.RawMessage) (graphql.GraphQLErrors, error) {
+		var errs graphql.GraphQLErrors
+		if err := json.Unmarshal(data, &errs); err != nil {
+			return nil, err
+		}
+		for i := range errs {
+			var extensions struct {
+				Internal struct {
+					Error struct {
+						Message string
+					}
+				}
+			}
+			if err := errs[i].DecodeExtensions(&extensions); err != nil {
+				return nil, err
+			}
+			if message := extensions.Internal.Error.Message; message != "" {
+				errs[i].Message += ": " + message
+			}
+		}
+		return errs, nil
+	}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithErrorDecoder(hasura)
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+	var errs graphql.GraphQLErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Message != "database query error: duplicate key" {
+		t.Errorf("got error: %v, want the decoded Hasura error", err)
+	}
+}
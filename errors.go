@@ -0,0 +1,351 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Categories of the errors returned by requests, to be tested with errors.Is:
+//
+//	if errors.Is(err, graphql.ErrNetwork) {
+//		// Retry later.
+//	}
+//
+// Errors of the context of a request, such as context.Canceled, are returned as they are, without a category.
+// The errors of each category have a concrete type, to be inspected with errors.As:
+// *NetworkError, *HTTPError, GraphQLErrors and *DecodeError.
+var (
+	// ErrNetwork is the category of errors sending a request or receiving its response,
+	// such as connection failures and timeouts.
+	ErrNetwork = errors.New("network error")
+
+	// ErrHTTPStatus is the category of errors for responses with a status code other than 200 OK.
+	ErrHTTPStatus = errors.New("unexpected HTTP status")
+
+	// ErrGraphQL is the category of the errors returned by the GraphQL server in the response.
+	// They can be inspected as GraphQLErrors with errors.As.
+	ErrGraphQL = errors.New("GraphQL error")
+
+	// ErrDecode is the category of errors decoding a response.
+	ErrDecode = errors.New("decoding error")
+)
+
+// ErrMalformedResponse is returned, as the error of a *DecodeError, for a response with a 200 OK status code
+// that has neither data nor errors, e.g. an empty response of a misbehaving proxy,
+// rather than leaving the result of the operation zero-valued as if it had succeeded.
+var ErrMalformedResponse = errors.New("malformed response: neither data nor errors")
+
+// NetworkError is returned when a request can't be sent, or its response can't be received,
+// e.g. because the connection failed or timed out. It is in the ErrNetwork category.
+type NetworkError struct {
+	// Err is the error of the HTTP client.
+	Err error
+}
+
+// Error implements error interface.
+func (e *NetworkError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error of the HTTP client.
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrNetwork.
+func (e *NetworkError) Is(target error) bool {
+	return target == ErrNetwork
+}
+
+// networkError returns err as a *NetworkError, unless it is an error of the context.
+func networkError(err error) error {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	}
+	return &NetworkError{Err: err}
+}
+
+// HTTPError is returned for a response with a status code other than 200 OK. It is in the ErrHTTPStatus category.
+type HTTPError struct {
+	// StatusCode is the status code of the response, e.g. 503.
+	StatusCode int
+
+	// Status is the status of the response, e.g. "503 Service Unavailable".
+	Status string
+
+	// Header is the header of the response, e.g. to read Retry-After.
+	Header http.Header
+
+	// Body is the body of the response, truncated to maxErrorBodySize bytes.
+	Body []byte
+
+	// Errors are the GraphQL errors in the body of the response, if it is JSON with an "errors" array,
+	// as many servers respond with a status code such as 400 Bad Request to invalid queries.
+	// They are also returned by Unwrap, so that the error is in the ErrGraphQL category too.
+	Errors GraphQLErrors
+}
+
+// maxErrorBodySize is the maximum number of bytes of a response body kept in an HTTPError.
+const maxErrorBodySize = 64 << 10
+
+// Error implements error interface.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("non-200 OK status code: %v body: %q", e.Status, e.Body)
+}
+
+// Is reports whether target is ErrHTTPStatus.
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrHTTPStatus
+}
+
+// Unwrap returns the GraphQL errors in the body of the response, if any.
+func (e *HTTPError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e.Errors
+}
+
+// statusError returns the error for a response with a status code other than 200 OK, reading its body,
+// and the GraphQL errors in it if it is JSON.
+func (c *Client) statusError(resp *http.Response) error {
+	body, _ := readAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	e := &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Header: resp.Header, Body: body}
+	if isJSON(resp.Header.Get("Content-Type")) {
+		out := struct {
+			Errors responseErrors
+		}{Errors: responseErrors{client: c}}
+		if json.Unmarshal(body, &out) == nil {
+			e.Errors = out.Errors.errs
+		}
+	}
+	return e
+}
+
+// isJSON reports whether contentType is a JSON media type,
+// such as application/json or application/graphql-response+json.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// DecodeError is returned when a response can't be decoded. It is in the ErrDecode category.
+type DecodeError struct {
+	// Err is the error of the JSON decoder.
+	Err error
+
+	// Offset is the byte offset in Body at which decoding failed, as reported by the JSON decoder,
+	// or -1 if unknown, e.g. for errors decoding the data of a response into the result of an operation.
+	Offset int64
+
+	// Body is the body of the response, truncated to maxErrorBodySize bytes.
+	Body []byte
+}
+
+// decodeErrorExcerpt is the number of bytes of the body on each side of the offset
+// included in the message of a DecodeError.
+const decodeErrorExcerpt = 64
+
+// Error implements error interface.
+// The message includes the offset, and an excerpt of the body around it.
+func (e *DecodeError) Error() string {
+	if e.Body == nil {
+		return e.Err.Error()
+	}
+	if e.Offset < 0 || e.Offset > int64(len(e.Body)) {
+		return fmt.Sprintf("%v (response body: %q)", e.Err, excerpt(e.Body, 0, 2*decodeErrorExcerpt))
+	}
+	start := int(e.Offset) - decodeErrorExcerpt
+	if start < 0 {
+		start = 0
+	}
+	return fmt.Sprintf("%v (offset %d of response body: %q)", e.Err, e.Offset, excerpt(e.Body, start, start+2*decodeErrorExcerpt))
+}
+
+// excerpt returns body[start:end], bounded by the length of body, with "..." marking the truncated ends.
+func excerpt(body []byte, start, end int) string {
+	if end > len(body) {
+		end = len(body)
+	}
+	s := string(body[start:end])
+	if start > 0 {
+		s = "..." + s
+	}
+	if end < len(body) {
+		s += "..."
+	}
+	return s
+}
+
+// Unwrap returns the error of the JSON decoder.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrDecode.
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+// decodeError returns err as a *DecodeError, with the body that failed to decode.
+// The offset is that of the *json.SyntaxError or *json.UnmarshalTypeError in err, if any.
+func decodeError(err error, body []byte) *DecodeError {
+	e := &DecodeError{Err: err, Offset: -1, Body: body}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		e.Offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		e.Offset = typeErr.Offset
+	}
+	return e
+}
+
+// bodyRecorder reads from r, keeping the first maxErrorBodySize bytes read in buf,
+// to be reported in a DecodeError if the body can't be decoded.
+type bodyRecorder struct {
+	r   io.Reader
+	buf *bytes.Buffer
+}
+
+// Read implements io.Reader.
+func (b bodyRecorder) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if room := maxErrorBodySize - b.buf.Len(); room > 0 {
+		if n < room {
+			room = n
+		}
+		b.buf.Write(p[:room])
+	}
+	return n, err
+}
+
+// body returns a copy of the recorded body.
+func (b bodyRecorder) body() []byte {
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+// GraphQLError is an error in the "errors" array of a response from a GraphQL server.
+//
+// Specification: https://spec.graphql.org/October2021/#sec-Errors.
+type GraphQLError struct {
+	// Message describes the error.
+	Message string
+
+	// Locations are the locations in the query document that the error relates to, if any.
+	Locations []Location
+
+	// Path is the path of the response field that failed, if any.
+	// Its elements are strings for field names and aliases, and ints for list indexes.
+	Path []interface{}
+
+	// Extensions holds additional information about the error, such as an error code.
+	// Use DecodeExtensions to decode it into a struct instead.
+	Extensions map[string]interface{}
+
+	// Field is the Go field of the result of the operation that Path corresponds to,
+	// e.g. "Repository.Issues.Nodes[3].Author", if it could be resolved.
+	Field string
+
+	// rawExtensions is the JSON encoding of Extensions, as received.
+	rawExtensions json.RawMessage
+}
+
+// Location is a location in a GraphQL document.
+type Location struct {
+	Line   int
+	Column int
+}
+
+// Error implements error interface.
+func (e *GraphQLError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("Message: %s, Locations: %+v, Field: %s", e.Message, e.Locations, e.Field)
+	}
+	return fmt.Sprintf("Message: %s, Locations: %+v", e.Message, e.Locations)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *GraphQLError) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Message    string
+		Locations  []Location
+		Path       []interface{}
+		Extensions json.RawMessage
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = GraphQLError{Message: raw.Message, Locations: raw.Locations, Path: raw.Path}
+	for i, elem := range e.Path {
+		if index, ok := elem.(float64); ok {
+			e.Path[i] = int(index)
+		}
+	}
+	if len(raw.Extensions) > 0 && string(raw.Extensions) != "null" {
+		e.rawExtensions = raw.Extensions
+		if err := json.Unmarshal(raw.Extensions, &e.Extensions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeExtensions decodes the extensions of the error into v, which should be a pointer to a struct
+// or map. It does nothing if the error has no extensions.
+func (e *GraphQLError) DecodeExtensions(v interface{}) error {
+	if len(e.rawExtensions) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.rawExtensions, v)
+}
+
+// GraphQLErrors represents the "errors" array in a response from a GraphQL server.
+// If returned via error interface, the slice is expected to contain at least 1 element.
+// It is in the ErrGraphQL category.
+type GraphQLErrors []GraphQLError
+
+// fieldErrors reports whether all the errors are field errors, which have a path.
+func (e GraphQLErrors) fieldErrors() bool {
+	for i := range e {
+		if len(e[i].Path) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Is reports whether target is ErrGraphQL.
+func (e GraphQLErrors) Is(target error) bool {
+	return target == ErrGraphQL
+}
+
+// Unwrap returns each error as a *GraphQLError, so that, with Go 1.20 or later, errors.Is and errors.As
+// look into each of them, e.g. to find the first *GraphQLError.
+func (e GraphQLErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i := range e {
+		errs[i] = &e[i]
+	}
+	return errs
+}
+
+// Error implements error interface.
+func (e GraphQLErrors) Error() string {
+	b := strings.Builder{}
+	for i := range e {
+		b.WriteString(e[i].Error())
+	}
+	return b.String()
+}
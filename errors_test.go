@@ -0,0 +1,308 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestGraphQLErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{
+			"errors": [
+				{
+					"message": "Name for character with ID 1002 could not be fetched.",
+					"locations": [{"line": 6, "column": 7}],
+					"path": ["hero", "heroFriends", 1, "name"],
+					"extensions": {"code": "CAN_NOT_FETCH_BY_ID", "timestamp": "Fri Feb 9 14:33:09 UTC 2018"}
+				},
+				{"message": "no extensions"}
+			],
+			"data": {"hero": null}
+		}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Hero *struct {
+			Name graphql.String
+		}
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "{hero{name}}", Result: &q}, nil)
+	var gqlErrs graphql.GraphQLErrors
+	if !errors.As(err, &gqlErrs) {
+		t.Fatalf("got error: %v, want GraphQLErrors", err)
+	}
+	if len(gqlErrs) != 2 {
+		t.Fatalf("got %d errors, want 2", len(gqlErrs))
+	}
+
+	e := gqlErrs[0]
+	if got, want := e.Path, []interface{}{"hero", "heroFriends", 1, "name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got path: %#v, want: %#v", got, want)
+	}
+	if got, want := e.Locations, []graphql.Location{{Line: 6, Column: 7}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got locations: %v, want: %v", got, want)
+	}
+	if got, want := e.Extensions["code"], "CAN_NOT_FETCH_BY_ID"; got != want {
+		t.Errorf("got code: %v, want: %v", got, want)
+	}
+	var extensions struct {
+		Code      string
+		Timestamp string
+	}
+	if err := e.DecodeExtensions(&extensions); err != nil {
+		t.Fatal(err)
+	}
+	if extensions.Code != "CAN_NOT_FETCH_BY_ID" || extensions.Timestamp != "Fri Feb 9 14:33:09 UTC 2018" {
+		t.Errorf("got extensions: %+v", extensions)
+	}
+
+	if gqlErrs[1].Extensions != nil || gqlErrs[1].Path != nil {
+		t.Errorf("got error: %+v, want no extensions and path", gqlErrs[1])
+	}
+	if err := gqlErrs[1].DecodeExtensions(&extensions); err != nil {
+		t.Errorf("got error decoding missing extensions: %v", err)
+	}
+	if got, want := err.Error(), "Message: Name for character with ID 1002 could not be fetched., Locations: [{Line:6 Column:7}]Message: no extensions, Locations: []"; got != want {
+		t.Errorf("got error: %q, want: %q", got, want)
+	}
+}
+
+func TestErrorCategories(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		transport http.RoundTripper
+		want      error
+		wantType  interface{} // Pointer to the concrete type of the error.
+	}{
+		{
+			name: "network",
+			transport: graphql.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			}),
+			want:     graphql.ErrNetwork,
+			wantType: new(*graphql.NetworkError),
+		},
+		{
+			name: "status",
+			transport: localRoundTripper{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				http.Error(w, "bad gateway", http.StatusBadGateway)
+			})},
+			want:     graphql.ErrHTTPStatus,
+			wantType: new(*graphql.HTTPError),
+		},
+		{
+			name: "graphql",
+			transport: localRoundTripper{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mustWrite(w, `{"errors": [{"message": "boom"}]}`)
+			})},
+			want:     graphql.ErrGraphQL,
+			wantType: new(graphql.GraphQLErrors),
+		},
+		{
+			name: "decode",
+			transport: localRoundTripper{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mustWrite(w, `<html>`)
+			})},
+			want:     graphql.ErrDecode,
+			wantType: new(*graphql.DecodeError),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := graphql.NewClient("/graphql", &http.Client{Transport: tc.transport})
+			var q struct {
+				Viewer struct {
+					Login graphql.String
+				}
+			}
+			err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+			for _, category := range []error{graphql.ErrNetwork, graphql.ErrHTTPStatus, graphql.ErrGraphQL, graphql.ErrDecode} {
+				if got, want := errors.Is(err, category), category == tc.want; got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want: %v", err, category, got, want)
+				}
+			}
+			if !errors.As(err, tc.wantType) {
+				t.Errorf("got error of type %T, want: %T", err, reflect.ValueOf(tc.wantType).Elem().Interface())
+			}
+		})
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	})}})
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+	var httpErr *graphql.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("got error: %v, want *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusTooManyRequests || httpErr.Status != "429 Too Many Requests" {
+		t.Errorf("got status: %d %q", httpErr.StatusCode, httpErr.Status)
+	}
+	if got, want := httpErr.Header.Get("Retry-After"), "30"; got != want {
+		t.Errorf("got Retry-After: %q, want: %q", got, want)
+	}
+	if got, want := string(httpErr.Body), "slow down\n"; got != want {
+		t.Errorf("got body: %q, want: %q", got, want)
+	}
+	if got, want := err.Error(), `non-200 OK status code: 429 Too Many Requests body: "slow down\n"`; got != want {
+		t.Errorf("got error: %q, want: %q", got, want)
+	}
+}
+
+func TestHTTPError_truncated(t *testing.T) {
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		mustWrite(w, strings.Repeat("x", 1<<20))
+	})}})
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+	var httpErr *graphql.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("got error: %v, want *HTTPError", err)
+	}
+	if got, want := len(httpErr.Body), 64<<10; got != want {
+		t.Errorf("got body of %d bytes, want: %d", got, want)
+	}
+}
+
+func TestHTTPError_graphQLErrors(t *testing.T) {
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/graphql-response+json; charset=utf-8")
+		w.WriteHeader(http.StatusBadRequest)
+		mustWrite(w, `{"errors": [{"message": "Cannot query field \"login\" on type \"Query\".", "locations": [{"line": 1, "column": 2}]}]}`)
+	})}})
+	var q struct {
+		Login graphql.String
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "{login}", Result: &q}, nil)
+	var httpErr *graphql.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("got error: %v, want *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status code: %d, want: %d", httpErr.StatusCode, http.StatusBadRequest)
+	}
+	if !errors.Is(err, graphql.ErrHTTPStatus) || !errors.Is(err, graphql.ErrGraphQL) {
+		t.Errorf("got error: %v, want it in the ErrHTTPStatus and ErrGraphQL categories", err)
+	}
+	var gqlErrs graphql.GraphQLErrors
+	if !errors.As(err, &gqlErrs) {
+		t.Fatalf("got error: %v, want GraphQLErrors", err)
+	}
+	want := graphql.GraphQLErrors{{
+		Message:   `Cannot query field "login" on type "Query".`,
+		Locations: []graphql.Location{{Line: 1, Column: 2}},
+	}}
+	if !reflect.DeepEqual(gqlErrs, want) {
+		t.Errorf("got errors: %#v, want: %#v", gqlErrs, want)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		body       string
+		wantOffset int64
+		wantError  string // Suffix of the error message.
+	}{
+		{
+			name:       "syntax",
+			body:       `{"data": {"viewer": {"login": "gopher"}}, oops}`,
+			wantOffset: 43,
+			wantError:  `invalid character 'o' looking for beginning of object key string (offset 43 of response body: "{\"data\": {\"viewer\": {\"login\": \"gopher\"}}, oops}")`,
+		},
+		{
+			name:       "truncated",
+			body:       `{"data": {"viewer": {"login": "` + strings.Repeat("x", 100) + `"}}, oops}`,
+			wantOffset: 137,
+			wantError:  `invalid character 'o' looking for beginning of object key string (offset 137 of response body: "...` + strings.Repeat("x", 58) + `\"}}, oops}")`,
+		},
+		{
+			name:       "data",
+			body:       `{"data": {"viewer": {"login": 42}}}`,
+			wantOffset: -1,
+			wantError:  ` (response body: "{\"data\": {\"viewer\": {\"login\": 42}}}")`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mustWrite(w, tc.body)
+			})}})
+			var q struct {
+				Viewer struct {
+					Login graphql.String
+				}
+			}
+			err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+			var decodeErr *graphql.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("got error: %v, want *DecodeError", err)
+			}
+			if decodeErr.Offset != tc.wantOffset {
+				t.Errorf("got offset: %d, want: %d", decodeErr.Offset, tc.wantOffset)
+			}
+			if got := string(decodeErr.Body); got != tc.body {
+				t.Errorf("got body: %q, want: %q", got, tc.body)
+			}
+			if got := err.Error(); !strings.HasSuffix(got, tc.wantError) {
+				t.Errorf("got error: %q, want suffix: %q", got, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestErrMalformedResponse(t *testing.T) {
+	for _, body := range []string{``, "\n", `{}`, `{"data": null}`, `{"extensions": {"cost": 1}}`} {
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			mustWrite(w, body)
+		})}})
+		var q struct {
+			Viewer struct {
+				Login graphql.String
+			}
+		}
+		err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+		if !errors.Is(err, graphql.ErrMalformedResponse) || !errors.Is(err, graphql.ErrDecode) {
+			t.Errorf("body %q: got error: %v, want ErrMalformedResponse", body, err)
+		}
+	}
+}
+
+func TestGraphQLErrors_Unwrap(t *testing.T) {
+	errs := graphql.GraphQLErrors{{Message: "first"}, {Message: "second"}}
+	var err error = fmt.Errorf("query viewer: %w", &graphql.HTTPError{StatusCode: http.StatusBadRequest, Errors: errs})
+
+	var gqlErr *graphql.GraphQLError
+	if !errors.As(err, &gqlErr) || gqlErr.Message != "first" {
+		t.Errorf("got GraphQL error: %v, want the first one", gqlErr)
+	}
+	if !errors.Is(err, &errs[1]) {
+		t.Error("errors.Is didn't find the second error")
+	}
+	unwrapped := errs.Unwrap()
+	if len(unwrapped) != 2 || unwrapped[1].(*graphql.GraphQLError).Message != "second" {
+		t.Errorf("got unwrapped errors: %v", unwrapped)
+	}
+}
@@ -0,0 +1,104 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecConfig configures a TokenSource that obtains tokens from an external helper process,
+// in the style of kubectl exec credential plugins.
+//
+// The command must print a JSON object to stdout, either a Kubernetes ExecCredential:
+//
+//	{"kind": "ExecCredential", "status": {"token": "...", "expirationTimestamp": "2021-01-02T15:04:05Z"}}
+//
+// or a flat object:
+//
+//	{"token": "...", "expirationTimestamp": "2021-01-02T15:04:05Z"}
+//
+// The expiration timestamp is optional. Without it, the token is cached for the lifetime of the source.
+type ExecConfig struct {
+	// Command is the helper executable, looked up in PATH.
+	Command string
+
+	// Args are the arguments passed to Command.
+	Args []string
+
+	// Env are extra environment variables, in "KEY=value" form,
+	// added to the environment of the current process.
+	Env []string
+
+	// ExpiryDelta is how long before the expiration timestamp the token is considered expired,
+	// to account for clock skew and request latency.
+	//
+	// Defaults to 10 seconds.
+	ExpiryDelta time.Duration
+}
+
+// NewExecTokenSource returns a TokenSource that runs the configured command
+// whenever no valid token is cached.
+func NewExecTokenSource(config ExecConfig) TokenSource {
+	if config.ExpiryDelta == 0 {
+		config.ExpiryDelta = 10 * time.Second
+	}
+	return &execTokenSource{config: config}
+}
+
+type execTokenSource struct {
+	config ExecConfig
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// execOutput is the JSON printed by the credential helper.
+type execOutput struct {
+	Status *execStatus `json:"status"`
+	execStatus
+}
+
+type execStatus struct {
+	Token               string    `json:"token"`
+	ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+}
+
+// Token implements TokenSource.
+func (s *execTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && (s.expiry.IsZero() || time.Now().Add(s.config.ExpiryDelta).Before(s.expiry)) {
+		return s.token, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.config.Command, s.config.Args...)
+	cmd.Env = append(os.Environ(), s.config.Env...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running credential helper %q: %v: %s", s.config.Command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var out execOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return "", fmt.Errorf("decoding output of credential helper %q: %v", s.config.Command, err)
+	}
+	status := out.execStatus
+	if out.Status != nil {
+		status = *out.Status
+	}
+	if status.Token == "" {
+		return "", fmt.Errorf("credential helper %q returned no token", s.config.Command)
+	}
+
+	s.token, s.expiry = status.Token, status.ExpirationTimestamp
+	return s.token, nil
+}
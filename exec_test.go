@@ -0,0 +1,83 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestNewExecTokenSource(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir, err := ioutil.TempDir("", "graphql-exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	counter := filepath.Join(dir, "runs")
+
+	// The helper appends to the counter file on every run, and prints an ExecCredential.
+	script := func(expiry time.Time) string {
+		return fmt.Sprintf(`echo run >> %q; echo '{"kind":"ExecCredential","status":{"token":"'$TOKEN'","expirationTimestamp":"%s"}}'`,
+			counter, expiry.UTC().Format(time.RFC3339))
+	}
+	runs := func() int {
+		b, _ := ioutil.ReadFile(counter)
+		return strings.Count(string(b), "run")
+	}
+
+	ts := graphql.NewExecTokenSource(graphql.ExecConfig{
+		Command: "sh",
+		Args:    []string{"-c", script(time.Now().Add(time.Hour))},
+		Env:     []string{"TOKEN=abc"},
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.Header.Get("Authorization"), "Bearer abc"; got != want {
+			t.Errorf("got Authorization: %q, want: %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithTokenSource(ts)
+	for i := 0; i < 2; i++ {
+		var q struct{}
+		if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{}", Result: &q}, nil); err != nil {
+			t.Fatalf("got error: %v, want: nil", err)
+		}
+	}
+	if got := runs(); got != 1 {
+		t.Errorf("got %d helper runs for a valid token, want: 1", got)
+	}
+
+	// A token within ExpiryDelta of its expiry is refreshed on every call.
+	ts = graphql.NewExecTokenSource(graphql.ExecConfig{
+		Command:     "sh",
+		Args:        []string{"-c", script(time.Now().Add(time.Second))},
+		Env:         []string{"TOKEN=abc"},
+		ExpiryDelta: time.Minute,
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := ts.Token(context.Background()); err != nil {
+			t.Fatalf("got error: %v, want: nil", err)
+		}
+	}
+	if got := runs(); got != 3 {
+		t.Errorf("got %d helper runs in total, want: 3", got)
+	}
+
+	ts = graphql.NewExecTokenSource(graphql.ExecConfig{Command: "sh", Args: []string{"-c", "echo failure >&2; exit 1"}})
+	if _, err := ts.Token(context.Background()); err == nil || !strings.Contains(err.Error(), "failure") {
+		t.Errorf("got error: %v, want: error containing helper stderr", err)
+	}
+}
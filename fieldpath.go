@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// annotateFields sets the Field of the errors with a path to the Go field of t that the path corresponds to.
+func annotateFields(errs GraphQLErrors, t reflect.Type) {
+	if t == nil {
+		return
+	}
+	for i := range errs {
+		if len(errs[i].Path) > 0 {
+			errs[i].Field = goFieldPath(t, errs[i].Path)
+		}
+	}
+}
+
+// goFieldPath returns the Go expression selecting the field of t that the response path corresponds to,
+// relative to a value of t, e.g. "Repository.Issues.Nodes[3].Author", or "" if the path can't be resolved.
+func goFieldPath(t reflect.Type, path []interface{}) string {
+	var b strings.Builder
+	for _, elem := range path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch elem := elem.(type) {
+		case int:
+			if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+				return ""
+			}
+			b.WriteString("[" + strconv.Itoa(elem) + "]")
+			t = t.Elem()
+		case string:
+			if t.Kind() != reflect.Struct {
+				return ""
+			}
+			f, ok := fieldByResponseKey(t, elem)
+			if !ok {
+				return ""
+			}
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(f.Name)
+			t = f.Type
+		default:
+			return ""
+		}
+	}
+	return b.String()
+}
+
+// fieldByResponseKey returns the field of the struct type t with the given key in the data of responses,
+// looking into embedded structs and inline fragments, whose fields are promoted.
+func fieldByResponseKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("graphql")
+		if _, hasName := jsonName(f); f.Anonymous && !hasName && (!hasTag || strings.HasPrefix(strings.TrimSpace(tag), "...")) {
+			if embedded := indirect(f.Type); embedded.Kind() == reflect.Struct {
+				if field, ok := fieldByResponseKey(embedded, key); ok {
+					return field, true
+				}
+			}
+			continue
+		}
+		if responseKey(f, tag, hasTag) == key {
+			return f, true
+		}
+	}
+	// Fall back to the field that encoding/json decodes the key into, for results declared with json tags.
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if name, _ := jsonName(f); f.PkgPath == "" && strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
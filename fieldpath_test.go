@@ -0,0 +1,51 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestGraphQLError_Field(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"repository": {"issues": {"nodes": [{"author": null}, {"author": null}]}}, "me": null}, "errors": [
+			{"message": "author is private", "path": ["repository", "issues", "nodes", 1, "author"]},
+			{"message": "viewer failed", "path": ["me"]},
+			{"message": "unknown", "path": ["repository", "stars"]},
+			{"message": "no path"}
+		]}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	type actor struct {
+		Login graphql.String
+	}
+	var q struct {
+		Repository struct {
+			Issues struct {
+				Nodes []struct {
+					Author *actor
+				}
+			} `graphql:"issues(first: 2)"`
+		} `graphql:"repository(owner: \"octocat\", name: \"hello\")"`
+		Viewer *actor `graphql:"me: viewer"`
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "...", Result: &q}, nil)
+	var errs graphql.GraphQLErrors
+	if !errors.As(err, &errs) || len(errs) != 4 {
+		t.Fatalf("got error: %v, want 4 GraphQL errors", err)
+	}
+	for i, want := range []string{"Repository.Issues.Nodes[1].Author", "Viewer", "", ""} {
+		if got := errs[i].Field; got != want {
+			t.Errorf("error %d: got field: %q, want: %q", i, got, want)
+		}
+	}
+	if got, want := errs[0].Error(), "Message: author is private, Locations: [], Field: Repository.Issues.Nodes[1].Author"; got != want {
+		t.Errorf("got error: %q, want: %q", got, want)
+	}
+}
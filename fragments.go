@@ -0,0 +1,139 @@
+package graphql
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WithNamedFragments returns a copy of the client whose constructed documents, see Client.ConstructQuery,
+// define the inline fragments that are repeated, e.g. `graphql:"... on User"` embedded in several places,
+// once as named fragments, spread where they are used, instead of repeating their selection sets.
+// It keeps the documents of large queries under the size limits of servers.
+//
+// Fragments are named after their Go types, or their type conditions for anonymous struct types,
+// e.g. "fragment UserFields on User{login,name}" for a UserFields type.
+func (c *Client) WithNamedFragments() *Client {
+	c2 := c.clone()
+	c2.namedFragments = true
+	return c2
+}
+
+// fragmentCandidate is an inline fragment of a query, to be named if repeated.
+type fragmentCandidate struct {
+	// inline is the inline fragment as written in the query, e.g. "... on User{login}".
+	inline string
+	// name is the name of the fragment, e.g. "UserFields".
+	name string
+	// condition is the type condition of the fragment, e.g. "User".
+	condition string
+	// directives are the directives of the inline fragment, if any, e.g. "@defer".
+	directives string
+	// selection is the selection set of the fragment, e.g. "{login}".
+	selection string
+}
+
+// nameFragments replaces the inline fragments of t repeated in query by spreads of named fragments,
+// and returns the resulting query and the definitions of the fragments. gqlgen is as for query.
+func nameFragments(query string, t reflect.Type, unions *unionTypes, gqlgen bool) (string, string) {
+	candidates := make(map[string]fragmentCandidate)
+	collectFragments(t, &queryWriter{unions: unions, gqlgen: gqlgen}, candidates, map[reflect.Type]bool{})
+	ordered := make([]fragmentCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		ordered = append(ordered, candidate)
+	}
+	// Outer fragments first, so that the fragments nested in them are counted once per definition.
+	sort.Slice(ordered, func(i, j int) bool {
+		if len(ordered[i].inline) != len(ordered[j].inline) {
+			return len(ordered[i].inline) > len(ordered[j].inline)
+		}
+		return ordered[i].inline < ordered[j].inline
+	})
+
+	var definitions []string
+	names := make(map[string]bool)
+	for _, candidate := range ordered {
+		count := strings.Count(query, candidate.inline)
+		for _, definition := range definitions {
+			count += strings.Count(definition, candidate.inline)
+		}
+		if count < 2 {
+			continue
+		}
+		name := candidate.name
+		for i := 2; names[name]; i++ {
+			name = candidate.name + strconv.Itoa(i)
+		}
+		names[name] = true
+		spread := "..." + name
+		if candidate.directives != "" {
+			spread += " " + candidate.directives
+		}
+		query = strings.ReplaceAll(query, candidate.inline, spread)
+		for i := range definitions {
+			definitions[i] = strings.ReplaceAll(definitions[i], candidate.inline, spread)
+		}
+		definitions = append(definitions, "fragment "+name+" on "+candidate.condition+candidate.selection)
+	}
+	return query, strings.Join(definitions, "")
+}
+
+// collectFragments adds the inline fragments of t with a type condition, as written by qw, to candidates,
+// by their text in queries, skipping the types in seen.
+func collectFragments(t reflect.Type, qw *queryWriter, candidates map[string]fragmentCandidate, seen map[reflect.Type]bool) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		collectFragments(t.Elem(), qw, candidates, seen)
+	case reflect.Interface:
+		for typename, concrete := range qw.unions.lookup(t) {
+			addFragment(candidates, "... on "+typename, concrete, qw)
+			collectFragments(concrete, qw, candidates, seen)
+		}
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if tag, ok := f.Tag.Lookup("graphql"); ok && strings.HasPrefix(strings.TrimSpace(tag), "...") {
+				addFragment(candidates, tagSelection(tag), f.Type, qw)
+			}
+			collectFragments(f.Type, qw, candidates, seen)
+		}
+	}
+}
+
+// addFragment adds the inline fragment of t, written as fragment, e.g. "... on User @defer", to candidates,
+// if it has a type condition and a selection set, written by qw.
+func addFragment(candidates map[string]fragmentCandidate, fragment string, t reflect.Type, qw *queryWriter) {
+	rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(fragment), "..."))
+	if !strings.HasPrefix(rest, "on ") {
+		return
+	}
+	rest = strings.TrimSpace(rest[len("on "):])
+	end := 0
+	for end < len(rest) && isNameOrNumberChar(rest[end]) {
+		end++
+	}
+	candidate := fragmentCandidate{condition: rest[:end], directives: strings.TrimSpace(rest[end:])}
+	var selection bytes.Buffer
+	(&queryWriter{unions: qw.unions, gqlgen: qw.gqlgen}).write(&selection, t, false)
+	if candidate.condition == "" || selection.Len() <= len("{}") {
+		return
+	}
+	candidate.selection = selection.String()
+	candidate.inline = fragment + candidate.selection
+	candidate.name = indirect(t).Name()
+	if !isName(candidate.name) {
+		candidate.name = candidate.condition + "Fragment"
+	}
+	if _, ok := candidates[candidate.inline]; !ok {
+		candidates[candidate.inline] = candidate
+	}
+}
@@ -0,0 +1,46 @@
+package graphql_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+type UserFields struct {
+	Login graphql.String
+	Name  graphql.String
+}
+
+func TestClient_WithNamedFragments(t *testing.T) {
+	client := graphql.NewClient("/graphql", http.DefaultClient)
+
+	var q struct {
+		Repository struct {
+			Owner struct {
+				UserFields `graphql:"... on User"`
+			}
+			Issues struct {
+				Nodes []struct {
+					Author struct {
+						UserFields `graphql:"... on User"`
+					}
+					Editor struct {
+						UserFields `graphql:"... on User @defer"`
+					}
+				}
+			} `graphql:"issues(first: 10)"`
+			Parent struct {
+				Bot struct {
+					Login graphql.String
+				} `graphql:"... on Bot"`
+			}
+		}
+	}
+	if got, _ := client.ConstructQuery(&q, nil, ""); got != `{repository{owner{... on User{login,name}},issues(first: 10){nodes{author{... on User{login,name}},editor{... on User @defer{login,name}}}},parent{... on Bot{login}}}}` {
+		t.Errorf("got query: %v", got)
+	}
+	if got, _ := client.WithNamedFragments().ConstructQuery(&q, nil, "GetRepository"); got != `query GetRepository{repository{owner{...UserFields},issues(first: 10){nodes{author{...UserFields},editor{... on User @defer{login,name}}}},parent{... on Bot{login}}}}fragment UserFields on User{login,name}` {
+		t.Errorf("got query: %v", got)
+	}
+}
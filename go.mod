@@ -6,6 +6,5 @@ require (
 	github.com/google/uuid v1.1.2
 	github.com/graph-gophers/graphql-go v0.0.0-20201112095111-7a585a01e04c
 	github.com/opentracing/opentracing-go v1.2.0 // indirect
-	golang.org/x/net v0.0.0-20201110031124-69a78807bb2b
 	nhooyr.io/websocket v1.8.6
 )
@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// WithGQLGenModels returns a copy of the client that accepts the models generated by gqlgen for servers
+// as query structs, so that they don't have to be duplicated on the client side. In documents constructed
+// by the client:
+//
+//   - Fields without graphql tag are selected by the names of their json tags, and `json:"-"` fields are left out.
+//   - Fields of recursive types, such as User.Friends []*User, are left out rather than failing,
+//     unless they have a depth option.
+//   - Fields of interface types, which gqlgen generates for GraphQL interfaces and unions, are left out,
+//     unless their types are registered with WithUnionTypes.
+//   - Types with an UnmarshalGQL method, gqlgen's custom scalars, are scalars.
+//
+// The values of variables with a MarshalGQL method are encoded with it, and the data of responses is decoded
+// into types with an UnmarshalGQL method with it, except for enums, which are GraphQLEnum types
+// handled as set with WithUnknownEnums. Client.Strict doesn't apply, as models have no graphql tags.
+func (c *Client) WithGQLGenModels() *Client {
+	c2 := c.clone()
+	c2.gqlgenModels = true
+	return c2
+}
+
+// gqlgenMarshaler is gqlgen's graphql.Marshaler, implemented by its custom scalars and enums.
+type gqlgenMarshaler interface {
+	MarshalGQL(w io.Writer)
+}
+
+// gqlgenUnmarshaler is gqlgen's graphql.Unmarshaler, implemented by its custom scalars and enums.
+type gqlgenUnmarshaler interface {
+	UnmarshalGQL(v interface{}) error
+}
+
+var (
+	gqlgenMarshalerType   = reflect.TypeOf((*gqlgenMarshaler)(nil)).Elem()
+	gqlgenUnmarshalerType = reflect.TypeOf((*gqlgenUnmarshaler)(nil)).Elem()
+)
+
+// isGQLGenScalar reports whether t is a gqlgen custom scalar, decoded with its UnmarshalGQL method.
+// Enums are decoded as strings instead, so that unknown values are handled as set with WithUnknownEnums.
+func isGQLGenScalar(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(gqlgenUnmarshalerType) && !t.Implements(graphQLEnumType)
+}
+
+// marshalGQL returns the JSON encoding of v, written by its MarshalGQL method.
+func marshalGQL(v gqlgenMarshaler) json.RawMessage {
+	var buf bytes.Buffer
+	v.MarshalGQL(&buf)
+	return buf.Bytes()
+}
+
+// gqlgenScalarTypes maps types to whether they have gqlgen custom scalar values, as reported by hasGQLGenScalars.
+var gqlgenScalarTypes sync.Map // map[reflect.Type]bool
+
+// hasGQLGenScalars reports whether t, or a type nested in it, is a gqlgen custom scalar.
+func hasGQLGenScalars(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if found, ok := gqlgenScalarTypes.Load(t); ok {
+		return found.(bool)
+	}
+	found := findGQLGenScalars(t, map[reflect.Type]bool{})
+	gqlgenScalarTypes.Store(t, found)
+	return found
+}
+
+// findGQLGenScalars reports whether t has gqlgen custom scalar values, skipping the types in seen.
+func findGQLGenScalars(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	if isGQLGenScalar(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return findGQLGenScalars(t.Elem(), seen)
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+			return false
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if findGQLGenScalars(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// excludeGQLGen reports whether the field f, without graphql tag, of a gqlgen model is left out of queries.
+func (qw *queryWriter) excludeGQLGen(f reflect.StructField) bool {
+	if name, ok := jsonName(f); ok && name == excludeTag {
+		return true
+	}
+	elem := f.Type
+	for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Interface && elem.NumMethod() > 0 &&
+		qw.unions.lookup(elem) == nil && qw.unions.fallback(elem) == nil
+}
@@ -0,0 +1,121 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+// The models below follow the conventions of the code generated by gqlgen.
+
+type Role string
+
+const (
+	RoleAdmin Role = "ADMIN"
+	RoleUser  Role = "USER"
+)
+
+func (e Role) IsValid() bool {
+	return e == RoleAdmin || e == RoleUser
+}
+
+func (e *Role) UnmarshalGQL(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+	*e = Role(s)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", s)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(e)))
+}
+
+// Money is a custom scalar of amounts in cents, written as decimal strings, e.g. "12.34".
+type Money struct {
+	Cents int64
+}
+
+func (m *Money) UnmarshalGQL(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("money must be a string")
+	}
+	var units, cents int64
+	if _, err := fmt.Sscanf(s, "%d.%02d", &units, &cents); err != nil {
+		return err
+	}
+	m.Cents = units*100 + cents
+	return nil
+}
+
+func (m Money) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(fmt.Sprintf("%d.%02d", m.Cents/100, m.Cents%100)))
+}
+
+type Node interface {
+	IsNode()
+}
+
+type User struct {
+	ID      string  `json:"id"`
+	Name    *string `json:"name,omitempty"`
+	Role    Role    `json:"role"`
+	Balance *Money  `json:"balance,omitempty"`
+	Friends []*User `json:"friends"`
+	Owner   Node    `json:"owner"`
+	Session string  `json:"-"`
+}
+
+func (User) IsNode() {}
+
+func TestClient_WithGQLGenModels(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"id": "1", "name": null, "role": "OWNER", "balance": "12.34"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		User *User `graphql:"user(role: $role, minBalance: $minBalance)"`
+	}
+	variables := map[string]interface{}{
+		"role":       RoleAdmin,
+		"minBalance": Money{Cents: 1050},
+	}
+	if _, err := client.ConstructQuery(&q, variables, ""); err == nil {
+		t.Error("got no error for the recursive model without WithGQLGenModels")
+	}
+
+	client = client.WithGQLGenModels()
+	query, err := client.ConstructQuery(&q, variables, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `query ($minBalance:Money!$role:Role!){user(role: $role, minBalance: $minBalance){id,name,role,balance}}`; query != want {
+		t.Errorf("got query: %s, want: %s", query, want)
+	}
+
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, variables); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"query":"` + query + `","variables":{"minBalance":"10.50","role":"ADMIN"}}` + "\n"; gotBody != want {
+		t.Errorf("got body: %v, want %v", gotBody, want)
+	}
+	// Unknown enum values are kept, see WithUnknownEnums.
+	if q.User == nil || q.User.ID != "1" || q.User.Name != nil || q.User.Role != "OWNER" || q.User.Balance == nil || q.User.Balance.Cents != 1234 {
+		t.Errorf("got user: %+v", q.User)
+	}
+}
@@ -3,20 +3,24 @@ package graphql
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"reflect"
 	"strings"
-
-	"golang.org/x/net/context/ctxhttp"
 )
 
 // Client is a GraphQL client.
 type Client struct {
-	// Strict will force the decoder to use only the `graphql` structural flag.
-	// If you set this to false, then it will use `graphql` as the first-class structural flag to use.
-	// If it is not available, it will attempt to use the `json` structural flag.
+	// Strict will force the decoder to use only the `graphql` structural flag,
+	// matching fields by their response keys, including aliases, and failing on fields that the result doesn't declare.
+	// If you set this to false, then the data is decoded with encoding/json, matching fields by their `json` structural
+	// flag or their names. It can be overridden per request with WithStrict.
 	//
 	// Defaults to false.
 	Strict     bool
@@ -24,7 +28,74 @@ type Client struct {
 	httpClient *http.Client
 	// DefaultHeaders allows you preset headers that will be made for all succeeding GraphQL requests.
 	// If you want request-specific headers, check `ManualRequest`.
+	//
+	// Deprecated: Mutating DefaultHeaders while requests are in flight is a data race.
+	// Use SetHeader, AddHeader and DeleteHeader instead.
 	DefaultHeaders http.Header
+
+	// headers are the default headers managed by SetHeader and friends.
+	headers *headerStore
+	// csrf handles CSRF tokens for mutations; nil if disabled.
+	csrf *csrfState
+	// middlewares wrap the HTTP transport, outermost first.
+	middlewares []Middleware
+	// credentials authenticates requests; nil if unauthenticated.
+	credentials credentialsFunc
+	// authRefresh is called before retrying a request rejected with 401 or 403.
+	authRefresh func(ctx context.Context) error
+	// userAgent is sent as User-Agent unless overridden by other headers.
+	userAgent string
+	// endpoints are the equivalent servers requests are balanced across; nil if only url is used.
+	endpoints *endpointSet
+	// persisted is the automatic persisted queries state; nil if disabled.
+	persisted *persistedQueryState
+	// manifest is the safelist of persisted queries; nil if full documents may be sent.
+	manifest *PersistedQueryManifest
+	// batcher collects concurrent operations into batches; nil if disabled.
+	batcher *batcher
+	// compressRequests enables gzip compression of request bodies of at least compressMinSize bytes.
+	compressRequests bool
+	compressMinSize  int
+	// cache holds the responses to queries; nil if disabled.
+	cache *responseCache
+	// normalized is the normalized entity cache; nil if disabled.
+	normalized *NormalizedCache
+	// maxResponseBytes limits the size of response bodies; 0 if unlimited.
+	maxResponseBytes int64
+	// codec encodes requests and decodes responses; nil if encoding/json is used.
+	codec Codec
+	// jsonOptions configure decoding with encoding/json, when codec is nil.
+	jsonOptions jsonOptions
+	// envelope configures where responses are read from; nil if they have the standard envelope.
+	envelope *EnvelopeConfig
+	// errorDecoder decodes the errors of responses; nil if they are standard GraphQL errors.
+	errorDecoder ErrorDecoder
+	// operationErrors configures the operation attached to errors; nil if none is.
+	operationErrors *OperationErrorConfig
+	// decodeHooks adapt the values of the data of responses before they are decoded.
+	decodeHooks []DecodeHook
+	// timeFormat encodes and decodes time.Time values; nil if they are RFC 3339 strings.
+	timeFormat *TimeFormat
+	// bigNumbersAsStrings encodes big.Int and big.Float variables as strings.
+	bigNumbersAsStrings bool
+	// bytesEncoding encodes Bytes variables; nil if they are encoded in the standard base64 alphabet.
+	bytesEncoding *base64.Encoding
+	// numericIDs encodes FlexID variables that are integers as numbers.
+	numericIDs bool
+	// variableTypes map Go types to the GraphQL types of variables, overriding those inferred.
+	variableTypes map[reflect.Type]string
+	// operationNamesFromTypes names anonymous operations after the types of their results.
+	operationNamesFromTypes bool
+	// unionTypes are the Go types that objects are decoded into, by __typename, for interface fields; nil if none.
+	unionTypes *unionTypes
+	// unknownEnums is how values of enums unknown to the client are decoded.
+	unknownEnums UnknownEnumPolicy
+	// namedFragments makes constructed documents define repeated fragments once, as named fragments.
+	namedFragments bool
+	// minifyDocuments removes the insignificant characters of the documents sent.
+	minifyDocuments bool
+	// gqlgenModels accepts the models generated by gqlgen as query structs and variables.
+	gqlgenModels bool
 }
 
 // ManualRequest allows you to define the graphql request in string format,
@@ -41,9 +112,15 @@ type ManualRequest struct {
 	// Result is where the JSON response of the request will be decoded.
 	//
 	// Make sure that this is an pointer type (address to the struct you wish to decode into).
+	// For exploratory tooling, it can also be a *map[string]interface{}, a non-nil map[string]interface{}
+	// filled in place, or a *json.RawMessage receiving the data as is.
 	Result interface{}
 
 	// Headers are the request-specific headers for this instance of a graphql request.
+	//
+	// Headers are merged in this order, later ones taking precedence:
+	// built-in headers (Content-Type, Accept, Accept-Encoding and User-Agent), the client's default headers,
+	// credentials, headers from the context (see WithContextHeaders), and finally these.
 	Headers http.Header
 }
 
@@ -56,229 +133,731 @@ func NewClient(url string, httpClient *http.Client) *Client {
 	return &Client{
 		url:        url,
 		httpClient: httpClient,
+		headers:    &headerStore{header: make(http.Header)},
+		userAgent:  DefaultUserAgent,
+	}
+}
+
+// clone returns a shallow copy of c, so that options can be applied
+// without affecting other users of c.
+func (c *Client) clone() *Client {
+	c2 := *c
+	c2.DefaultHeaders = c.DefaultHeaders.Clone()
+	c2.headers = c.headers.clone()
+	return &c2
+}
+
+// Clone returns a copy of the client. The copy shares the underlying HTTP client and transport,
+// so it is cheap to create, but changes to its configuration don't affect the original.
+func (c *Client) Clone() *Client {
+	return c.clone()
+}
+
+// WithHeaders returns a copy of the client whose default headers also include h.
+// Values in h replace existing default values for the same keys.
+//
+// Together with the other With methods, it allows request-scoped customization
+// without building new clients or mutating shared ones:
+//
+//	c2 := c.WithHeaders(h).WithURL(u)
+func (c *Client) WithHeaders(h http.Header) *Client {
+	c2 := c.clone()
+	for key, values := range h {
+		c2.headers.header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
 	}
+	return c2
+}
+
+// WithURL returns a copy of the client targeting the specified GraphQL server URL.
+// It replaces any endpoints configured with WithEndpoints.
+func (c *Client) WithURL(url string) *Client {
+	c2 := c.clone()
+	c2.url = url
+	c2.endpoints = nil
+	return c2
+}
+
+// withTransport returns a copy of c whose HTTP client uses a copy of the current
+// *http.Transport, modified by fn. If the HTTP client has no transport, a copy of
+// http.DefaultTransport is used. Custom http.RoundTripper implementations can't be modified.
+func (c *Client) withTransport(fn func(t *http.Transport) error) (*Client, error) {
+	var transport *http.Transport
+	switch rt := c.httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = rt.Clone()
+	default:
+		return nil, fmt.Errorf("cannot configure transport of type %T", rt)
+	}
+	if err := fn(transport); err != nil {
+		return nil, err
+	}
+	c2 := c.clone()
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c2.httpClient = &httpClient
+	return c2, nil
 }
 
 // Query executes a single GraphQL query request,
 // with a query derived from q, populating the response into it.
 // q should be a pointer to struct that corresponds to the GraphQL schema.
-func (c *Client) Query(ctx context.Context, request ManualRequest, variables map[string]interface{}) error {
-	return c.Do(ctx, queryOperation, request, variables, "")
+func (c *Client) Query(ctx context.Context, request ManualRequest, variables map[string]interface{}, options ...RequestOption) error {
+	return c.Do(ctx, queryOperation, request, variables, "", options...)
 }
 
 // Mutate executes a single GraphQL mutation request,
 // with a mutation derived from m, populating the response into it.
 // m should be a pointer to struct that corresponds to the GraphQL schema.
-func (c *Client) Mutate(ctx context.Context, request ManualRequest, variables map[string]interface{}) error {
-	return c.Do(ctx, mutationOperation, request, variables, "")
+func (c *Client) Mutate(ctx context.Context, request ManualRequest, variables map[string]interface{}, options ...RequestOption) error {
+	return c.Do(ctx, mutationOperation, request, variables, "", options...)
 }
 
 // DoRaw executes a single GraphQL operation.
 // return raw message and error
 //
 // Deprecated: Currently deprecated; will revisit this later.
-func (c *Client) DoRaw(ctx context.Context, op operationType, v interface{}, variables map[string]interface{}, name string) (*json.RawMessage, error) {
-	var query string
+func (c *Client) DoRaw(ctx context.Context, op operationType, v interface{}, variables map[string]interface{}, name string, options ...RequestOption) (*json.RawMessage, error) {
+	resp, manualRequest, err := c.execute(ctx, op, v, variables, name, options)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-	var manualRequest *ManualRequest
+	var out struct {
+		Data   *json.RawMessage
+		Errors GraphQLErrors
+		//Extensions interface{} // Unused.
+	}
 
-	mr, ok := v.(ManualRequest)
+	// If input was a manual request, then use output from manual request
+	if manualRequest != nil {
+		err = c.decode(resp.Body, manualRequest.Result)
+		return nil, err
+	}
 
-	if ok {
-		manualRequest = &mr
-		query = manualRequest.Query
+	// Do standard
+	err = c.decode(resp.Body, &out)
 
-	} else {
-		switch op {
-		case queryOperation:
-			query = constructQuery(v, variables, name)
-		case mutationOperation:
-			query = constructMutation(v, variables, name)
-		}
+	if err != nil {
+		// TODO: Consider including response body in returned error, if deemed helpful.
+		return nil, err
 	}
 
-	in := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables,omitempty"`
-	}{
-		Query:     query,
-		Variables: variables,
+	if len(out.Errors) > 0 {
+		return out.Data, out.Errors
 	}
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(in)
+
+	return out.Data, nil
+}
+
+// Do executes a single GraphQL operation and unmarshal json.
+func (c *Client) Do(ctx context.Context, op operationType, v interface{}, variables map[string]interface{}, name string, options ...RequestOption) error {
+	result, err := c.doPartial(ctx, op, v, variables, name, options)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	httpRequest, err := http.NewRequest("POST", c.url, &buf)
+	return c.operationError(resultError(result, newRequestOptions(options)), op, v, variables, name)
+}
 
-	if err != nil {
-		return nil, err
+// resultError returns the errors of result, unless they are delivered as warnings with opts.
+func resultError(result PartialResult, opts requestOptions) error {
+	if len(result.Errors) == 0 {
+		return nil
 	}
+	if opts.warnings != nil && result.HasData && result.Errors.fieldErrors() {
+		opts.warnings(result.Errors)
+		return nil
+	}
+	return result.Errors
+}
 
-	// Default headers first
-	for key, value := range c.DefaultHeaders {
-		httpRequest.Header[key] = value
+// PartialResult is the outcome of an operation executed with QueryPartial or MutatePartial.
+//
+// Per the GraphQL specification, a response can have both data and errors, e.g. when some fields
+// failed to resolve and were set to null. The data is then decoded as usual, and the errors are reported here.
+type PartialResult struct {
+	// HasData reports whether the response had data, which was decoded into the result of the operation.
+	HasData bool
+
+	// Errors are the errors of the response, if any.
+	Errors GraphQLErrors
+}
+
+// QueryPartial executes a single GraphQL query request, as Query, but returns the errors of the response
+// along with the data, in result, rather than as an error. The returned error is only set if the request failed,
+// or its response couldn't be decoded.
+func (c *Client) QueryPartial(ctx context.Context, request ManualRequest, variables map[string]interface{}, options ...RequestOption) (PartialResult, error) {
+	return c.doPartial(ctx, queryOperation, request, variables, "", options)
+}
+
+// MutatePartial executes a single GraphQL mutation request, as Mutate, but returns the errors of the response
+// along with the data, as QueryPartial does.
+func (c *Client) MutatePartial(ctx context.Context, request ManualRequest, variables map[string]interface{}, options ...RequestOption) (PartialResult, error) {
+	return c.doPartial(ctx, mutationOperation, request, variables, "", options)
+}
+
+func (c *Client) doPartial(ctx context.Context, op operationType, v interface{}, variables map[string]interface{}, name string, options []RequestOption) (PartialResult, error) {
+	resp, manualRequest, err := c.execute(ctx, op, v, variables, name, options)
+	if err != nil {
+		return PartialResult{}, c.operationError(err, op, v, variables, name)
 	}
+	defer resp.Body.Close()
 
-	// Request-specific headers next
-	for key, value := range mr.Headers {
-		httpRequest.Header[key] = value
+	var target interface{} = v
+	if manualRequest != nil {
+		target = manualRequest.Result
+	}
+	opts := newRequestOptions(options)
+	var body io.Reader = resp.Body
+	if opts.meta != nil {
+		opts.meta.StatusCode = resp.StatusCode
+		opts.meta.Header = resp.Header
+		body = countingReader{r: resp.Body, n: &opts.meta.ResponseSize}
 	}
+	result, err := c.decodeResult(body, target, opts)
+	return result, c.operationError(err, op, v, variables, name)
+}
 
-	resp, err := ctxhttp.Do(ctx, c.httpClient, httpRequest)
+// decodeResponse decodes the GraphQL response read from r, populating its data into target.
+// If the response has errors, they are returned.
+func (c *Client) decodeResponse(r io.Reader, target interface{}) error {
+	result, err := c.decodeResult(r, target, requestOptions{})
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return result.Errors
+	}
+	return nil
+}
 
+// decodeResult decodes the GraphQL response read from r, populating its data into target,
+// and returns its errors in the result.
+func (c *Client) decodeResult(r io.Reader, target interface{}, opts requestOptions) (PartialResult, error) {
+	out := responseEnvelope{
+		Data:   responseData{client: c, target: target, raw: opts.rawData, strict: c.Strict},
+		Errors: responseErrors{client: c},
+	}
+	if opts.strictSet {
+		out.Data.strict = opts.strict
+	}
+	recorder := bodyRecorder{r: r, buf: getBuffer()}
+	defer putBuffer(recorder.buf)
+	var err error
+	if c.envelope != nil {
+		err = c.decodeEnvelope(recorder, c.envelope, &out)
+	} else {
+		err = c.decode(recorder, &out)
+	}
+	if err != nil && len(bytes.TrimSpace(recorder.buf.Bytes())) == 0 {
+		return PartialResult{}, decodeError(ErrMalformedResponse, recorder.body())
+	}
 	if err != nil {
-		return nil, err
+		e := decodeError(err, recorder.body())
+		var syntaxErr *json.SyntaxError
+		if out.Data.failed || (c.envelope != nil && !errors.As(err, &syntaxErr)) {
+			// The offset is relative to the data, or to the value at a path of the envelope, not to the body.
+			e.Offset = -1
+		}
+		return PartialResult{}, e
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return nil, fmt.Errorf("non-200 OK status code: %v body: %q", resp.Status, body)
+	annotateFields(out.Errors.errs, reflect.TypeOf(target))
+	if !out.Data.present && len(out.Errors.errs) == 0 {
+		return PartialResult{}, decodeError(ErrMalformedResponse, recorder.body())
 	}
-	var out struct {
-		Data   *json.RawMessage
-		Errors errors
-		//Extensions interface{} // Unused.
+	if out.Data.missing != nil && len(out.Errors.errs) == 0 {
+		// With errors, required fields may be null because they failed, as the errors tell.
+		return PartialResult{}, decodeError(out.Data.missing, recorder.body())
 	}
+	if opts.extensions != nil && len(out.Extensions) > 0 {
+		if err := json.Unmarshal(out.Extensions, opts.extensions); err != nil {
+			return PartialResult{}, decodeError(fmt.Errorf("decoding extensions: %w", err), recorder.body())
+		}
+	}
+	if opts.meta != nil && len(out.Extensions) > 0 {
+		if err := json.Unmarshal(out.Extensions, &opts.meta.Extensions); err != nil {
+			return PartialResult{}, decodeError(fmt.Errorf("decoding extensions: %w", err), recorder.body())
+		}
+	}
+	return PartialResult{HasData: out.Data.present, Errors: out.Errors.errs}, nil
+}
 
-	// If input was a manual request, then use output from manual request
+// responseData decodes the data of a response into target, as it is decoded,
+// recording whether the response had data that wasn't null, and whether required fields of target are missing.
+// If raw is set, the data is also copied into it, as is.
+type responseData struct {
+	client  *Client
+	target  interface{}
+	raw     *json.RawMessage
+	strict  bool
+	present bool
+	failed  bool
+	missing error
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *responseData) UnmarshalJSON(data []byte) error {
+	if d.raw != nil {
+		*d.raw = append((*d.raw)[:0], data...)
+	}
+	if string(data) == "null" {
+		return nil
+	}
+	d.present = true
+	target := d.target
+	if v := reflect.ValueOf(target); v.Kind() == reflect.Map && !v.IsNil() {
+		// A map is filled in place, as through a pointer to it.
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		target = p.Interface()
+	}
+	if err := d.client.unmarshalData(data, target, d.strict); err != nil {
+		d.failed = true
+		return err
+	}
+	if d.client.unknownEnums == RejectUnknownEnums {
+		if err := checkEnums(reflect.ValueOf(target)); err != nil {
+			d.failed = true
+			return err
+		}
+	}
+	if t := reflect.TypeOf(d.target); hasRequired(t) || hasDefaults(t) {
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		if hasRequired(t) {
+			d.missing = checkRequired(t, value)
+		}
+		if hasDefaults(t) {
+			if err := applyDefaults(reflect.ValueOf(d.target), value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// execute builds the HTTP request for a single GraphQL operation and sends it.
+// If v is a ManualRequest, it is returned so that the caller can decode into its Result.
+//
+// On success, the returned response has a 200 OK status code, and the caller is responsible for closing its body.
+func (c *Client) execute(ctx context.Context, op operationType, v interface{}, variables map[string]interface{}, name string, options []RequestOption) (*http.Response, *ManualRequest, error) {
+	opts := newRequestOptions(options)
+	if opts.operationName != "" {
+		name = opts.operationName
+	}
+	in, uploads, manualRequest, err := c.newPayload(op, v, variables, name, opts.directives)
+	if err != nil {
+		return nil, nil, err
+	}
+	var header http.Header
 	if manualRequest != nil {
+		header = manualRequest.Headers
+	}
 
-		var target interface{} = v
+	if opts.proxySet {
+		ctx = context.WithValue(ctx, proxyOverrideKey{}, opts.proxyURL)
+	}
 
-		if manualRequest != nil {
-			target = manualRequest.Result
+	var cacheKey, scope string
+	cacheable := c.cache != nil && op == queryOperation && len(uploads) == 0 && opts.cachePolicy != NoCache
+	normalizable := c.normalized != nil && len(uploads) == 0 && opts.cachePolicy != NoCache
+	if cacheable || normalizable {
+		scope, err = c.requestScope(ctx, header, opts)
+		if err != nil {
+			return nil, nil, err
 		}
-
-		err = json.NewDecoder(resp.Body).Decode(target)
-		return nil, err
+	}
+	if op == queryOperation && (cacheable || normalizable) {
+		cacheKey = requestKey(in)
+		if scope != "" {
+			cacheKey += "-" + scope
+		}
+	}
+	readCache := opts.cachePolicy == CacheFirst || opts.cachePolicy == CacheOnly
+	if cacheable && readCache {
+		resp, conditional, stale := c.cache.lookup(ctx, cacheKey)
+		if conditional != nil {
+			for key, values := range header {
+				conditional[key] = values
+			}
+			header = conditional
+		}
+		if stale && opts.cachePolicy != CacheOnly {
+			refreshOpts := opts
+			refreshOpts.meta = nil // The metadata is of the cached response returned to the caller.
+			c.cache.refresh(cacheKey, func() ([]byte, error) {
+				resp, err := c.fetch(detachedContext{ctx}, op, in, uploads, header, refreshOpts, cacheKey, scope, cacheable, normalizable)
+				if err != nil {
+					return nil, err
+				}
+				defer resp.Body.Close()
+				return readAll(resp.Body)
+			})
+		}
+		if resp != nil {
+			return resp, manualRequest, nil
+		}
+	}
+	if normalizable && op == queryOperation && readCache {
+		if body := c.normalized.read(ctx, scope, cacheKey, in); body != nil {
+			return syntheticResponse(body, nil), manualRequest, nil
+		}
+	}
+	if opts.cachePolicy == CacheOnly {
+		return nil, nil, ErrCacheMiss
 	}
 
-	// Do standard
-	err = json.NewDecoder(resp.Body).Decode(&out)
+	resp, err := c.fetch(ctx, op, in, uploads, header, opts, cacheKey, scope, cacheable, normalizable)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, manualRequest, nil
+}
 
+// fetch sends a single GraphQL operation to the server, and stores its response in the caches.
+// If cacheable is true, the response cache is used with cacheKey, and if normalizable is true,
+// the response is written to the normalized cache, in the given scope (see requestScope).
+//
+// On success, the returned response has a 200 OK status code, and the caller is responsible for closing its body.
+func (c *Client) fetch(ctx context.Context, op operationType, in requestPayload, uploads []fileUpload, header http.Header, opts requestOptions, cacheKey, scope string, cacheable, normalizable bool) (*http.Response, error) {
+	resp, err := c.roundTrip(ctx, op, in, uploads, header, opts)
 	if err != nil {
-		// TODO: Consider including response body in returned error, if deemed helpful.
 		return nil, err
 	}
-
-	if len(out.Errors) > 0 {
-		return out.Data, out.Errors
+	if cacheable && resp.StatusCode == http.StatusNotModified {
+		if cached := c.cache.revalidated(ctx, cacheKey, resp.Header, opts.cacheTTL); cached != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			return cached, nil
+		}
 	}
-
-	return out.Data, nil
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, c.statusError(resp)
+	}
+	if cacheable {
+		if err := c.cache.store(ctx, cacheKey, resp, opts.cacheTTL); err != nil {
+			return nil, err
+		}
+	}
+	if normalizable {
+		if err := c.normalized.write(ctx, scope, cacheKey, in, resp); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
 }
 
-// Do executes a single GraphQL operation and unmarshal json.
-func (c *Client) Do(ctx context.Context, op operationType, v interface{}, variables map[string]interface{}, name string) error {
+// roundTrip sends a single GraphQL operation, batched or as a persisted query if the client is configured so,
+// and returns the response.
+func (c *Client) roundTrip(ctx context.Context, op operationType, in requestPayload, uploads []fileUpload, header http.Header, opts requestOptions) (*http.Response, error) {
+	if c.batcher != nil && canBatch(ctx, header, uploads, opts) {
+		return c.batcher.do(ctx, c, op, in)
+	}
+	if c.persisted != nil && c.manifest == nil && len(uploads) == 0 && c.persisted.supported() {
+		in.Extensions = map[string]interface{}{"persistedQuery": persistedQueryExtension(documentHash(in.Query))}
+		resp, err := c.sendPersisted(ctx, op, in, header, opts)
+		if err != nil || resp != nil {
+			return resp, err
+		}
+		if !c.persisted.supported() {
+			in.Extensions = nil
+		}
+	}
+	body, err := c.encodeRequestBody(in, uploads, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(ctx, op, body, header, opts)
+}
 
+// newPayload builds the payload of a single GraphQL operation, with the file uploads in its variables,
+// and directives added to the operation.
+// If v is a ManualRequest, it is returned so that the caller can decode into its Result.
+func (c *Client) newPayload(op operationType, v interface{}, variables map[string]interface{}, name string, directives []string) (requestPayload, []fileUpload, *ManualRequest, error) {
 	var query string
 	var manualRequest *ManualRequest
 
+	if err := validateVariables(variables); err != nil {
+		return requestPayload{}, nil, nil, err
+	}
 	mr, ok := v.(ManualRequest)
 
 	if ok {
 		manualRequest = &mr
 		query = manualRequest.Query
-
+		if name == "" {
+			name = documentOperationName(query)
+		}
+		if name == "" {
+			name = c.typeOperationName(mr.Result)
+		}
+		query = nameOperation(query, name)
 	} else {
-		switch op {
-		case queryOperation:
-			query = constructQuery(v, variables, name)
-		case mutationOperation:
-			query = constructMutation(v, variables, name)
+		if name == "" {
+			name = c.typeOperationName(v)
+		}
+		var err error
+		if query, err = c.constructOperation(op, v, variables, name); err != nil {
+			return requestPayload{}, nil, nil, err
 		}
 	}
-
-	in := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables,omitempty"`
-	}{
-		Query:     query,
-		Variables: variables,
+	query = addOperationDirectives(query, directives)
+	if c.minifyDocuments {
+		query = normalizeDocument(query)
 	}
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(in)
+
+	variables, err := marshalVariables(variables, marshalOptions{
+		times:               c.timeFormat,
+		bigNumbersAsStrings: c.bigNumbersAsStrings,
+		bytes:               c.bytesEncoding,
+		numericIDs:          c.numericIDs,
+		gqlgen:              c.gqlgenModels,
+	})
 	if err != nil {
-		return err
+		return requestPayload{}, nil, nil, err
+	}
+	variables, uploads := extractUploads(variables)
+	in := requestPayload{
+		Query:         query,
+		OperationName: name,
+		Variables:     variables,
+	}
+	if c.manifest != nil {
+		operationName, hash, err := c.manifest.lookup(name, query)
+		if err != nil {
+			return requestPayload{}, nil, nil, err
+		}
+		in.Query = ""
+		in.OperationName = operationName
+		in.Extensions = map[string]interface{}{"persistedQuery": persistedQueryExtension(hash)}
 	}
+	return in, uploads, manualRequest, nil
+}
 
-	httpRequest, err := http.NewRequest("POST", c.url, &buf)
+// requestPayload is the JSON payload of a GraphQL request.
+type requestPayload struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
 
+// encodeRequestBody encodes in as the body of a request,
+// as JSON or, if there are file uploads, as a multipart form.
+func (c *Client) encodeRequestBody(in requestPayload, uploads []fileUpload, opts requestOptions) (requestBody, error) {
+	data, err := c.marshal(in)
 	if err != nil {
-		return err
+		return requestBody{}, err
 	}
+	if len(uploads) > 0 {
+		return multipartBody(data, uploads, opts.uploadProgress)
+	}
+	return requestBody{contentType: "application/json", data: data}, nil
+}
 
-	// Default headers first
-	for key, value := range c.DefaultHeaders {
-		httpRequest.Header[key] = value
+// requestBody is the encoded body of a GraphQL request.
+type requestBody struct {
+	contentType string
+	data        []byte
+	// contentEncoding is the encoding of data, e.g. "gzip"; empty if not compressed.
+	contentEncoding string
+
+	// open, if set, streams the body instead of data. It is called every time the body is sent.
+	open func() (io.ReadCloser, error)
+	// length is the length of the streamed body, or -1 if unknown.
+	length int64
+	// reopenable reports whether open can be called again once the body was read, e.g. after an authentication challenge.
+	reopenable bool
+
+	// params, if set, are sent as URL query parameters of a GET request instead,
+	// unless the resulting URL would be longer than maxURLLength.
+	params       url.Values
+	maxURLLength int
+}
+
+// send posts the encoded GraphQL request body to the server.
+// If the client has several endpoints, they are tried in turn until one is available.
+func (c *Client) send(ctx context.Context, op operationType, body requestBody, header http.Header, opts requestOptions) (*http.Response, error) {
+	body, err := c.compress(body)
+	if err != nil {
+		return nil, err
+	}
+	if c.endpoints == nil {
+		return c.sendTo(ctx, c.url, op, body, header, opts)
 	}
 
-	// Request-specific headers next
-	for key, value := range mr.Headers {
-		httpRequest.Header[key] = value
+	urls := c.endpoints.order(opts.affinityKey)
+	for i, url := range urls {
+		resp, err := c.sendTo(ctx, url, op, body, header, opts)
+		last := i == len(urls)-1
+		if err != nil {
+			c.endpoints.failed(url)
+			if last || ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+		if isUnavailable(resp.StatusCode) {
+			c.endpoints.failed(url)
+			if !last {
+				_, _ = io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+				continue
+			}
+		}
+		c.endpoints.succeeded(url, resp)
+		return resp, nil
 	}
+	panic("unreachable")
+}
 
-	resp, err := ctxhttp.Do(ctx, c.httpClient, httpRequest)
+// sendTo posts the encoded GraphQL request body to the server at url.
+// If the server rejects the credentials with 401 Unauthorized or 403 Forbidden,
+// and an auth refresh callback is configured, the credentials are refreshed
+// and the request is retried exactly once.
+func (c *Client) sendTo(ctx context.Context, url string, op operationType, body requestBody, header http.Header, opts requestOptions) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		httpRequest, err := c.newHTTPRequest(ctx, url, op, body, header, opts)
+		if err != nil {
+			return nil, err
+		}
 
-	if err != nil {
-		return err
+		if opts.meta != nil {
+			opts.meta.RequestSize = httpRequest.ContentLength
+		}
+		resp, err := c.doHTTP(httpRequest)
+		if err != nil {
+			return nil, networkError(err)
+		}
+		if opts.meta != nil {
+			opts.meta.StatusCode = resp.StatusCode
+			opts.meta.Header = resp.Header
+		}
+		if err := decompressResponse(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		if c.maxResponseBytes > 0 {
+			resp.Body = &limitedBody{ReadCloser: resp.Body, limit: c.maxResponseBytes}
+		}
+		if c.csrf != nil {
+			c.csrf.observe(resp)
+		}
+
+		if attempt == 0 && c.authRefresh != nil &&
+			(resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			if err := c.authRefresh(ctx); err != nil {
+				return nil, fmt.Errorf("refreshing credentials: %v", err)
+			}
+			continue
+		}
+		return resp, nil
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("non-200 OK status code: %v body: %q", resp.Status, body)
+}
+
+// newHTTPRequest creates the HTTP request posting body to url, with all headers and credentials applied.
+// header holds the request-specific headers, and opts the per-request options.
+func (c *Client) newHTTPRequest(ctx context.Context, url string, op operationType, body requestBody, header http.Header, opts requestOptions) (*http.Request, error) {
+	get := false
+	if body.params != nil {
+		separator := "?"
+		if strings.Contains(url, "?") {
+			separator = "&"
+		}
+		if getURL := url + separator + body.params.Encode(); len(getURL) <= body.maxURLLength {
+			url, get = getURL, true
+		}
 	}
-	var out struct {
-		Data   *json.RawMessage
-		Errors errors
-		//Extensions interface{} // Unused.
+	var httpRequest *http.Request
+	var err error
+	if get {
+		httpRequest, err = http.NewRequestWithContext(ctx, "GET", url, nil)
+	} else {
+		httpRequest, err = http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body.data))
 	}
-	err = json.NewDecoder(resp.Body).Decode(&out)
 	if err != nil {
-		// TODO: Consider including response body in returned error, if deemed helpful.
-		return err
+		return nil, err
 	}
-	if out.Data != nil {
 
-		var target interface{} = v
+	// Built-in headers first
+	if !get {
+		httpRequest.Header.Set("Content-Type", body.contentType)
+		if body.contentEncoding != "" {
+			httpRequest.Header.Set("Content-Encoding", body.contentEncoding)
+		}
+	}
+	if get || strings.HasPrefix(body.contentType, "multipart/") {
+		// GET and multipart requests are simple CORS requests; servers such as Apollo Server
+		// only accept them with a header proving that they aren't cross-site requests.
+		httpRequest.Header.Set("Apollo-Require-Preflight", "true")
+	}
+	httpRequest.Header.Set("Accept", "application/json")
+	httpRequest.Header.Set("Accept-Encoding", acceptEncoding)
+	httpRequest.Header.Set("User-Agent", c.userAgent)
+	if err := c.setHeaders(ctx, httpRequest, header, opts); err != nil {
+		return nil, err
+	}
 
-		if manualRequest != nil {
-			target = manualRequest.Result
+	if op == mutationOperation && c.csrf != nil {
+		if err := c.csrf.apply(ctx, c, httpRequest); err != nil {
+			return nil, err
 		}
+	}
 
-		err := json.Unmarshal(*out.Data, target)
+	// Streamed bodies last, so that they are only opened once nothing else can fail
+	if body.open != nil {
+		httpRequest.Body, err = body.open()
 		if err != nil {
-			// TODO: Consider including response body in returned error, if deemed helpful.
-			return err
+			return nil, err
+		}
+		httpRequest.ContentLength = body.length
+		httpRequest.GetBody = nil
+		if body.reopenable {
+			httpRequest.GetBody = body.open
 		}
 	}
-	if len(out.Errors) > 0 {
-		return out.Errors
-	}
-	return nil
+	return httpRequest, nil
 }
 
-// errors represents the "errors" array in a response from a GraphQL server.
-// If returned via error interface, the slice is expected to contain at least 1 element.
-//
-// Specification: https://facebook.github.io/graphql/#sec-Errors.
-type errors []struct {
-	Extensions interface{}
-	Message    string
-	Locations  []struct {
-		Line   int
-		Column int
+// setHeaders sets the headers of httpRequest that may differ between clients and requests:
+// default headers, credentials, context headers and the request-specific header.
+func (c *Client) setHeaders(ctx context.Context, httpRequest *http.Request, header http.Header, opts requestOptions) error {
+	// Default headers first
+	for key, value := range c.DefaultHeaders {
+		httpRequest.Header[key] = value
 	}
-}
+	c.headers.apply(httpRequest.Header)
 
-// Error implements error interface.
-func (e errors) Error() string {
-	b := strings.Builder{}
-	for _, err := range e {
-		b.WriteString(fmt.Sprintf("Message: %s, Locations: %+v", err.Message, err.Locations))
+	// Credentials next, so that request-specific headers can still override them
+	credentials := c.credentials
+	if opts.credentials != nil {
+		credentials = opts.credentials
+	}
+	if credentials != nil {
+		if err := credentials(ctx, httpRequest); err != nil {
+			return err
+		}
 	}
-	return b.String()
+
+	// Context headers next
+	if h, ok := ctx.Value(contextHeadersKey{}).(http.Header); ok {
+		for key, value := range h {
+			httpRequest.Header[key] = value
+		}
+	}
+
+	// Request-specific headers last
+	for key, value := range header {
+		httpRequest.Header[key] = value
+	}
+	return nil
 }
 
 type operationType uint8
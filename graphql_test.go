@@ -2,12 +2,15 @@ package graphql_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/darrensapalo/go-graphql-client"
 )
@@ -81,6 +84,158 @@ func TestClient_Query_NoGraphQLStructuralFlagsJSONObject(t *testing.T) {
 	}
 }
 
+func TestClient_Query_untypedResult(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	want := map[string]interface{}{"viewer": map[string]interface{}{"login": "gopher"}}
+
+	var m map[string]interface{}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &m}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got map: %v, want: %v", m, want)
+	}
+
+	m = map[string]interface{}{}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: m}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got map filled in place: %v, want: %v", m, want)
+	}
+
+	var raw json.RawMessage
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &raw}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(raw), `{"viewer": {"login": "gopher"}}`; got != want {
+		t.Errorf("got raw data: %s, want: %s", got, want)
+	}
+}
+
+func TestClient_Query_lists(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {
+			"users": [{"login": "a"}, null, {"login": "b"}],
+			"grid": [[{"login": "c"}, null], null, []],
+			"tags": ["x", null],
+			"none": null
+		}}`)
+	})
+	type user struct {
+		Login graphql.String
+	}
+	type query struct {
+		Users []*user
+		Grid  [][]*user
+		Tags  []*graphql.String
+		None  []user
+	}
+	want := query{
+		Users: []*user{{Login: "a"}, nil, {Login: "b"}},
+		Grid:  [][]*user{{{Login: "c"}, nil}, nil, {}},
+		Tags:  []*graphql.String{graphql.NewString("x"), nil},
+	}
+	// The selection set of list elements is written once, whatever the nesting of lists.
+	doc, err := graphql.ConstructQuery(&query{}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{users{login},grid{login},tags,none{login}}`; doc != want {
+		t.Fatalf("got document: %s, want: %s", doc, want)
+	}
+
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	strict := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	strict.Strict = true
+	for name, client := range map[string]*graphql.Client{
+		"default": client,
+		"strict":  strict,
+		"hooks":   client.WithDecodeHooks(graphql.StringToTimeHook(time.RFC3339)),
+	} {
+		// Lists of previous results are replaced.
+		q := query{Users: make([]*user, 5), None: []user{{Login: "stale"}}}
+		if err := client.Query(context.Background(), graphql.ManualRequest{Query: doc, Result: &q}, nil); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(q, want) {
+			t.Errorf("%s: got: %+v, want: %+v", name, q, want)
+		}
+	}
+}
+
+func TestClient_Query_rawData(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher", "name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	var raw json.RawMessage
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login,name}}", Result: &q}, nil, graphql.WithRawData(&raw)); err != nil {
+		t.Fatal(err)
+	}
+	if q.Viewer.Login != "gopher" {
+		t.Errorf("got login: %q, want: %q", q.Viewer.Login, "gopher")
+	}
+	if got, want := string(raw), `{"viewer": {"login": "gopher", "name": "Gopher"}}`; got != want {
+		t.Errorf("got raw data: %s, want: %s", got, want)
+	}
+}
+
+func TestClient_Query_extensions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"extensions": {"cost": {"requestedQueryCost": 3, "throttleStatus": {"currentlyAvailable": 997}}}, "data": {"viewer": {"login": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	var extensions struct {
+		Cost struct {
+			RequestedQueryCost int
+			ThrottleStatus     struct {
+				CurrentlyAvailable int
+			}
+		}
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil, graphql.WithExtensions(&extensions)); err != nil {
+		t.Fatal(err)
+	}
+	if extensions.Cost.RequestedQueryCost != 3 || extensions.Cost.ThrottleStatus.CurrentlyAvailable != 997 {
+		t.Errorf("got extensions: %+v", extensions)
+	}
+	if q.Viewer.Login != "gopher" {
+		t.Errorf("got login: %q, want: %q", q.Viewer.Login, "gopher")
+	}
+
+	var m map[string]interface{}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil, graphql.WithExtensions(&m)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["cost"]; !ok {
+		t.Errorf("got extensions: %v, want cost", m)
+	}
+}
+
 func TestClient_Query_noDataWithErrorResponse(t *testing.T) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
@@ -125,6 +280,123 @@ func TestClient_Query_noDataWithErrorResponse(t *testing.T) {
 	}
 }
 
+func TestClient_Query_partialDataWithErrorResponse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"errors": [{"message": "name is private"}], "data": {"user": {"login": "gopher", "name": null}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		User struct {
+			Login graphql.String
+			Name  *graphql.String
+		}
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "{user{login,name}}", Result: &q}, nil)
+	if got, want := fmt.Sprint(err), "Message: name is private, Locations: []"; got != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+	if q.User.Login != "gopher" || q.User.Name != nil {
+		t.Errorf("got user: %+v, want login gopher and no name", q.User)
+	}
+
+	// Results that aren't pointers can't be populated.
+	err = client.Query(context.Background(), graphql.ManualRequest{Query: "{user{login,name}}", Result: q}, nil)
+	if err == nil {
+		t.Error("got error: nil, want: non-nil")
+	}
+}
+
+func TestClient_Query_errorsAsWarnings(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		body         string
+		wantErr      bool
+		wantWarnings int
+	}{
+		{
+			name:         "field errors",
+			body:         `{"errors": [{"message": "name is private", "path": ["user", "name"]}], "data": {"user": {"login": "gopher", "name": null}}}`,
+			wantWarnings: 1,
+		},
+		{
+			name:    "request error",
+			body:    `{"errors": [{"message": "name is private", "path": ["user", "name"]}, {"message": "too complex"}], "data": {"user": {"login": "gopher", "name": null}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "no data",
+			body:    `{"errors": [{"message": "user failed", "path": ["user"]}], "data": null}`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, tc.body)
+			})
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+			var q struct {
+				User struct {
+					Login graphql.String
+					Name  *graphql.String
+				}
+			}
+			var warnings graphql.GraphQLErrors
+			err := client.Query(context.Background(), graphql.ManualRequest{Query: "{user{login,name}}", Result: &q}, nil,
+				graphql.WithErrorsAsWarnings(func(errs graphql.GraphQLErrors) { warnings = append(warnings, errs...) }))
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("got error: %v, want error: %v", err, tc.wantErr)
+			}
+			if len(warnings) != tc.wantWarnings {
+				t.Errorf("got %d warnings, want: %d", len(warnings), tc.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestClient_QueryPartial(t *testing.T) {
+	for _, tc := range []struct {
+		body        string
+		wantHasData bool
+		wantErrors  int
+		wantLogin   graphql.String
+	}{
+		{body: `{"data": {"user": {"login": "gopher"}}}`, wantHasData: true, wantLogin: "gopher"},
+		{body: `{"data": {"user": {"login": "gopher"}}, "errors": [{"message": "name is private", "path": ["user", "name"]}]}`, wantHasData: true, wantErrors: 1, wantLogin: "gopher"},
+		{body: `{"data": null, "errors": [{"message": "not found"}]}`, wantErrors: 1},
+		{body: `{"errors": [{"message": "syntax error"}, {"message": "another error"}]}`, wantErrors: 2},
+	} {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			mustWrite(w, tc.body)
+		})
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+		var q struct {
+			User struct {
+				Login graphql.String
+				Name  *graphql.String
+			}
+		}
+		result, err := client.QueryPartial(context.Background(), graphql.ManualRequest{Query: "{user{login,name}}", Result: &q}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.HasData != tc.wantHasData || len(result.Errors) != tc.wantErrors {
+			t.Errorf("%s: got result: %+v, want data: %v and %d errors", tc.body, result, tc.wantHasData, tc.wantErrors)
+		}
+		if q.User.Login != tc.wantLogin {
+			t.Errorf("%s: got login: %q, want: %q", tc.body, q.User.Login, tc.wantLogin)
+		}
+	}
+}
+
 func TestClient_Query_errorStatusCode(t *testing.T) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
@@ -156,6 +428,104 @@ func TestClient_Query_errorStatusCode(t *testing.T) {
 	}
 }
 
+func TestClient_WithHeaders_WithURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got := req.Header.Get("X-Tenant"); got != "" {
+			t.Errorf("got X-Tenant: %q on base client, want: none", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"endpoint": "base"}}`)
+	})
+	mux.HandleFunc("/tenant/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.Header.Get("X-Tenant"), "acme"; got != want {
+			t.Errorf("got X-Tenant: %q, want: %q", got, want)
+		}
+		if got, want := req.Header.Get("X-Shared"), "shared"; got != want {
+			t.Errorf("got X-Shared: %q, want: %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"endpoint": "tenant"}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	client.SetHeader("X-Shared", "shared")
+
+	derived := client.WithHeaders(http.Header{"x-tenant": {"acme"}}).WithURL("/tenant/graphql")
+	client.SetHeader("X-Base-Only", "base")
+	if got := derived.Header().Get("X-Base-Only"); got != "" {
+		t.Errorf("got X-Base-Only: %q on derived client, want: none", got)
+	}
+	if got := client.Clone().Header().Get("X-Base-Only"); got != "base" {
+		t.Errorf("got X-Base-Only: %q on clone, want: %q", got, "base")
+	}
+
+	for _, tc := range []struct {
+		client *graphql.Client
+		want   string
+	}{
+		{client, "base"},
+		{derived, "tenant"},
+	} {
+		var q struct {
+			Endpoint string
+		}
+		err := tc.client.Query(context.Background(), graphql.ManualRequest{Query: "{endpoint}", Result: &q}, nil)
+		if err != nil {
+			t.Fatalf("got error: %v, want: nil", err)
+		}
+		if q.Endpoint != tc.want {
+			t.Errorf("got endpoint: %q, want: %q", q.Endpoint, tc.want)
+		}
+	}
+}
+
+func TestClient_Query_requestContext(t *testing.T) {
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	client := graphql.NewClient("/graphql", &http.Client{Transport: graphql.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if got := req.Context().Value(key{}); got != "value" {
+			t.Errorf("got context value: %v, want: value", got)
+		}
+		cancel()
+		return nil, fmt.Errorf("connection reset")
+	})})
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	err := client.Query(ctx, graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+	if err != context.Canceled {
+		t.Errorf("got error: %v, want: %v", err, context.Canceled)
+	}
+}
+
+func BenchmarkClient_Query(b *testing.B) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.Copy(ioutil.Discard, req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"login": "gopher", "name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	variables := map[string]interface{}{"login": graphql.String("gopher")}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var q struct {
+			User struct {
+				Login graphql.String
+				Name  graphql.String
+			}
+		}
+		err := client.Query(context.Background(), graphql.ManualRequest{Query: "query($login:String!){user(login:$login){login,name}}", Result: &q}, variables)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 // localRoundTripper is an http.RoundTripper that executes HTTP transactions
 // by using handler directly, instead of going over an HTTP connection.
 type localRoundTripper struct {
@@ -182,3 +552,24 @@ func mustWrite(w io.Writer, s string) {
 		panic(err)
 	}
 }
+
+func TestConstructQuery(t *testing.T) {
+	var q struct {
+		Repository struct {
+			Name graphql.String
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	variables := map[string]interface{}{
+		"owner": graphql.String("golang"),
+		"name":  graphql.String("go"),
+	}
+	if got, err := graphql.ConstructQuery(&q, variables, "GetRepository"); err != nil || got != `query GetRepository($name:String!$owner:String!){repository(owner: $owner, name: $name){name}}` {
+		t.Errorf("got: %v, %v", got, err)
+	}
+	if got, err := graphql.ConstructMutation(&q, nil, ""); err != nil || got != `mutation{repository(owner: $owner, name: $name){name}}` {
+		t.Errorf("got: %v, %v", got, err)
+	}
+	if got, err := graphql.ConstructSubscription(&q, nil, "Watch"); err != nil || got != `subscription Watch{repository(owner: $owner, name: $name){name}}` {
+		t.Errorf("got: %v, %v", got, err)
+	}
+}
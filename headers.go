@@ -0,0 +1,120 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+)
+
+// DefaultUserAgent is the User-Agent sent by clients, unless changed with WithUserAgent.
+// It includes the library version when available from the build information.
+var DefaultUserAgent = defaultUserAgent()
+
+// modulePath is the import path of this module, used to find its version in the build information.
+const modulePath = "github.com/darrensapalo/go-graphql-client"
+
+func defaultUserAgent() string {
+	const name = "go-graphql-client"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return name
+	}
+	modules := append([]*debug.Module{&info.Main}, info.Deps...)
+	for _, m := range modules {
+		if m.Path == modulePath && m.Version != "" && m.Version != "(devel)" {
+			return name + "/" + m.Version
+		}
+	}
+	return name
+}
+
+// WithUserAgent returns a copy of the client that sends userAgent as the User-Agent header.
+// An empty userAgent makes the client send no User-Agent at all.
+func (c *Client) WithUserAgent(userAgent string) *Client {
+	c2 := c.clone()
+	c2.userAgent = userAgent
+	return c2
+}
+
+// headerStore holds the client's default headers.
+// It is safe for concurrent use, and copied when a client is derived from another.
+type headerStore struct {
+	mu     sync.RWMutex
+	header http.Header
+}
+
+// SetHeader sets the default header key to value, replacing any existing values.
+// Default headers are sent with every request. It is safe to call concurrently with requests.
+func (c *Client) SetHeader(key, value string) {
+	c.headers.mu.Lock()
+	c.headers.header.Set(key, value)
+	c.headers.mu.Unlock()
+}
+
+// AddHeader adds value to the default header key, appending to any existing values.
+func (c *Client) AddHeader(key, value string) {
+	c.headers.mu.Lock()
+	c.headers.header.Add(key, value)
+	c.headers.mu.Unlock()
+}
+
+// DeleteHeader removes the default header key.
+func (c *Client) DeleteHeader(key string) {
+	c.headers.mu.Lock()
+	c.headers.header.Del(key)
+	c.headers.mu.Unlock()
+}
+
+// Header returns a copy of the default headers.
+func (c *Client) Header() http.Header {
+	c.headers.mu.RLock()
+	defer c.headers.mu.RUnlock()
+	return c.headers.header.Clone()
+}
+
+// apply copies the stored headers into h.
+func (s *headerStore) apply(h http.Header) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, values := range s.header {
+		h[key] = append([]string(nil), values...)
+	}
+}
+
+// clone returns an independent copy of s.
+func (s *headerStore) clone() *headerStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &headerStore{header: s.header.Clone()}
+}
+
+// contextHeadersKey is the context key of the headers set by WithContextHeaders.
+type contextHeadersKey struct{}
+
+// WithContextHeaders returns a copy of ctx carrying headers that the client adds to outgoing requests.
+// It allows forwarding per-end-user values, such as authorization, through layers
+// that don't know about the GraphQL client.
+//
+// Headers from nested calls are merged, with the innermost values taking precedence.
+// Context headers override the client's default headers and credentials,
+// but are overridden by the request-specific headers of a ManualRequest.
+func WithContextHeaders(ctx context.Context, h http.Header) context.Context {
+	merged := ContextHeaders(ctx)
+	if merged == nil {
+		merged = make(http.Header, len(h))
+	}
+	for key, values := range h {
+		merged[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	return context.WithValue(ctx, contextHeadersKey{}, merged)
+}
+
+// ContextHeaders returns a copy of the headers stored in ctx by WithContextHeaders, or nil if there are none.
+func ContextHeaders(ctx context.Context) http.Header {
+	h, _ := ctx.Value(contextHeadersKey{}).(http.Header)
+	if h == nil {
+		return nil
+	}
+	return h.Clone()
+}
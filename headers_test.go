@@ -0,0 +1,133 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestWithContextHeaders(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		for key, want := range map[string]string{
+			"Authorization": "Bearer end-user",
+			"X-Tenant":      "inner",
+			"X-Request-Id":  "outer",
+			"X-Default":     "default",
+			"X-Override":    "request",
+		} {
+			if got := req.Header.Get(key); got != want {
+				t.Errorf("got %s: %q, want: %q", key, got, want)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithTokenSource(graphql.StaticToken("service"))
+	client.SetHeader("X-Default", "default")
+	client.SetHeader("X-Tenant", "default")
+
+	ctx := graphql.WithContextHeaders(context.Background(), http.Header{
+		"X-Request-Id": {"outer"},
+		"X-Tenant":     {"outer"},
+	})
+	ctx = graphql.WithContextHeaders(ctx, http.Header{
+		"authorization": {"Bearer end-user"},
+		"X-Tenant":      {"inner"},
+		"X-Override":    {"context"},
+	})
+	if got, want := graphql.ContextHeaders(ctx).Get("X-Tenant"), "inner"; got != want {
+		t.Errorf("got ContextHeaders X-Tenant: %q, want: %q", got, want)
+	}
+
+	var q struct{}
+	err := client.Query(ctx, graphql.ManualRequest{
+		Query:   "{}",
+		Result:  &q,
+		Headers: http.Header{"X-Override": {"request"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+}
+
+func TestClient_SetHeader_concurrent(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.Header.Get("X-Static"), "static"; got != want {
+			t.Errorf("got X-Static: %q, want: %q", got, want)
+		}
+		if req.Header.Get("X-Removed") != "" {
+			t.Error("got X-Removed header, want: none")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	client.SetHeader("X-Static", "static")
+	client.SetHeader("X-Removed", "removed")
+	client.DeleteHeader("X-Removed")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			client.SetHeader("X-Counter", fmt.Sprint(i))
+			client.AddHeader("X-Values", "v")
+			client.DeleteHeader("X-Values")
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		var q struct{}
+		if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{}", Result: &q}, nil); err != nil {
+			t.Fatalf("got error: %v, want: nil", err)
+		}
+	}
+	<-done
+
+	if got, want := client.Header().Get("X-Counter"), "99"; got != want {
+		t.Errorf("got X-Counter: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_builtInHeaders(t *testing.T) {
+	var got http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	query := func(client *graphql.Client) {
+		var q struct{}
+		if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{}", Result: &q}, nil); err != nil {
+			t.Fatalf("got error: %v, want: nil", err)
+		}
+	}
+
+	query(client)
+	for key, want := range map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/json",
+		"User-Agent":   graphql.DefaultUserAgent,
+	} {
+		if got := got.Get(key); got != want {
+			t.Errorf("got %s: %q, want: %q", key, got, want)
+		}
+	}
+
+	derived := client.WithUserAgent("my-service/1.2.3")
+	derived.SetHeader("Accept", "application/graphql-response+json")
+	query(derived)
+	if got, want := got.Get("User-Agent"), "my-service/1.2.3"; got != want {
+		t.Errorf("got User-Agent: %q, want: %q", got, want)
+	}
+	if got, want := got.Get("Accept"), "application/graphql-response+json"; got != want {
+		t.Errorf("got Accept: %q, want: %q", got, want)
+	}
+}
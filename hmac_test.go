@@ -0,0 +1,134 @@
+package graphql_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_WithMiddleware_HMACSigner(t *testing.T) {
+	key := []byte("secret")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := req.Header.Get("X-Gateway-Timestamp"), "1600000000"; got != want {
+			t.Errorf("got timestamp: %q, want: %q", got, want)
+		}
+		want := graphql.HMACSignature(key, nil, req.Method, graphql.HMACPath(req.URL), req.Header.Get("X-Gateway-Timestamp"), body)
+		if got := req.Header.Get("X-Signature"); got != want {
+			t.Errorf("got signature: %q, want: %q", got, want)
+		}
+		if got, want := req.Header.Get("X-Order"), "outer,inner"; got != want {
+			t.Errorf("got middleware order: %q, want: %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+	})
+
+	appendOrder := func(name string) graphql.Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return graphql.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req = req.Clone(req.Context())
+				order := name
+				if v := req.Header.Get("X-Order"); v != "" {
+					order = v + "," + name
+				}
+				req.Header.Set("X-Order", order)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithMiddleware(appendOrder("outer"), appendOrder("inner")).
+		WithMiddleware(graphql.HMACSigner(graphql.HMACConfig{
+			Key:             key,
+			TimestampHeader: "X-Gateway-Timestamp",
+			Now:             func() time.Time { return time.Unix(1600000000, 0) },
+		}))
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{
+		Query:     "{viewer{login}}",
+		Variables: map[string]interface{}{"first": 1},
+		Result:    &q,
+	}, nil)
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+	if got, want := q.Viewer.Login, graphql.String("gopher"); got != want {
+		t.Errorf("got q.Viewer.Login: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_WithMiddleware_HMACSigner_persistedQueriesGET(t *testing.T) {
+	key := []byte("secret")
+	var gotPath, gotSignature string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" {
+			t.Fatalf("got method: %s, want: GET", req.Method)
+		}
+		gotPath = graphql.HMACPath(req.URL)
+		gotSignature = req.Header.Get("X-Signature")
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"login": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithPersistedQueriesGET(0).
+		WithMiddleware(graphql.HMACSigner(graphql.HMACConfig{
+			Key: key,
+			Now: func() time.Time { return time.Unix(1600000000, 0) },
+		}))
+
+	var q struct {
+		User struct {
+			Login graphql.String
+		} `graphql:"user(login: $login)"`
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "query($login:String!){user(login:$login){login}}", Result: &q}, map[string]interface{}{"login": "gopher"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(gotPath, "/graphql?extensions=") || !strings.Contains(gotPath, "&variables=") {
+		t.Errorf("got signed path: %q, want the query string included", gotPath)
+	}
+	if want := graphql.HMACSignature(key, nil, "GET", gotPath, "1600000000", nil); gotSignature != want {
+		t.Errorf("got signature: %q, want: %q", gotSignature, want)
+	}
+	tampered := strings.Replace(gotPath, "gopher", "admin", 1)
+	if graphql.HMACSignature(key, nil, "GET", tampered, "1600000000", nil) == gotSignature {
+		t.Error("got the same signature with different variables")
+	}
+}
+
+func TestHMACPath(t *testing.T) {
+	for _, tc := range []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/graphql", "/graphql"},
+		{"https://example.com/a%2Fb", "/a%2Fb"},
+		{"https://example.com/graphql?variables=%7B%7D&extensions=x", "/graphql?extensions=x&variables=%7B%7D"},
+	} {
+		u, err := url.Parse(tc.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := graphql.HMACPath(u); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.url, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// DecodeHook adapts a value of the data of a response before it is decoded into a value of type to,
+// as mapstructure's decode hooks do. data is the value decoded from JSON, as into an interface{}: nil, bool,
+// float64 (or json.Number with WithUseNumber), string, []interface{} or map[string]interface{}; from is its type.
+//
+// A hook returns the value to decode instead, or data as is if it doesn't apply. If the returned value is
+// assignable to type to, it is used as is; otherwise it is decoded as usual.
+type DecodeHook func(from, to reflect.Type, data interface{}) (interface{}, error)
+
+// WithDecodeHooks returns a copy of the client that applies hooks, in order, to every value of the data of
+// responses before decoding it into the result, so that server scalar formats can be adapted without
+// implementing json.Unmarshaler on every type, e.g. to parse a string as a time.Time or a custom enum.
+// The hooks are added to those of the client.
+//
+// Decoding with hooks is slower, as the data is decoded generically first, and ignores WithDisallowUnknownFields.
+// It applies to the default encoding/json decoding only, not to a codec set with WithCodec.
+func (c *Client) WithDecodeHooks(hooks ...DecodeHook) *Client {
+	c2 := c.clone()
+	c2.decodeHooks = append(c.decodeHooks[:len(c.decodeHooks):len(c.decodeHooks)], hooks...)
+	return c2
+}
+
+// StringToTimeHook returns a DecodeHook parsing strings decoded into time.Time values with layout,
+// e.g. "2006-01-02 15:04:05" for servers that don't use RFC 3339.
+func StringToTimeHook(layout string) DecodeHook {
+	timeType := reflect.TypeOf(time.Time{})
+	return func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		s, ok := data.(string)
+		if !ok || to != timeType {
+			return data, nil
+		}
+		return time.Parse(layout, s)
+	}
+}
+
+// decodeWithHooks decodes the JSON-encoded data into v, a pointer, with d.
+// Numbers are decoded as json.Number, so that they are decoded into Go values without loss of precision,
+// and passed to hooks and set in interface{} values as float64 unless opts.useNumber is set.
+func decodeWithHooks(data []byte, v interface{}, d *hookDecoder, opts jsonOptions) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	d.useNumber = opts.useNumber
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+	return d.decode(rv.Elem(), value)
+}
+
+// hookDecoder decodes generic JSON values into Go values, applying hooks,
+// and decoding objects into interface values by their __typename with unions.
+type hookDecoder struct {
+	hooks  []DecodeHook
+	unions *unionTypes
+
+	// gqlgen decodes gqlgen custom scalars with their UnmarshalGQL method.
+	gqlgen bool
+
+	// useNumber keeps numbers as json.Number, rather than float64, for hooks and interface{} values.
+	useNumber bool
+}
+
+// decode decodes value into v, which must be settable, applying the hooks first.
+func (d *hookDecoder) decode(v reflect.Value, value interface{}) error {
+	number, isNumber := value.(json.Number)
+	isNumber = isNumber && !d.useNumber
+	var f float64
+	if isNumber {
+		f, _ = number.Float64()
+		value = f
+	}
+	for _, hook := range d.hooks {
+		var err error
+		value, err = hook(reflect.TypeOf(value), v.Type(), value)
+		if err != nil {
+			return err
+		}
+	}
+	if isNumber && v.Kind() != reflect.Interface {
+		if g, ok := value.(float64); ok && g == f {
+			// Left as is by the hooks, so decoded from its literal, without loss of precision.
+			value = number
+		}
+	}
+	if value != nil && reflect.TypeOf(value).AssignableTo(v.Type()) {
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+	if reflect.PtrTo(v.Type()).Implements(graphQLUnmarshalerType) {
+		return v.Addr().Interface().(GraphQLUnmarshaler).UnmarshalGraphQL(value)
+	}
+	if d.gqlgen && isGQLGenScalar(v.Type()) {
+		return v.Addr().Interface().(gqlgenUnmarshaler).UnmarshalGQL(value)
+	}
+	if v.Type() == bytesUUID {
+		return parseUUID(v, value)
+	}
+	if v.Type() == bigIntType || v.Type() == bigFloatType {
+		return decodeBigNumber(v, value)
+	}
+	if reflect.PtrTo(v.Type()).Implements(jsonUnmarshaler) {
+		return d.unmarshal(v, value)
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if types := d.unions.lookup(v.Type()); types != nil || d.unions.fallback(v.Type()) != nil {
+			return d.decodeUnion(v, value, types)
+		}
+		return d.unmarshal(v, value)
+	case reflect.Ptr:
+		if value == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(v.Elem(), value)
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return d.unmarshal(v, value)
+		}
+		return d.decodeStruct(v, object)
+	case reflect.Slice:
+		list, ok := value.([]interface{})
+		if !ok {
+			return d.unmarshal(v, value)
+		}
+		s := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := d.decode(s.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Array:
+		list, ok := value.([]interface{})
+		if !ok {
+			return d.unmarshal(v, value)
+		}
+		for i := 0; i < v.Len(); i++ {
+			if i < len(list) {
+				if err := d.decode(v.Index(i), list[i]); err != nil {
+					return err
+				}
+			} else {
+				v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+			}
+		}
+		return nil
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return d.unmarshal(v, value)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(object)))
+		}
+		for key, item := range object {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(elem, item); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		return nil
+	default:
+		return d.unmarshal(v, value)
+	}
+}
+
+// decodeStruct decodes the fields of object into the fields of the struct v, matched as encoding/json does,
+// including the fields of embedded structs.
+func (d *hookDecoder) decodeStruct(v reflect.Value, object map[string]interface{}) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, hasName := jsonName(f)
+		if f.Anonymous && !hasName {
+			embedded := v.Field(i)
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.Type().Elem().Kind() != reflect.Struct || !embedded.CanSet() {
+					continue
+				}
+				if embedded.IsNil() {
+					embedded.Set(reflect.New(embedded.Type().Elem()))
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := d.decodeStruct(embedded, object); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" || name == "-" {
+			continue
+		}
+		key, value := lookupField(object, name)
+		if key == "" {
+			continue
+		}
+		if err := d.decode(v.Field(i), value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// unmarshal decodes value into v, through its JSON encoding, as encoding/json does.
+func (d *hookDecoder) unmarshal(v reflect.Value, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v.Addr().Interface())
+}
@@ -0,0 +1,95 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+type status int
+
+const (
+	statusUnknown status = iota
+	statusActive
+	statusArchived
+)
+
+func TestClient_WithDecodeHooks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"repositories": [
+			{"name": "go", "createdAt": "2009-11-10 23:00:00", "status": "ACTIVE", "owner": {"login": "golang"}},
+			{"name": "old", "createdAt": "2001-02-03 04:05:06", "status": "ARCHIVED", "owner": null}
+		], "tags": {"lang": "go"}}}`)
+	})
+	statusHook := func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != reflect.TypeOf(status(0)) {
+			return data, nil
+		}
+		switch data {
+		case "ACTIVE":
+			return statusActive, nil
+		case "ARCHIVED":
+			return statusArchived, nil
+		}
+		return nil, fmt.Errorf("unknown status %v", data)
+	}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithDecodeHooks(graphql.StringToTimeHook("2006-01-02 15:04:05"), statusHook)
+
+	type owner struct {
+		Login graphql.String
+	}
+	var q struct {
+		Repositories []struct {
+			Name      string
+			CreatedAt time.Time
+			Status    status
+			Owner     *owner
+		}
+		Tags map[string]string
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "...", Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Repositories) != 2 {
+		t.Fatalf("got %d repositories, want 2", len(q.Repositories))
+	}
+	go1 := q.Repositories[0]
+	if go1.Name != "go" || !go1.CreatedAt.Equal(time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC)) || go1.Status != statusActive ||
+		go1.Owner == nil || go1.Owner.Login != "golang" {
+		t.Errorf("got first repository: %+v", go1)
+	}
+	if old := q.Repositories[1]; old.Status != statusArchived || old.Owner != nil {
+		t.Errorf("got second repository: %+v", old)
+	}
+	if q.Tags["lang"] != "go" {
+		t.Errorf("got tags: %v", q.Tags)
+	}
+}
+
+func TestClient_WithDecodeHooks_error(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"createdAt": "yesterday"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithDecodeHooks(graphql.StringToTimeHook("2006-01-02"))
+
+	var q struct {
+		Viewer struct {
+			CreatedAt time.Time
+		}
+	}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "...", Result: &q}, nil)
+	if err == nil {
+		t.Fatal("got error: nil, want a parsing error")
+	}
+}
@@ -210,6 +210,11 @@ func (d *decoder) decode() error {
 					}
 					for i := 0; i < v.NumField(); i++ {
 						if isGraphQLFragment(v.Type().Field(i)) || v.Type().Field(i).Anonymous {
+							// Embedded struct pointers are allocated so that their fields can be set, as encoding/json does.
+							if f := v.Field(i); !isGraphQLFragment(v.Type().Field(i)) && f.Kind() == reflect.Ptr && f.IsNil() &&
+								f.Type().Elem().Kind() == reflect.Struct && f.CanSet() {
+								f.Set(reflect.New(f.Type().Elem()))
+							}
 							// Add GraphQL fragment or embedded struct.
 							d.vs = append(d.vs, []reflect.Value{v.Field(i)})
 							frontier = append(frontier, v.Field(i))
@@ -319,12 +324,13 @@ func hasGraphQLName(f reflect.StructField, name string, strict bool) bool {
 		}
 	}
 
-	value = strings.TrimSpace(value) // TODO: Parse better.
+	value = strings.TrimSpace(trimOptions(value))
 	if strings.HasPrefix(value, "...") {
 		// GraphQL fragment. It doesn't have a name.
 		return false
 	}
-	if i := strings.Index(value, "("); i != -1 {
+	if i := strings.IndexAny(value, "(@"); i != -1 {
+		// Arguments or directives, e.g. "details @include(if: $withDetails)".
 		value = value[:i]
 	}
 	if i := strings.Index(value, ":"); i != -1 {
@@ -333,6 +339,21 @@ func hasGraphQLName(f reflect.StructField, name string, strict bool) bool {
 	return strings.TrimSpace(value) == name
 }
 
+// trimOptions returns the graphql tag value without its options, e.g. ",required" or ",depth=3".
+func trimOptions(value string) string {
+	for {
+		i := strings.LastIndex(value, ",")
+		if i < 0 {
+			return value
+		}
+		option := strings.TrimSpace(value[i+1:])
+		if option != "required" && !strings.HasPrefix(option, "depth=") {
+			return value
+		}
+		value = value[:i]
+	}
+}
+
 // isGraphQLFragment reports whether struct field f is a GraphQL fragment.
 func isGraphQLFragment(f reflect.StructField) bool {
 	value, ok := f.Tag.Lookup("graphql")
@@ -62,6 +62,28 @@ func TestUnmarshalGraphQL_graphqlTag(t *testing.T) {
 	}
 }
 
+func TestUnmarshalGraphQL_directiveTag(t *testing.T) {
+	type query struct {
+		Foo graphql.String `graphql:"foo @include(if: $withFoo)"`
+		Bar graphql.String `graphql:"baz: bar(n: 1) @skip(if: $noBar)"`
+	}
+	var got query
+	err := jsonutil.UnmarshalGraphQL([]byte(`{
+		"foo": "foo",
+		"baz": "bar"
+	}`), &got, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := query{
+		Foo: "foo",
+		Bar: "bar",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Error("not equal")
+	}
+}
+
 func TestUnmarshalGraphQL_jsonTag(t *testing.T) {
 	type query struct {
 		Foo graphql.String `json:"baz"`
@@ -196,6 +218,39 @@ func TestUnmarshalGraphQL_pointer(t *testing.T) {
 	}
 }
 
+func TestUnmarshalGraphQL_embeddedPointer(t *testing.T) {
+	type Node struct {
+		ID graphql.ID
+	}
+	type Timestamps struct {
+		Node
+		CreatedAt graphql.String
+	}
+	type query struct {
+		Issue struct {
+			*Timestamps
+			Title graphql.String
+		}
+	}
+	var got query
+	err := jsonutil.UnmarshalGraphQL([]byte(`{
+		"issue": {
+			"id": "1",
+			"createdAt": "2020-01-01T00:00:00Z",
+			"title": "foo"
+		}
+	}`), &got, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want query
+	want.Issue.Timestamps = &Timestamps{Node: Node{ID: "1"}, CreatedAt: "2020-01-01T00:00:00Z"}
+	want.Issue.Title = "foo"
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("not equal:\ngot: %v\nwant: %v", got, want)
+	}
+}
+
 func TestUnmarshalGraphQL_objectPointerArray(t *testing.T) {
 	type query struct {
 		Foo []*struct {
@@ -0,0 +1,171 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenManagerConfig configures a TokenManager.
+type TokenManagerConfig struct {
+	// Refresh obtains a new token. It is required.
+	Refresh func(ctx context.Context) (string, error)
+
+	// RefreshBefore is how long before the token expiry the background refresh happens.
+	// If the token lifetime is shorter than RefreshBefore, the refresh happens halfway through it.
+	//
+	// Defaults to 1 minute.
+	RefreshBefore time.Duration
+
+	// RetryInterval is how long to wait before retrying a failed background refresh.
+	// Retries stop once the current token has expired; the next request then refreshes it synchronously.
+	//
+	// Defaults to 10 seconds.
+	RetryInterval time.Duration
+}
+
+// TokenManager is a TokenSource for JWTs. It decodes the exp claim of each token,
+// and refreshes the token in the background before it expires,
+// so that requests never pay the refresh latency or race the expiry.
+//
+// Tokens that aren't JWTs, or that have no exp claim, are used until Refresh is called.
+// Its Refresh method can be passed to Client.WithAuthRefresh.
+type TokenManager struct {
+	config TokenManagerConfig
+
+	refreshMu sync.Mutex // Serializes refreshes.
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	timer  *time.Timer
+	closed bool
+}
+
+// NewTokenManager creates a TokenManager. The first token is obtained on the first call to Token.
+func NewTokenManager(config TokenManagerConfig) *TokenManager {
+	if config.RefreshBefore == 0 {
+		config.RefreshBefore = time.Minute
+	}
+	if config.RetryInterval == 0 {
+		config.RetryInterval = 10 * time.Second
+	}
+	return &TokenManager{config: config}
+}
+
+// Token implements TokenSource. It returns the current token,
+// refreshing it synchronously only if there is no valid token.
+func (m *TokenManager) Token(ctx context.Context) (string, error) {
+	if token, ok := m.current(); ok {
+		return token, nil
+	}
+	return m.refresh(ctx, false)
+}
+
+// Refresh replaces the current token with a new one, regardless of its expiry.
+func (m *TokenManager) Refresh(ctx context.Context) error {
+	_, err := m.refresh(ctx, true)
+	return err
+}
+
+// Close stops background refreshes.
+func (m *TokenManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+}
+
+// current returns the current token, and whether it is still valid.
+func (m *TokenManager) current() (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.token, m.token != "" && (m.expiry.IsZero() || time.Now().Before(m.expiry))
+}
+
+// refresh obtains a new token. Unless force is true, a valid token obtained
+// by a concurrent refresh is returned instead.
+func (m *TokenManager) refresh(ctx context.Context, force bool) (string, error) {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	if token, ok := m.current(); ok && !force {
+		return token, nil
+	}
+
+	token, err := m.config.Refresh(ctx)
+	if err != nil {
+		return "", fmt.Errorf("refreshing token: %v", err)
+	}
+	expiry, err := JWTExpiry(token)
+	if err != nil {
+		expiry = time.Time{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.token, m.expiry = token, expiry
+	m.schedule(m.expiry.Sub(time.Now()) - m.config.RefreshBefore)
+	return token, nil
+}
+
+// schedule arranges a background refresh after d. m.mu must be held.
+func (m *TokenManager) schedule(d time.Duration) {
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	lifetime := m.expiry.Sub(time.Now())
+	if m.closed || m.expiry.IsZero() || lifetime <= 0 {
+		return
+	}
+	if d <= 0 {
+		d = lifetime / 2
+	}
+	m.timer = time.AfterFunc(d, m.background)
+}
+
+// background refreshes the token, retrying on failure while the current token is valid.
+func (m *TokenManager) background() {
+	_, err := m.refresh(context.Background(), true)
+	if err == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if time.Now().Add(m.config.RetryInterval).Before(m.expiry) {
+		m.schedule(m.config.RetryInterval)
+	}
+}
+
+// JWTExpiry returns the time of the exp claim of a JWT.
+// The token signature is not verified.
+func JWTExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("malformed JWT: expected 3 parts, got %d", len(parts))
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed JWT payload: %v", err)
+	}
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("malformed JWT claims: %v", err)
+	}
+	if claims.Exp == nil {
+		return time.Time{}, fmt.Errorf("JWT has no exp claim")
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed JWT exp claim: %v", err)
+	}
+	sec := int64(exp)
+	return time.Unix(sec, int64((exp-float64(sec))*float64(time.Second))), nil
+}
@@ -0,0 +1,91 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+// newTestJWT returns an unsigned JWT with the given subject and expiry.
+func newTestJWT(sub string, exp time.Time) string {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	payload := enc.EncodeToString([]byte(fmt.Sprintf(`{"sub":%q,"exp":%d}`, sub, exp.Unix())))
+	return header + "." + payload + "."
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp := time.Unix(1700000000, 0)
+	got, err := graphql.JWTExpiry(newTestJWT("gopher", exp))
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+	if !got.Equal(exp) {
+		t.Errorf("got expiry: %v, want: %v", got, exp)
+	}
+	for _, token := range []string{"opaque-token", "a.b.c", "e30.e30.sig"} {
+		if _, err := graphql.JWTExpiry(token); err == nil {
+			t.Errorf("JWTExpiry(%q): got error: nil, want: non-nil", token)
+		}
+	}
+}
+
+func TestTokenManager_backgroundRefresh(t *testing.T) {
+	var mu sync.Mutex
+	refreshes := 0
+	refreshed := make(chan struct{}, 10)
+	m := graphql.NewTokenManager(graphql.TokenManagerConfig{
+		Refresh: func(context.Context) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			refreshes++
+			refreshed <- struct{}{}
+			// The token expires in a bit more than an hour; with RefreshBefore set to an hour,
+			// the background refresh happens within a second.
+			exp := time.Unix(time.Now().Unix(), 0).Add(time.Hour + time.Second)
+			return newTestJWT(fmt.Sprint("token-", refreshes), exp), nil
+		},
+		RefreshBefore: time.Hour,
+	})
+	defer m.Close()
+
+	first, err := m.Token(context.Background())
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+	if again, _ := m.Token(context.Background()); again != first {
+		t.Error("got a different token before the refresh point, want the cached one")
+	}
+	<-refreshed
+
+	select {
+	case <-refreshed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("background refresh didn't happen")
+	}
+	// The refreshed token is stored once the Refresh callback has returned.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		second, err := m.Token(context.Background())
+		if err != nil {
+			t.Fatalf("got error: %v, want: nil", err)
+		}
+		if second != first {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("got the first token after the background refresh, want a new one")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if refreshes < 2 {
+		t.Errorf("got %d refreshes, want: at least 2", refreshes)
+	}
+}
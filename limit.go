@@ -0,0 +1,48 @@
+package graphql
+
+import (
+	"fmt"
+	"io"
+)
+
+// ResponseTooLargeError is returned when a response body exceeds the limit set with WithMaxResponseBytes.
+type ResponseTooLargeError struct {
+	// Limit is the maximum number of bytes allowed.
+	Limit int64
+}
+
+// Error implements error interface.
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds %d bytes", e.Limit)
+}
+
+// WithMaxResponseBytes returns a copy of the client that reads at most n bytes of every response body,
+// after decompression. Reading more fails with a *ResponseTooLargeError, so that a misbehaving server
+// can't make the client allocate unbounded memory. If n is not positive, response bodies are not limited.
+func (c *Client) WithMaxResponseBytes(n int64) *Client {
+	c2 := c.clone()
+	c2.maxResponseBytes = n
+	return c2
+}
+
+// limitedBody is a response body failing with a *ResponseTooLargeError once more than limit bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+	read  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.read > b.limit {
+		return 0, &ResponseTooLargeError{Limit: b.limit}
+	}
+	if int64(len(p)) > b.limit-b.read+1 {
+		p = p[:b.limit-b.read+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n - int(b.read-b.limit), &ResponseTooLargeError{Limit: b.limit}
+	}
+	return n, err
+}
@@ -0,0 +1,48 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_WithMaxResponseBytes(t *testing.T) {
+	const body = `{"data": {"viewer": {"login": "gopher"}}}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, body)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	for _, tc := range []struct {
+		limit   int64
+		wantErr bool
+	}{
+		{limit: 0},
+		{limit: int64(len(body))},
+		{limit: int64(len(body)) - 1, wantErr: true},
+		{limit: 10, wantErr: true},
+	} {
+		var q struct {
+			Viewer struct {
+				Login graphql.String
+			}
+		}
+		err := client.WithMaxResponseBytes(tc.limit).Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+		var tooLarge *graphql.ResponseTooLargeError
+		switch {
+		case !tc.wantErr && err != nil:
+			t.Errorf("limit %d: got error: %v", tc.limit, err)
+		case !tc.wantErr && q.Viewer.Login != "gopher":
+			t.Errorf("limit %d: got login: %q, want: %q", tc.limit, q.Viewer.Login, "gopher")
+		case tc.wantErr && !errors.As(err, &tooLarge):
+			t.Errorf("limit %d: got error: %v, want a *ResponseTooLargeError", tc.limit, err)
+		case tc.wantErr && tooLarge.Limit != tc.limit:
+			t.Errorf("got limit: %d, want: %d", tooLarge.Limit, tc.limit)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GraphQLMarshaler is implemented by types that encode themselves differently in GraphQL requests than as JSON,
+// e.g. a Money type that the application encodes as a JSON object, but that the server takes as a string.
+// MarshalGraphQL returns the value to encode instead, as JSON, in variables and in the arguments of
+// operations built with NewQuery and NewMutation.
+//
+// It applies to the values of variables, their elements if they are lists, the values of maps, and the fields
+// of structs, named as by encoding/json, except in structs with a MarshalJSON method and in embedded structs
+// of unexported types, which are left to encoding/json. The encodings configured on the client, such as
+// WithTimeFormat and WithBytesEncoding, apply to the same values.
+type GraphQLMarshaler interface {
+	MarshalGraphQL() (interface{}, error)
+}
+
+// GraphQLUnmarshaler is implemented by types that decode themselves differently from the data of responses
+// than from JSON. UnmarshalGraphQL is called with the value of the data decoded as into an interface{}: nil, bool,
+// float64 (or json.Number with WithUseNumber), string, []interface{} or map[string]interface{}.
+//
+// Results with such types are decoded generically, with encoding/json, even if a codec is set with WithCodec,
+// and even in strict mode.
+type GraphQLUnmarshaler interface {
+	UnmarshalGraphQL(value interface{}) error
+}
+
+var (
+	graphQLMarshalerType   = reflect.TypeOf((*GraphQLMarshaler)(nil)).Elem()
+	graphQLUnmarshalerType = reflect.TypeOf((*GraphQLUnmarshaler)(nil)).Elem()
+)
+
+// marshalOptions configures the encoding of variables by the client.
+type marshalOptions struct {
+	// times encodes time.Time values; nil if they are encoded by encoding/json.
+	times *TimeFormat
+	// bigNumbersAsStrings encodes big.Int and big.Float values as strings.
+	bigNumbersAsStrings bool
+	// bytes encodes Bytes values; nil if they are encoded by their MarshalJSON method.
+	bytes *base64.Encoding
+	// numericIDs encodes FlexID values that are integers as numbers.
+	numericIDs bool
+	// gqlgen encodes values with a MarshalGQL method with it.
+	gqlgen bool
+}
+
+// marshalVariables returns variables with the GraphQLMarshaler values, and the values encoded as configured
+// by opts, replaced by the values they encode as, and the unset Optionals left out, as they are from
+// the variable definitions of documents. It returns variables as is if there are none.
+func marshalVariables(variables map[string]interface{}, opts marshalOptions) (map[string]interface{}, error) {
+	var marshaled map[string]interface{}
+	copyVariables := func() {
+		if marshaled == nil {
+			marshaled = make(map[string]interface{}, len(variables))
+			for name, value := range variables {
+				marshaled[name] = value
+			}
+		}
+	}
+	for name, value := range variables {
+		if o, ok := value.(Optional); ok && !o.IsSet() {
+			copyVariables()
+			delete(marshaled, name)
+			continue
+		}
+		replaced, ok, err := marshalGraphQL(reflect.ValueOf(value), opts)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		copyVariables()
+		marshaled[name] = replaced
+	}
+	if marshaled == nil {
+		return variables, nil
+	}
+	return marshaled, nil
+}
+
+// marshalGraphQL returns the value v encodes as, and whether it differs from v because v,
+// or one of its elements, is a GraphQLMarshaler, or encoded as configured by opts, see GraphQLMarshaler.
+func marshalGraphQL(v reflect.Value, opts marshalOptions) (interface{}, bool, error) {
+	if !v.IsValid() {
+		return nil, false, nil
+	}
+	if opts.times != nil && v.Type() == timeType {
+		return opts.times.format(v.Interface().(time.Time)), true, nil
+	}
+	if opts.bytes != nil && v.Type() == bytesType && !v.IsNil() {
+		return opts.bytes.EncodeToString(v.Bytes()), true, nil
+	}
+	if opts.numericIDs && v.Type() == flexIDType && isInteger(v.String()) {
+		return json.Number(v.String()), true, nil
+	}
+	if opts.bigNumbersAsStrings {
+		if s, ok := formatBigNumber(v); ok {
+			return s, true, nil
+		}
+	}
+	if v.Type() == bytesUUID {
+		// Encoded as a string, rather than a list of bytes.
+		return uuid.UUID(v.Interface().([16]byte)).String(), true, nil
+	}
+	if v.Type().Implements(graphQLMarshalerType) && !(v.Kind() == reflect.Ptr && v.IsNil()) {
+		value, err := v.Interface().(GraphQLMarshaler).MarshalGraphQL()
+		return value, err == nil, err
+	}
+	if opts.gqlgen && v.Type().Implements(gqlgenMarshalerType) && !(v.Kind() == reflect.Ptr && v.IsNil()) {
+		return marshalGQL(v.Interface().(gqlgenMarshaler)), true, nil
+	}
+	if o, ok := v.Interface().(Optional); ok {
+		return marshalGraphQL(reflect.ValueOf(o.value), opts)
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return marshalGraphQL(v.Elem(), opts)
+		}
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, v.Len())
+		changed := false
+		for i := range list {
+			value, ok, err := marshalGraphQL(v.Index(i), opts)
+			if err != nil {
+				return nil, false, err
+			}
+			list[i], changed = value, changed || ok
+		}
+		if changed {
+			return list, true, nil
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		object := make(map[string]interface{}, v.Len())
+		changed := false
+		for iter := v.MapRange(); iter.Next(); {
+			value, ok, err := marshalGraphQL(iter.Value(), opts)
+			if err != nil {
+				return nil, false, err
+			}
+			object[iter.Key().String()], changed = value, changed || ok
+		}
+		if changed {
+			return object, true, nil
+		}
+	case reflect.Struct:
+		if v.Type().Implements(jsonMarshalerType) || reflect.PtrTo(v.Type()).Implements(jsonMarshalerType) {
+			break
+		}
+		object := make(map[string]interface{}, v.NumField())
+		changed, ok, err := marshalFields(object, v, opts)
+		if err != nil {
+			return nil, false, err
+		}
+		if changed && ok {
+			return object, true, nil
+		}
+	}
+	return v.Interface(), false, nil
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// marshalFields adds the fields of the struct v to object, named and left out as by encoding/json,
+// with their values replaced as by marshalGraphQL, and the unset Optionals left out.
+// It reports whether some of them differ from the encoding of v by encoding/json, and whether
+// all of them could be added: the fields of embedded structs of unexported types can't.
+func marshalFields(object map[string]interface{}, v reflect.Value, opts marshalOptions) (changed, ok bool, err error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, options := f.Tag.Get("json"), ""
+		if i := strings.Index(name, ","); i >= 0 {
+			name, options = name[:i], name[i:]+","
+		}
+		if name == "-" && options == "" {
+			continue
+		}
+		field := v.Field(i)
+		if f.Anonymous && name == "" {
+			embedded := field
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if f.PkgPath != "" {
+					return false, false, nil
+				}
+				embeddedChanged, ok, err := marshalFields(object, embedded, opts)
+				if err != nil || !ok {
+					return false, ok, err
+				}
+				changed = changed || embeddedChanged
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if o, ok := field.Interface().(Optional); ok && !o.IsSet() {
+			changed = true
+			continue
+		}
+		if strings.Contains(options, ",omitempty,") && isEmptyValue(field) {
+			continue
+		}
+		if strings.Contains(options, ",string,") {
+			switch field.Kind() {
+			case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+				reflect.Float32, reflect.Float64, reflect.String:
+				// Quoted as by encoding/json.
+				b, err := json.Marshal(field.Interface())
+				if err != nil {
+					return false, false, err
+				}
+				object[name] = string(b)
+				continue
+			}
+		}
+		value, fieldChanged, err := marshalGraphQL(field, opts)
+		if err != nil {
+			return false, false, err
+		}
+		object[name], changed = value, changed || fieldChanged
+	}
+	return changed, true, nil
+}
+
+// unmarshalerTypes maps types to whether they have GraphQLUnmarshaler values, as reported by hasUnmarshalers.
+var unmarshalerTypes sync.Map // map[reflect.Type]bool
+
+// hasUnmarshalers reports whether t, or a type nested in it, implements GraphQLUnmarshaler,
+// is a UUID as [16]byte, which encoding/json decodes as a list, or is a big.Int or big.Float,
+// which encoding/json doesn't decode from strings and numbers alike.
+func hasUnmarshalers(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if found, ok := unmarshalerTypes.Load(t); ok {
+		return found.(bool)
+	}
+	found := findUnmarshalers(t, map[reflect.Type]bool{})
+	unmarshalerTypes.Store(t, found)
+	return found
+}
+
+// findUnmarshalers reports whether t has GraphQLUnmarshaler values, skipping the types in seen.
+func findUnmarshalers(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	if reflect.PtrTo(t).Implements(graphQLUnmarshalerType) || t == bytesUUID || t == bigIntType || t == bigFloatType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return findUnmarshalers(t.Elem(), seen)
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+			return false
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if findUnmarshalers(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+// money is encoded by the application as a JSON object, and by the server as a string, e.g. "12.34".
+type money struct {
+	Cents int64 `json:"cents"`
+}
+
+func (m money) MarshalGraphQL() (interface{}, error) {
+	return fmt.Sprintf("%d.%02d", m.Cents/100, m.Cents%100), nil
+}
+
+func (m *money) UnmarshalGraphQL(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("got %T, want a string", value)
+	}
+	var units, cents int64
+	if _, err := fmt.Sscanf(s, "%d.%d", &units, &cents); err != nil {
+		return err
+	}
+	m.Cents = units*100 + cents
+	return nil
+}
+
+func TestGraphQLMarshaler(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"order": {"total": "12.34", "discount": null, "items": ["1.00", "11.34"]}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Order struct {
+			Total    money
+			Discount *money
+			Items    []money
+		} `graphql:"order(minTotal: $minTotal, prices: $prices)"`
+	}
+	variables := map[string]interface{}{
+		"minTotal": &money{Cents: 1000},
+		"prices":   []money{{Cents: 5}},
+		"id":       graphql.ID("1"),
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "query{order{total,discount,items}}", Result: &q}, variables); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"query":"query{order{total,discount,items}}","variables":{"id":"1","minTotal":"10.00","prices":["0.05"]}}` + "\n"; gotBody != want {
+		t.Errorf("got body: %v, want: %v", gotBody, want)
+	}
+	if q.Order.Total.Cents != 1234 || q.Order.Discount != nil || len(q.Order.Items) != 2 || q.Order.Items[1].Cents != 1134 {
+		t.Errorf("got order: %+v", q.Order)
+	}
+
+	query := graphql.NewQuery(graphql.Field("order").Arg("minTotal", money{Cents: 1}).Select(graphql.Field("total"))).Document(nil)
+	if want := `{order(minTotal:"0.01"){total}}`; query != want {
+		t.Errorf("got query: %v, want: %v", query, want)
+	}
+}
+
+func TestGraphQLMarshaler_structFields(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"createOrder": {"id": "1"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithTimeFormat(graphql.TimeUnixMillis).
+		WithBytesEncoding(base64.RawURLEncoding).
+		WithNumericIDs()
+
+	type Audit struct {
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	type OrderInput struct {
+		Audit
+		CustomerID graphql.FlexID   `json:"customerId"`
+		Total      money            `json:"total"`
+		Signature  graphql.Bytes    `json:"signature"`
+		Note       graphql.Optional `json:"note"`
+		Coupon     string           `json:"coupon,omitempty"`
+		Items      []money          `json:"items"`
+		Session    string           `json:"-"`
+	}
+	type prioritizedInput struct {
+		*OrderInput
+		Priority int `json:"priority,string"`
+	}
+	var m struct {
+		CreateOrder struct {
+			ID graphql.ID
+		} `graphql:"createOrder(input: $input)"`
+	}
+	input := OrderInput{
+		CustomerID: "42",
+		Total:      money{Cents: 1234},
+		Signature:  graphql.Bytes{0xfb, 0xff},
+		Items:      []money{{Cents: 5}},
+		Session:    "secret",
+	}
+	input.CreatedAt = time.Unix(1136214245, 0)
+	variables := map[string]interface{}{"input": input, "inputs": []prioritizedInput{{OrderInput: &input, Priority: 2}}}
+	if err := client.Mutate(context.Background(), graphql.ManualRequest{Query: "mutation($input:OrderInput!){createOrder(input:$input){id}}", Result: &m}, variables); err != nil {
+		t.Fatal(err)
+	}
+	// Structs with encoded fields are sent as objects, with their keys sorted.
+	wantInput := `"createdAt":1136214245000,"customerId":42,"items":["0.05"],%s"signature":"-_8","total":"12.34"`
+	want := `{"query":"mutation($input:OrderInput!){createOrder(input:$input){id}}","variables":{"input":{` + fmt.Sprintf(wantInput, "") +
+		`},"inputs":[{` + fmt.Sprintf(wantInput, `"priority":"2",`) + `}]}}` + "\n"
+	if gotBody != want {
+		t.Errorf("got body: %v, want: %v", gotBody, want)
+	}
+}
@@ -0,0 +1,193 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MergedQuery is one of the queries executed together by QueryMerged.
+type MergedQuery struct {
+	// Query is a pointer to struct that corresponds to the GraphQL schema, populated with the response.
+	Query interface{}
+
+	// Variables are the variables of Query.
+	Variables map[string]interface{}
+}
+
+// QueryMerged executes several queries in a single GraphQL request, populating the response into each of them.
+// The root fields of the i-th query are aliased with the prefix "q<i>_", and its variables are renamed the same way,
+// so that the queries don't conflict.
+//
+// The data of every query is decoded as that of a response to the query alone, with the decoding options of the client,
+// such as WithTimeFormat, and populated even if the response has errors; the errors are then returned.
+func (c *Client) QueryMerged(ctx context.Context, queries []MergedQuery, options ...RequestOption) error {
+	query, variables, err := mergeQueries(queries, c.variableTypes, c.unionTypes, c.gqlgenModels)
+	if err != nil {
+		return err
+	}
+	var data map[string]json.RawMessage
+	err = c.Do(ctx, queryOperation, ManualRequest{Query: query, Result: &data}, variables, "", options...)
+	if data == nil {
+		return err
+	}
+	opts := newRequestOptions(options)
+	strict := c.Strict
+	if opts.strictSet {
+		strict = opts.strict
+	}
+	for i, q := range queries {
+		prefix := mergePrefix(i)
+		fields := make(map[string]json.RawMessage)
+		for key, value := range data {
+			if strings.HasPrefix(key, prefix) {
+				fields[strings.TrimPrefix(key, prefix)] = value
+			}
+		}
+		object, jsonErr := json.Marshal(fields)
+		if jsonErr != nil {
+			return jsonErr
+		}
+		// Decode as the data of a response to q alone, with the decoding options of the client.
+		d := responseData{client: c, target: q.Query, strict: strict}
+		if decodeErr := d.UnmarshalJSON(object); decodeErr != nil {
+			e := decodeError(decodeErr, object)
+			e.Offset = -1 // The offset is relative to the data of q, not to the body.
+			return e
+		}
+		if d.missing != nil && err == nil {
+			// With errors, required fields may be null because they failed, as the errors tell.
+			return decodeError(d.missing, object)
+		}
+	}
+	return err
+}
+
+// QueryAliased executes the same query once per set of variables, in a single GraphQL request,
+// as with QueryMerged. results must be a pointer to a slice of the query struct type;
+// it is set to one populated query per set of variables, in the same order.
+//
+// It fetches many objects in one round trip from servers without fields taking lists of arguments,
+// e.g. a user per ID.
+func (c *Client) QueryAliased(ctx context.Context, results interface{}, variables []map[string]interface{}, options ...RequestOption) error {
+	v := reflect.ValueOf(results)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice || v.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("got %T, want a pointer to a slice of struct", results)
+	}
+	elemType := v.Elem().Type().Elem()
+	queries := make([]MergedQuery, len(variables))
+	for i := range variables {
+		queries[i] = MergedQuery{Query: reflect.New(elemType).Interface(), Variables: variables[i]}
+	}
+	err := c.QueryMerged(ctx, queries, options...)
+	out := reflect.MakeSlice(v.Elem().Type(), len(queries), len(queries))
+	for i, q := range queries {
+		out.Index(i).Set(reflect.ValueOf(q.Query).Elem())
+	}
+	v.Elem().Set(out)
+	return err
+}
+
+// mergePrefix returns the prefix of the aliases and variables of the i-th merged query.
+func mergePrefix(i int) string {
+	return "q" + strconv.Itoa(i) + "_"
+}
+
+// variableReference matches the references to variables in a selection, e.g. "$owner".
+var variableReference = regexp.MustCompile(`\$[_A-Za-z][_0-9A-Za-z]*`)
+
+// mergeQueries constructs a single query document and its variables from several queries,
+// declaring variables with the types mapped by types, if any, and selecting the fragments of unions, if any.
+// gqlgen is as for query.
+func mergeQueries(queries []MergedQuery, types map[reflect.Type]string, unions *unionTypes, gqlgen bool) (string, map[string]interface{}, error) {
+	variables := make(map[string]interface{})
+	var selections bytes.Buffer
+	for i, q := range queries {
+		t := reflect.TypeOf(q.Query)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			return "", nil, fmt.Errorf("merged query %d: got %T, want a pointer to struct", i, q.Query)
+		}
+		prefix := mergePrefix(i)
+		var fields bytes.Buffer
+		qw := &queryWriter{unions: unions, gqlgen: gqlgen}
+		writeMergedFields(&fields, t.Elem(), prefix, qw)
+		if qw.err != nil {
+			return "", nil, qw.err
+		}
+		// Rename the references in a single pass, rather than per variable in map iteration order,
+		// so that names that are prefixed names of other variables, e.g. "id" and "q0_id", aren't renamed twice.
+		selection := variableReference.ReplaceAllStringFunc(fields.String(), func(reference string) string {
+			if _, ok := q.Variables[reference[1:]]; !ok {
+				return reference
+			}
+			return "$" + prefix + reference[1:]
+		})
+		for name, value := range q.Variables {
+			variables[prefix+name] = value
+		}
+		if selections.Len() > 0 && selection != "" {
+			selections.WriteString(",")
+		}
+		selections.WriteString(selection)
+	}
+	if definitions := variableDefinitions(variables, types); definitions != "" {
+		return "query(" + definitions + "){" + selections.String() + "}", variables, nil
+	}
+	return "{" + selections.String() + "}", nil, nil
+}
+
+// writeMergedFields writes the root fields of t to w, aliased with prefix.
+// Embedded structs without a graphql tag have their fields inlined, as by queryWriter.write.
+// Errors are recorded in qw.
+func writeMergedFields(w *bytes.Buffer, t reflect.Type, prefix string, qw *queryWriter) {
+	qw.stack = append(qw.stack, t)
+	defer func() { qw.stack = qw.stack[:len(qw.stack)-1] }()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		value, ok := f.Tag.Lookup("graphql")
+		if ok && value == excludeTag || !ok && qw.gqlgen && qw.excludeGQLGen(f) || !qw.expand(t, f) {
+			continue
+		}
+		if f.Anonymous && !ok && !qw.hasJSONName(f) {
+			writeMergedFields(w, indirect(f.Type), prefix, qw)
+			continue
+		}
+		if ok {
+			value = tagSelection(value)
+		} else {
+			value = qw.fieldName(f)
+		}
+		if w.Len() > 0 {
+			w.WriteString(",")
+		}
+		key, field := splitAlias(value)
+		w.WriteString(prefix + key + ":" + field)
+		qw.write(w, f.Type, false)
+	}
+}
+
+// splitAlias splits a field selection, such as "alias:field(arg:1)", into its response key and the rest.
+func splitAlias(selection string) (key, field string) {
+	field = strings.TrimSpace(selection)
+	head := field
+	if i := strings.IndexAny(head, "(@{"); i >= 0 {
+		head = head[:i]
+	}
+	if i := strings.Index(head, ":"); i >= 0 {
+		return strings.TrimSpace(head[:i]), strings.TrimSpace(field[i+1:])
+	}
+	return strings.TrimSpace(head), field
+}
+
+// indirect returns the type pointed to by t, if it is a pointer.
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,162 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_QueryMerged(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Query     string
+			Variables map[string]interface{}
+		}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := in.Query, `query($q1_name:String!$q1_owner:String!){q0_viewer:viewer{login},q1_repo:repository(owner:$q1_owner,name:$q1_name){stargazerCount}}`; got != want {
+			t.Errorf("got query:\n%v\nwant:\n%v", got, want)
+		}
+		if got, want := len(in.Variables), 2; got != want {
+			t.Errorf("got %v variables, want: %v", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"q0_viewer": {"login": "gopher"}, "q1_repo": {"stargazerCount": 42}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var viewer struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	var repo struct {
+		Repo struct {
+			StargazerCount graphql.Int
+		} `graphql:"repo:repository(owner:$owner,name:$name)"`
+	}
+	err := client.QueryMerged(context.Background(), []graphql.MergedQuery{
+		{Query: &viewer},
+		{Query: &repo, Variables: map[string]interface{}{"owner": graphql.String("golang"), "name": graphql.String("go")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if viewer.Viewer.Login != "gopher" {
+		t.Errorf("got login: %q, want: %q", viewer.Viewer.Login, "gopher")
+	}
+	if repo.Repo.StargazerCount != 42 {
+		t.Errorf("got stargazer count: %v, want: 42", repo.Repo.StargazerCount)
+	}
+}
+
+func TestClient_QueryAliased(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Query     string
+			Variables map[string]interface{}
+		}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := in.Query, `query($q0_id:ID!$q1_id:ID!){q0_user:user(id:$q0_id){login},q1_user:user(id:$q1_id){login}}`; got != want {
+			t.Errorf("got query:\n%v\nwant:\n%v", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"q0_user": {"login": "alice"}, "q1_user": null}, "errors": [{"message": "user not found", "path": ["q1_user"]}]}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var users []struct {
+		User *struct {
+			Login graphql.String
+		} `graphql:"user(id:$id)"`
+	}
+	err := client.QueryAliased(context.Background(), &users, []map[string]interface{}{
+		{"id": graphql.ID("1")},
+		{"id": graphql.ID("2")},
+	})
+	if err == nil {
+		t.Error("got error: nil, want: user not found")
+	}
+	if len(users) != 2 {
+		t.Fatalf("got %v users, want: 2", len(users))
+	}
+	if users[0].User == nil || users[0].User.Login != "alice" {
+		t.Errorf("got first user: %+v, want alice", users[0].User)
+	}
+	if users[1].User != nil {
+		t.Errorf("got second user: %+v, want: nil", users[1].User)
+	}
+}
+
+func TestClient_QueryMerged_deterministic(t *testing.T) {
+	var bodies []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		bodies = append(bodies, mustRead(req.Body))
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		User struct {
+			Login graphql.String
+		} `graphql:"user(id:$id)"`
+		Other struct {
+			Login graphql.String
+		} `graphql:"other:user(id:$q0_id)"`
+	}
+	for i := 0; i < 20; i++ {
+		err := client.QueryMerged(context.Background(), []graphql.MergedQuery{
+			{Query: &q, Variables: map[string]interface{}{"id": graphql.ID("1"), "q0_id": graphql.ID("2"), "owner": graphql.String("golang")}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := `{"query":"query($q0_id:ID!$q0_owner:String!$q0_q0_id:ID!){q0_user:user(id:$q0_id){login},q0_other:user(id:$q0_q0_id){login}}","variables":{"q0_id":"1","q0_owner":"golang","q0_q0_id":"2"}}` + "\n"
+	for _, body := range bodies {
+		if body != want {
+			t.Fatalf("got body: %v, want: %v", body, want)
+		}
+	}
+}
+
+func TestClient_QueryMerged_clientOptions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"q0_viewer": {"createdAt": 1136214245000}, "q1_repo": {"name": null}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithTimeFormat(graphql.TimeUnixMillis)
+
+	var viewer struct {
+		Viewer struct {
+			CreatedAt time.Time
+		}
+	}
+	var repo struct {
+		Repo struct {
+			Name *string `graphql:"name,required"`
+		} `graphql:"repo:repository(owner:\"golang\",name:\"go\")"`
+	}
+	err := client.QueryMerged(context.Background(), []graphql.MergedQuery{{Query: &viewer}, {Query: &repo}})
+	if want := time.Unix(1136214245, 0); !viewer.Viewer.CreatedAt.Equal(want) {
+		t.Errorf("got createdAt: %v, want: %v", viewer.Viewer.CreatedAt, want)
+	}
+	var requiredErr *graphql.RequiredFieldError
+	if !errors.As(err, &requiredErr) || requiredErr.Error() != "required field repo.name is null or missing" {
+		t.Errorf("got error: %v, want a *RequiredFieldError for repo.name", err)
+	}
+}
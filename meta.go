@@ -0,0 +1,77 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ResponseMeta describes the response to an operation executed with QueryWithResponse, MutateWithResponse
+// or DoWithResponse, for callers who need more than its data.
+type ResponseMeta struct {
+	// StatusCode is the status code of the HTTP response, e.g. 200. It is 0 if no response was received.
+	StatusCode int
+
+	// Header is the header of the HTTP response.
+	Header http.Header
+
+	// Duration is the time taken by the operation, from building its request to decoding its response.
+	Duration time.Duration
+
+	// RequestSize is the size of the body of the HTTP request, as sent after compression,
+	// or -1 if unknown, e.g. for streamed file uploads. It is 0 for GET requests and responses read from a cache.
+	RequestSize int64
+
+	// ResponseSize is the number of bytes of the body of the response that were decoded, after decompression.
+	ResponseSize int64
+
+	// Extensions are the extensions of the response, if any.
+	Extensions map[string]interface{}
+}
+
+// QueryWithResponse executes a single GraphQL query request, as Query, and returns the metadata of its response.
+// The metadata is returned even if the query failed, with whatever was known about the response.
+func (c *Client) QueryWithResponse(ctx context.Context, request ManualRequest, variables map[string]interface{}, options ...RequestOption) (*ResponseMeta, error) {
+	return c.DoWithResponse(ctx, queryOperation, request, variables, "", options...)
+}
+
+// MutateWithResponse executes a single GraphQL mutation request, as Mutate, and returns the metadata of its response,
+// as QueryWithResponse does.
+func (c *Client) MutateWithResponse(ctx context.Context, request ManualRequest, variables map[string]interface{}, options ...RequestOption) (*ResponseMeta, error) {
+	return c.DoWithResponse(ctx, mutationOperation, request, variables, "", options...)
+}
+
+// DoWithResponse executes a single GraphQL operation, as Do, and returns the metadata of its response.
+// The metadata is returned even if the operation failed, with whatever was known about the response.
+func (c *Client) DoWithResponse(ctx context.Context, op operationType, v interface{}, variables map[string]interface{}, name string, options ...RequestOption) (*ResponseMeta, error) {
+	meta := &ResponseMeta{}
+	start := time.Now()
+	options = append(options[:len(options):len(options)], withResponseMeta(meta))
+	result, err := c.doPartial(ctx, op, v, variables, name, options)
+	meta.Duration = time.Since(start)
+	if err != nil {
+		return meta, err
+	}
+	return meta, c.operationError(resultError(result, newRequestOptions(options)), op, v, variables, name)
+}
+
+// withResponseMeta records the metadata of the response into meta.
+func withResponseMeta(meta *ResponseMeta) RequestOption {
+	return func(opts *requestOptions) {
+		opts.meta = meta
+	}
+}
+
+// countingReader counts the bytes read from r into n.
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+// Read implements io.Reader.
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	*c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,70 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_QueryWithResponse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "42")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}, "extensions": {"cost": 3}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	meta, err := client.QueryWithResponse(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Viewer.Login != "gopher" {
+		t.Errorf("got login: %q, want: %q", q.Viewer.Login, "gopher")
+	}
+	if meta.StatusCode != http.StatusOK || meta.Header.Get("X-Request-Id") != "42" {
+		t.Errorf("got status code %d and header %v", meta.StatusCode, meta.Header)
+	}
+	if got, want := meta.RequestSize, int64(len(`{"query":"{viewer{login}}"}`)+1); got != want {
+		t.Errorf("got request size: %d, want: %d", got, want)
+	}
+	if got, want := meta.ResponseSize, int64(len(`{"data": {"viewer": {"login": "gopher"}}, "extensions": {"cost": 3}}`)); got != want {
+		t.Errorf("got response size: %d, want: %d", got, want)
+	}
+	if meta.Duration <= 0 {
+		t.Errorf("got duration: %v, want > 0", meta.Duration)
+	}
+	if got, want := meta.Extensions["cost"], float64(3); got != want {
+		t.Errorf("got cost extension: %v, want: %v", got, want)
+	}
+}
+
+func TestClient_QueryWithResponse_error(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	meta, err := client.QueryWithResponse(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+	if !errors.Is(err, graphql.ErrHTTPStatus) {
+		t.Errorf("got error: %v, want ErrHTTPStatus", err)
+	}
+	if meta.StatusCode != http.StatusServiceUnavailable || meta.Header.Get("Retry-After") != "1" {
+		t.Errorf("got status code %d and header %v", meta.StatusCode, meta.Header)
+	}
+}
@@ -0,0 +1,53 @@
+package graphql
+
+import (
+	"net/http"
+)
+
+// Middleware wraps the http.RoundTripper that sends GraphQL requests,
+// e.g. to sign, log or otherwise modify requests and responses.
+//
+// Implementations must not modify the request they receive; clone it first.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware returns a copy of the client that sends requests through the given middlewares,
+// in addition to the ones already configured. The first middleware is the outermost one.
+func (c *Client) WithMiddleware(middlewares ...Middleware) *Client {
+	c2 := c.clone()
+	c2.middlewares = append(append([]Middleware(nil), c.middlewares...), middlewares...)
+	return c2
+}
+
+// doHTTP sends req with the client's HTTP client, through the configured middlewares.
+// If the context of req is done, its error is returned rather than the one of the HTTP client.
+func (c *Client) doHTTP(req *http.Request) (*http.Response, error) {
+	httpClient := c.httpClient
+	if len(c.middlewares) > 0 {
+		transport := httpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		for i := len(c.middlewares) - 1; i >= 0; i-- {
+			transport = c.middlewares[i](transport)
+		}
+		hc := *httpClient
+		hc.Transport = transport
+		httpClient = &hc
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,128 @@
+// Package negotiate provides SPNEGO (HTTP Negotiate) authentication for GraphQL clients,
+// as required by services deployed inside Windows Active Directory or Kerberos environments.
+//
+// This package doesn't implement Kerberos itself, so that the graphql package
+// doesn't depend on a Kerberos library. A TokenProvider produces the SPNEGO tokens;
+// for example, using github.com/jcmturner/gokrb5/v8:
+//
+//	type kerberosProvider struct {
+//		client *client.Client // gokrb5 client, logged in with a keytab or password.
+//	}
+//
+//	func (p kerberosProvider) Token(ctx context.Context, spn string) ([]byte, error) {
+//		s := spnego.SPNEGOClient(p.client, spn)
+//		if err := s.AcquireCred(); err != nil {
+//			return nil, err
+//		}
+//		token, err := s.InitSecContext()
+//		if err != nil {
+//			return nil, err
+//		}
+//		return token.Marshal()
+//	}
+//
+// The provider is then plugged into a client as a middleware:
+//
+//	client := graphql.NewClient(url, nil).WithMiddleware(negotiate.Middleware(kerberosProvider{kc}, negotiate.Config{}))
+package negotiate
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	graphql "github.com/darrensapalo/go-graphql-client"
+)
+
+// TokenProvider produces SPNEGO tokens for a service principal name, e.g. "HTTP/graphql.corp.example.com".
+type TokenProvider interface {
+	Token(ctx context.Context, spn string) ([]byte, error)
+}
+
+// Config configures the Negotiate middleware.
+type Config struct {
+	// SPN is the service principal name of the GraphQL server.
+	//
+	// Defaults to "HTTP/" followed by the host name of the request URL.
+	SPN string
+
+	// Preemptive sends a token with every request. Otherwise, a token is only sent after
+	// the server answers 401 Unauthorized with a "WWW-Authenticate: Negotiate" challenge,
+	// which costs an extra round trip but avoids generating tokens for servers that don't need them.
+	Preemptive bool
+}
+
+// Middleware returns a middleware that authenticates requests with the Negotiate scheme.
+func Middleware(provider TokenProvider, config Config) graphql.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return graphql.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if config.Preemptive {
+				authReq, err := authenticate(req, provider, config)
+				if err != nil {
+					return nil, err
+				}
+				return next.RoundTrip(authReq)
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || !isNegotiateChallenge(resp.Header) {
+				return resp, err
+			}
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			if req.GetBody == nil && req.Body != nil && req.Body != http.NoBody {
+				// Uploads of files that don't implement io.Seeker can't be sent again.
+				return nil, fmt.Errorf("negotiate: request body can't be replayed after a challenge")
+			}
+
+			authReq, err := authenticate(req, provider, config)
+			if err != nil {
+				return nil, err
+			}
+			return next.RoundTrip(authReq)
+		})
+	}
+}
+
+// authenticate returns a copy of req, with a fresh body and the Negotiate Authorization header set.
+func authenticate(req *http.Request, provider TokenProvider, config Config) (*http.Request, error) {
+	spn := config.SPN
+	if spn == "" {
+		host := req.URL.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		spn = "HTTP/" + host
+	}
+	token, err := provider.Token(req.Context(), spn)
+	if err != nil {
+		return nil, fmt.Errorf("negotiate: obtaining token for %s: %v", spn, err)
+	}
+
+	authReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		authReq.Body = body
+	}
+	authReq.Header.Set("Authorization", "Negotiate "+base64.StdEncoding.EncodeToString(token))
+	return authReq, nil
+}
+
+// isNegotiateChallenge reports whether the response headers ask for Negotiate authentication.
+func isNegotiateChallenge(h http.Header) bool {
+	for _, challenge := range h.Values("WWW-Authenticate") {
+		scheme := strings.Fields(challenge)
+		if len(scheme) > 0 && strings.EqualFold(strings.TrimSuffix(scheme[0], ","), "Negotiate") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package negotiate_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	graphql "github.com/darrensapalo/go-graphql-client"
+	"github.com/darrensapalo/go-graphql-client/negotiate"
+)
+
+type fakeProvider struct{}
+
+func (fakeProvider) Token(ctx context.Context, spn string) ([]byte, error) {
+	return []byte("token-for-" + spn), nil
+}
+
+func TestMiddleware(t *testing.T) {
+	want := "Negotiate " + base64.StdEncoding.EncodeToString([]byte("token-for-HTTP/graphql.example"))
+	for _, preemptive := range []bool{false, true} {
+		requests := 0
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requests++
+			if body, _ := ioutil.ReadAll(req.Body); len(body) == 0 {
+				t.Error("got empty request body")
+			}
+			if req.Header.Get("Authorization") != want {
+				w.Header().Set("WWW-Authenticate", "Negotiate")
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+		})
+		transport := graphql.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w.Result(), nil
+		})
+		client := graphql.NewClient("http://graphql.example:8080/graphql", &http.Client{Transport: transport}).
+			WithMiddleware(negotiate.Middleware(fakeProvider{}, negotiate.Config{Preemptive: preemptive}))
+
+		var q struct {
+			Viewer struct {
+				Login string
+			}
+		}
+		err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+		if err != nil {
+			t.Fatalf("preemptive=%v: got error: %v, want: nil", preemptive, err)
+		}
+		if q.Viewer.Login != "gopher" {
+			t.Errorf("preemptive=%v: got login %q, want %q", preemptive, q.Viewer.Login, "gopher")
+		}
+		wantRequests := 2
+		if preemptive {
+			wantRequests = 1
+		}
+		if requests != wantRequests {
+			t.Errorf("preemptive=%v: got %d requests, want %d", preemptive, requests, wantRequests)
+		}
+	}
+}
+
+func TestMiddleware_upload(t *testing.T) {
+	want := "Negotiate " + base64.StdEncoding.EncodeToString([]byte("token-for-HTTP/graphql.example"))
+	for _, tc := range []struct {
+		name      string
+		file      io.Reader
+		challenge bool
+		wantErr   bool
+	}{
+		{name: "seekable", file: strings.NewReader("hello"), challenge: true},
+		{name: "not seekable without challenge", file: struct{ io.Reader }{strings.NewReader("hello")}},
+		{name: "not seekable with challenge", file: struct{ io.Reader }{strings.NewReader("hello")}, challenge: true, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requests++
+				file, _, err := req.FormFile("0")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if content, _ := ioutil.ReadAll(file); string(content) != "hello" {
+					t.Errorf("got file content %q, want %q", content, "hello")
+				}
+				if tc.challenge && req.Header.Get("Authorization") != want {
+					w.Header().Set("WWW-Authenticate", "Negotiate")
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"data": {"upload": {"id": "1"}}}`)
+			})
+			transport := graphql.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				return w.Result(), nil
+			})
+			client := graphql.NewClient("http://graphql.example/graphql", &http.Client{Transport: transport}).
+				WithMiddleware(negotiate.Middleware(fakeProvider{}, negotiate.Config{}))
+
+			var m struct {
+				Upload struct {
+					ID string
+				} `graphql:"upload(file: $file)"`
+			}
+			variables := map[string]interface{}{"file": graphql.Upload{File: tc.file, Filename: "hello.txt"}}
+			err := client.Mutate(context.Background(), graphql.ManualRequest{Query: "mutation($file:Upload!){upload(file:$file){id}}", Result: &m}, variables)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("got error: nil, want the body to be reported as not replayable")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error: %v, want: nil", err)
+			}
+			if m.Upload.ID != "1" {
+				t.Errorf("got id %q, want %q", m.Upload.ID, "1")
+			}
+			wantRequests := 1
+			if tc.challenge {
+				wantRequests = 2
+			}
+			if requests != wantRequests {
+				t.Errorf("got %d requests, want %d", requests, wantRequests)
+			}
+		})
+	}
+}
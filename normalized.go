@@ -0,0 +1,576 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// NormalizedCache is an Apollo-style normalized cache. It stores the objects of responses as entities,
+// identified by their __typename and id fields, and assembles the results of queries from these entities.
+// Queries selecting the same entities share their data, and the entities returned by mutations
+// replace the cached ones, so that later queries see the changes without reaching the server.
+//
+// Only objects whose __typename and id are selected are normalized. Other objects are stored
+// with the query that returned them. The fields of entities are stored by name and arguments,
+// like the storeFieldName of Apollo Client, so that aliases share data and fields selected
+// with different arguments don't overwrite each other.
+type NormalizedCache struct {
+	backend Cache
+
+	// mu serializes the updates of entities, which read and then write them.
+	mu sync.Mutex
+	// generation is part of the backend keys, and changed by Clear to forget all entries.
+	generation int
+	// scopes are the scopes of the entities written since the last Clear, see Client.requestScope.
+	scopes map[string]bool
+}
+
+// normalizedResult is the normalized data of a query response, as stored in the backend.
+type normalizedResult struct {
+	Data      interface{} `json:"data"`
+	Selection selection   `json:"selection"`
+}
+
+// entityRef references an entity in a normalized value.
+// It is stored in the backend as {"__ref": "<entity key>"}.
+type entityRef string
+
+func (r entityRef) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"__ref": string(r)})
+}
+
+// selection is the tree of fields selected by a query, as seen in its response. Leaves are nil.
+type selection map[string]selection
+
+// NewNormalizedCache returns an empty normalized cache storing entities and query results in backend.
+// If backend is nil, an in-memory LRUCache holding 10000 entries is used.
+func NewNormalizedCache(backend Cache) *NormalizedCache {
+	if backend == nil {
+		backend = NewLRUCache(10000)
+	}
+	return &NormalizedCache{backend: backend, scopes: make(map[string]bool)}
+}
+
+// WithNormalizedCache returns a copy of the client that answers queries from cache when all the entities
+// and fields they select are cached, and stores the responses to queries and mutations in cache.
+// Requests with file uploads and responses with errors are neither answered from nor stored in the cache.
+//
+// As for WithCache, the entities and results of requests sent with different headers or credentials are kept apart.
+func (c *Client) WithNormalizedCache(cache *NormalizedCache) *Client {
+	c2 := c.clone()
+	c2.normalized = cache
+	return c2
+}
+
+// Evict removes the entity with the given typename and id from the cache, whatever the credentials
+// it was fetched with. Queries selecting it are sent to the server again.
+func (n *NormalizedCache) Evict(typename string, id interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	key := fmt.Sprintf("%s:%v", typename, id)
+	n.backend.Delete(context.Background(), n.entityBackendKey("", key))
+	for scope := range n.scopes {
+		n.backend.Delete(context.Background(), n.entityBackendKey(scope, key))
+	}
+}
+
+// Clear makes the cache forget all entities and query results.
+// Entries of a shared backend are left for other processes, until they are evicted.
+func (n *NormalizedCache) Clear() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.generation++
+	n.scopes = make(map[string]bool)
+}
+
+func (n *NormalizedCache) entityBackendKey(scope, key string) string {
+	if scope != "" {
+		key = scope + "/" + key
+	}
+	return "graphql:" + strconv.Itoa(n.generation) + ":entity:" + key
+}
+
+func (n *NormalizedCache) resultBackendKey(key string) string {
+	return "graphql:" + strconv.Itoa(n.generation) + ":result:" + key
+}
+
+// getEntity returns the fields of the entity with the given key.
+func (n *NormalizedCache) getEntity(ctx context.Context, scope, key string) (map[string]interface{}, bool) {
+	value, ok := n.backend.Get(ctx, n.entityBackendKey(scope, key))
+	if !ok {
+		return nil, false
+	}
+	fields, ok := decodeNormalized(value).(map[string]interface{})
+	return fields, ok
+}
+
+// read assembles the response to the query in with the given cache key from the cached entities of scope.
+// It returns nil if the query wasn't seen before, or some of the data it selects is missing.
+func (n *NormalizedCache) read(ctx context.Context, scope, key string, in requestPayload) []byte {
+	if key == "" {
+		return nil
+	}
+	names := documentFieldNames(in.Query, in.Variables)
+	if names == nil {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	value, ok := n.backend.Get(ctx, n.resultBackendKey(key))
+	if !ok {
+		return nil
+	}
+	var result normalizedResult
+	if err := json.Unmarshal(value, &result); err != nil {
+		return nil
+	}
+	stored, ok := decodeNormalized(value).(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	data, ok := n.denormalize(ctx, scope, stored["data"], result.Selection, names)
+	if !ok {
+		return nil
+	}
+	body, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+// write normalizes the data of resp, the response to the operation in, into the entities of scope,
+// unless the response has errors. If key is not empty, the normalized result is also stored
+// as the result of the query with that cache key. The body of resp is replaced so that it can still be read.
+func (n *NormalizedCache) write(ctx context.Context, scope, key string, in requestPayload, resp *http.Response) error {
+	body, err := readAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var out struct {
+		Data   interface{}
+		Errors []json.RawMessage
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&out); err != nil || len(out.Errors) > 0 || out.Data == nil {
+		return nil
+	}
+	names := documentFieldNames(in.Query, in.Variables)
+	if names == nil {
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if scope != "" && n.scopes == nil {
+		n.scopes = make(map[string]bool)
+	}
+	if scope != "" {
+		n.scopes[scope] = true
+	}
+	data := n.normalize(ctx, scope, out.Data, names)
+	if key != "" {
+		value, err := json.Marshal(normalizedResult{Data: data, Selection: selectionOf(out.Data)})
+		if err != nil {
+			return nil
+		}
+		n.backend.Set(ctx, n.resultBackendKey(key), value, 0)
+	}
+	return nil
+}
+
+// decodeNormalized decodes a value stored in the backend, restoring entity references.
+func decodeNormalized(value []byte) interface{} {
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+	return restoreRefs(v)
+}
+
+// restoreRefs replaces the {"__ref": key} objects in v with entity references.
+func restoreRefs(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["__ref"].(string); ok && len(v) == 1 {
+			return entityRef(ref)
+		}
+		for name, value := range v {
+			v[name] = restoreRefs(value)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = restoreRefs(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// normalize stores the entities in v into scope, and returns v with the entities replaced by references.
+// names are the store names of the fields of v.
+func (n *NormalizedCache) normalize(ctx context.Context, scope string, v interface{}, names fieldNames) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		fields := make(map[string]interface{}, len(v))
+		for name, value := range v {
+			fields[name] = n.normalize(ctx, scope, value, names.child(name))
+		}
+		key, ok := entityKey(v)
+		if !ok {
+			return fields
+		}
+		entity, ok := n.getEntity(ctx, scope, key)
+		if !ok {
+			entity = make(map[string]interface{}, len(fields))
+		}
+		for name, value := range fields {
+			entity[names.storeName(name)] = value
+		}
+		if value, err := json.Marshal(entity); err == nil {
+			n.backend.Set(ctx, n.entityBackendKey(scope, key), value, 0)
+		}
+		return entityRef(key)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = n.normalize(ctx, scope, item, names)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+// denormalize replaces the entity references in v by entities of scope, restricting their fields to sel.
+// names are the store names of the fields of v. It reports whether all the selected data is in the cache.
+func (n *NormalizedCache) denormalize(ctx context.Context, scope string, v interface{}, sel selection, names fieldNames) (interface{}, bool) {
+	switch v := v.(type) {
+	case entityRef:
+		entity, ok := n.getEntity(ctx, scope, string(v))
+		if !ok {
+			return nil, false
+		}
+		out := make(map[string]interface{}, len(sel))
+		for name, child := range sel {
+			value, ok := entity[names.storeName(name)]
+			if !ok {
+				return nil, false
+			}
+			if out[name], ok = n.denormalize(ctx, scope, value, child, names.child(name)); !ok {
+				return nil, false
+			}
+		}
+		return out, true
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for name, value := range v {
+			var ok bool
+			if out[name], ok = n.denormalize(ctx, scope, value, sel[name], names.child(name)); !ok {
+				return nil, false
+			}
+		}
+		return out, true
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			var ok bool
+			if out[i], ok = n.denormalize(ctx, scope, item, sel, names); !ok {
+				return nil, false
+			}
+		}
+		return out, true
+	default:
+		return v, true
+	}
+}
+
+// entityKey returns the key identifying object as an entity, if it has a __typename and an id.
+func entityKey(object map[string]interface{}) (string, bool) {
+	typename, ok := object["__typename"].(string)
+	if !ok {
+		return "", false
+	}
+	switch id := object["id"].(type) {
+	case string:
+		return typename + ":" + id, true
+	case json.Number:
+		return typename + ":" + id.String(), true
+	default:
+		return "", false
+	}
+}
+
+// selectionOf returns the fields selected in v. The selections of the items of lists are merged.
+func selectionOf(v interface{}) selection {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		sel := make(selection, len(v))
+		for name, value := range v {
+			sel[name] = selectionOf(value)
+		}
+		return sel
+	case []interface{}:
+		var sel selection
+		for _, item := range v {
+			sel = mergeSelections(sel, selectionOf(item))
+		}
+		return sel
+	default:
+		return nil
+	}
+}
+
+// mergeSelections returns the union of a and b.
+func mergeSelections(a, b selection) selection {
+	if a == nil {
+		return b
+	}
+	for name, child := range b {
+		a[name] = mergeSelections(a[name], child)
+	}
+	return a
+}
+
+// fieldNames maps the response keys of the fields selected by an operation to the names under which
+// the normalized cache stores them: the field name, followed by its arguments as canonical JSON,
+// e.g. `user({"id":"1"})` for `viewer: user(id: $id)` with the variable id set to "1".
+type fieldNames map[string]*fieldName
+
+type fieldName struct {
+	name   string
+	fields fieldNames
+}
+
+// storeName returns the store name of the field with the given response key.
+func (f fieldNames) storeName(key string) string {
+	if field := f[key]; field != nil {
+		return field.name
+	}
+	return key
+}
+
+// child returns the store names of the fields selected in the field with the given response key.
+func (f fieldNames) child(key string) fieldNames {
+	if field := f[key]; field != nil {
+		return field.fields
+	}
+	return nil
+}
+
+// fieldNameParser parses the fields selected by an operation, see documentFieldNames.
+type fieldNameParser struct {
+	tokens    []string
+	variables map[string]interface{}
+	// fragments are the indexes of the selection sets of the fragments of the document, by name.
+	fragments map[string]int
+	// spreading are the fragments being parsed, to stop at cyclic spreads.
+	spreading map[string]bool
+	failed    bool
+}
+
+// documentFieldNames returns the store names of the fields selected by the first operation of query,
+// including those of the fragments it spreads, given the values of its variables.
+// It returns nil if query can't be parsed.
+func documentFieldNames(query string, variables map[string]interface{}) fieldNames {
+	p := &fieldNameParser{
+		tokens:    documentTokens(query),
+		variables: variables,
+		fragments: make(map[string]int),
+		spreading: make(map[string]bool),
+	}
+	operation := -1
+	for i := 0; i < len(p.tokens); {
+		// Find the selection set of the definition, skipping its variable definitions and directive arguments.
+		start, depth := i, 0
+		for ; i < len(p.tokens) && (p.tokens[i] != "{" || depth > 0); i++ {
+			switch p.tokens[i] {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			}
+		}
+		if i == len(p.tokens) {
+			return nil
+		}
+		if p.tokens[start] == "fragment" && start+1 < i {
+			p.fragments[p.tokens[start+1]] = i
+		} else if operation < 0 {
+			operation = i
+		}
+		for depth = 0; i < len(p.tokens); i++ {
+			if p.tokens[i] == "{" {
+				depth++
+			} else if p.tokens[i] == "}" {
+				if depth--; depth == 0 {
+					i++
+					break
+				}
+			}
+		}
+	}
+	if operation < 0 {
+		return nil
+	}
+	names := make(fieldNames)
+	p.selectionSet(operation, names)
+	if p.failed {
+		return nil
+	}
+	return names
+}
+
+func (p *fieldNameParser) token(i int) string {
+	if i >= len(p.tokens) {
+		p.failed = true
+		return ""
+	}
+	return p.tokens[i]
+}
+
+// selectionSet adds the fields of the selection set starting at index i to names,
+// and returns the index just after it.
+func (p *fieldNameParser) selectionSet(i int, names fieldNames) int {
+	if p.token(i) != "{" {
+		p.failed = true
+		return len(p.tokens)
+	}
+	for i++; !p.failed && p.token(i) != "}"; {
+		if p.tokens[i] == "..." {
+			switch t := p.token(i + 1); t {
+			case "on":
+				i = p.selectionSet(p.directives(i+3), names)
+			case "{", "@":
+				i = p.selectionSet(p.directives(i+1), names)
+			default:
+				i = p.directives(i + 2)
+				if start, ok := p.fragments[t]; ok && !p.spreading[t] {
+					p.spreading[t] = true
+					p.selectionSet(start, names)
+					p.spreading[t] = false
+				}
+			}
+			continue
+		}
+		key, name := p.tokens[i], p.tokens[i]
+		i++
+		if p.token(i) == ":" {
+			name = p.token(i + 1)
+			i += 2
+		}
+		if p.token(i) == "(" {
+			var args map[string]interface{}
+			args, i = p.arguments(i)
+			b, err := json.Marshal(args)
+			if err != nil {
+				p.failed = true
+			}
+			name += "(" + string(b) + ")"
+		}
+		i = p.directives(i)
+		field := names[key]
+		if field == nil {
+			field = &fieldName{name: name}
+			names[key] = field
+		}
+		if p.token(i) == "{" {
+			if field.fields == nil {
+				field.fields = make(fieldNames)
+			}
+			i = p.selectionSet(i, field.fields)
+		}
+	}
+	return i + 1
+}
+
+// directives skips the directives starting at index i, if any, and returns the index just after them.
+func (p *fieldNameParser) directives(i int) int {
+	for !p.failed && p.token(i) == "@" {
+		i += 2
+		if p.token(i) == "(" {
+			_, i = p.arguments(i)
+		}
+	}
+	return i
+}
+
+// arguments returns the arguments starting at index i, and the index just after them.
+// Variables are replaced by their values, encoded as JSON.
+func (p *fieldNameParser) arguments(i int) (map[string]interface{}, int) {
+	args := make(map[string]interface{})
+	for i++; !p.failed && p.token(i) != ")"; {
+		name := p.tokens[i]
+		if p.token(i+1) != ":" {
+			p.failed = true
+			break
+		}
+		args[name], i = p.value(i + 2)
+	}
+	return args, i + 1
+}
+
+// value returns the value starting at index i, and the index just after it.
+func (p *fieldNameParser) value(i int) (interface{}, int) {
+	t := p.token(i)
+	switch {
+	case t == "":
+		return nil, i
+	case t == "$":
+		b, err := json.Marshal(p.variables[p.token(i+1)])
+		if err != nil {
+			p.failed = true
+		}
+		return json.RawMessage(b), i + 2
+	case t == "[":
+		var list []interface{}
+		for i++; !p.failed && p.token(i) != "]"; {
+			var item interface{}
+			item, i = p.value(i)
+			list = append(list, item)
+		}
+		return list, i + 1
+	case t == "{":
+		object := make(map[string]interface{})
+		for i++; !p.failed && p.token(i) != "}"; {
+			name := p.tokens[i]
+			if p.token(i+1) != ":" {
+				p.failed = true
+				break
+			}
+			object[name], i = p.value(i + 2)
+		}
+		return object, i + 1
+	case strings.HasPrefix(t, `"""`):
+		return strings.TrimSuffix(strings.TrimPrefix(t, `"""`), `"""`), i + 1
+	case t[0] == '"':
+		var s string
+		if err := json.Unmarshal([]byte(t), &s); err != nil {
+			return t, i + 1
+		}
+		return s, i + 1
+	case t == "true" || t == "false":
+		return t == "true", i + 1
+	case t == "null":
+		return nil, i + 1
+	case isNumber(t):
+		return json.Number(t), i + 1
+	default: // Enum values.
+		return t, i + 1
+	}
+}
@@ -0,0 +1,141 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_WithNormalizedCache(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		body := mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(body, "renameUser"):
+			mustWrite(w, `{"data": {"renameUser": {"__typename": "User", "id": 2, "name": "Robert"}}}`)
+		default:
+			mustWrite(w, `{"data": {"user": {"__typename": "User", "id": 1, "name": "Alice",
+				"friends": [{"__typename": "User", "id": 2, "name": "Bob"}]}}}`)
+		}
+	})
+	cache := graphql.NewNormalizedCache(nil)
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithNormalizedCache(cache)
+
+	type user struct {
+		ID      int
+		Name    string
+		Friends []struct {
+			ID   int
+			Name string
+		}
+	}
+	query := func() user {
+		var q struct {
+			User user
+		}
+		err := client.Query(context.Background(), graphql.ManualRequest{
+			Query:  "{user(id:1){__typename,id,name,friends{__typename,id,name}}}",
+			Result: &q,
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q.User
+	}
+
+	if u := query(); u.Name != "Alice" || len(u.Friends) != 1 || u.Friends[0].Name != "Bob" {
+		t.Errorf("got user: %+v, want Alice with friend Bob", u)
+	}
+	if u := query(); u.Name != "Alice" || len(u.Friends) != 1 || u.Friends[0].Name != "Bob" {
+		t.Errorf("got cached user: %+v, want Alice with friend Bob", u)
+	}
+	if requests != 1 {
+		t.Errorf("got %v requests, want: 1", requests)
+	}
+
+	var m struct {
+		RenameUser struct {
+			Name string
+		}
+	}
+	err := client.Mutate(context.Background(), graphql.ManualRequest{
+		Query:  `mutation{renameUser(id:2,name:"Robert"){__typename,id,name}}`,
+		Result: &m,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := query(); u.Friends[0].Name != "Robert" {
+		t.Errorf("got friend: %+v, want Robert updated by the mutation", u.Friends[0])
+	}
+	if requests != 2 {
+		t.Errorf("got %v requests, want: 2", requests)
+	}
+
+	cache.Evict("User", 2)
+	query()
+	if requests != 3 {
+		t.Errorf("got %v requests after eviction, want: 3", requests)
+	}
+}
+
+func TestClient_WithNormalizedCache_arguments(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		body := mustRead(req.Body)
+		avatar := "small"
+		if strings.Contains(body, `size":2`) {
+			avatar = "large"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"__typename": "User", "id": 1, "name": "`+req.Header.Get("Authorization")+`", "avatar": "`+avatar+`"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithNormalizedCache(graphql.NewNormalizedCache(nil))
+
+	type user struct {
+		Name   string
+		Avatar string
+	}
+	query := func(size int, options ...graphql.RequestOption) user {
+		var q struct {
+			User user
+		}
+		err := client.Query(context.Background(), graphql.ManualRequest{
+			Query:  "query($size:Int!){user(id:1){__typename,id,name,avatar(size:$size)}}",
+			Result: &q,
+		}, map[string]interface{}{"size": size}, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q.User
+	}
+
+	for _, tc := range []struct {
+		size         int
+		token        string
+		want         user
+		wantRequests int
+	}{
+		{size: 1, token: "a", want: user{Name: "Bearer a", Avatar: "small"}, wantRequests: 1},
+		{size: 2, token: "a", want: user{Name: "Bearer a", Avatar: "large"}, wantRequests: 2},
+		// The avatars of both sizes are stored with the user.
+		{size: 1, token: "a", want: user{Name: "Bearer a", Avatar: "small"}, wantRequests: 2},
+		{size: 1, token: "b", want: user{Name: "Bearer b", Avatar: "small"}, wantRequests: 3},
+		// The entities fetched with other credentials are kept apart.
+		{size: 1, token: "a", want: user{Name: "Bearer a", Avatar: "small"}, wantRequests: 3},
+	} {
+		if got := query(tc.size, graphql.WithRequestToken(tc.token)); got != tc.want || requests != tc.wantRequests {
+			t.Errorf("size %v, token %q: got user: %+v after %v requests, want: %+v after %v", tc.size, tc.token, got, requests, tc.want, tc.wantRequests)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+// WithBigNumbersAsStrings returns a copy of the client that encodes the big.Int and big.Float values of variables
+// as JSON strings, e.g. "12345678901234567890", for servers whose scalars of arbitrary-precision numbers,
+// such as BigInt or Decimal, take strings. By default, big.Int values are encoded as JSON numbers,
+// and big.Float values as strings, as encoding/json does.
+//
+// Whatever the encoding of variables, big.Int and big.Float values are decoded from the data of responses
+// from both JSON strings and numbers, without loss of precision. Decimal types of other packages, such as
+// shopspring/decimal, are encoded and decoded as they implement encoding/json; implement GraphQLMarshaler
+// to encode them otherwise.
+//
+// The GraphQL types of such variables are inferred from their Go names, e.g. Int for big.Int;
+// declare the scalar types of the server with RegisterScalar or WithVariableTypes.
+func (c *Client) WithBigNumbersAsStrings() *Client {
+	c2 := c.clone()
+	c2.bigNumbersAsStrings = true
+	return c2
+}
+
+// formatBigNumber returns the string encoding of v, a big.Int or big.Float, and whether it is one.
+func formatBigNumber(v reflect.Value) (string, bool) {
+	switch v.Type() {
+	case bigIntType:
+		n := v.Interface().(big.Int)
+		return n.String(), true
+	case bigFloatType:
+		f := v.Interface().(big.Float)
+		return f.Text('g', -1), true
+	}
+	return "", false
+}
+
+// decodeBigNumber decodes data, a JSON number or string, into v, a big.Int or big.Float.
+func decodeBigNumber(v reflect.Value, data interface{}) error {
+	var s string
+	switch data := data.(type) {
+	case nil:
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	case json.Number:
+		s = data.String()
+	case float64:
+		s = strconv.FormatFloat(data, 'f', -1, 64)
+	case string:
+		s = data
+	default:
+		return fmt.Errorf("cannot decode %T into %v", data, v.Type())
+	}
+	var ok bool
+	switch n := v.Addr().Interface().(type) {
+	case *big.Int:
+		_, ok = n.SetString(s, 10)
+	case *big.Float:
+		_, ok = n.SetString(s)
+	}
+	if !ok {
+		return fmt.Errorf("cannot decode %q into %v", s, v.Type())
+	}
+	return nil
+}
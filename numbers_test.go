@@ -0,0 +1,62 @@
+package graphql_test
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_bigNumbers(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"account": {"balance": 12345678901234567890123, "limit": "98765432109876543210", "rate": "0.1234567890123456789", "cents": 9007199254740993, "raw": 1.5}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Account struct {
+			Balance big.Int
+			Limit   *big.Int
+			Rate    big.Float
+			Cents   int64
+			Raw     interface{}
+		}
+	}
+	limit, _ := new(big.Int).SetString("100000000000000000000", 10)
+	variables := map[string]interface{}{"limit": limit, "rate": big.NewFloat(0.5)}
+	err := client.WithBigNumbersAsStrings().Query(context.Background(), graphql.ManualRequest{Query: "{account{balance,limit,rate,cents,raw}}", Result: &q}, variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"query":"{account{balance,limit,rate,cents,raw}}","variables":{"limit":"100000000000000000000","rate":"0.5"}}` + "\n"; gotBody != want {
+		t.Errorf("got body: %v, want: %v", gotBody, want)
+	}
+	if got := q.Account.Balance.String(); got != "12345678901234567890123" {
+		t.Errorf("got balance: %v", got)
+	}
+	if q.Account.Limit == nil || q.Account.Limit.String() != "98765432109876543210" {
+		t.Errorf("got limit: %v", q.Account.Limit)
+	}
+	if got := q.Account.Rate.Text('g', 19); got != "0.1234567890123456789" {
+		t.Errorf("got rate: %v", got)
+	}
+	if q.Account.Cents != 9007199254740993 {
+		t.Errorf("got cents: %v, want: 9007199254740993", q.Account.Cents)
+	}
+	if q.Account.Raw != 1.5 {
+		t.Errorf("got raw: %#v, want: 1.5", q.Account.Raw)
+	}
+
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{account{limit}}", Result: &q}, map[string]interface{}{"limit": limit}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"query":"{account{limit}}","variables":{"limit":100000000000000000000}}` + "\n"; gotBody != want {
+		t.Errorf("got body: %v, want: %v", gotBody, want)
+	}
+}
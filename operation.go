@@ -0,0 +1,300 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// OperationErrorConfig configures the operation attached to errors, see WithOperationInErrors.
+type OperationErrorConfig struct {
+	// Document attaches the document of the operation, in addition to its name.
+	Document bool
+
+	// Redact replaces the string and number literals of the attached document, which may hold personal data
+	// or secrets, by placeholders, and removes its comments. Variables are never attached.
+	Redact bool
+}
+
+// WithOperationInErrors returns a copy of the client that wraps the errors of operations in an *OperationError
+// with the operation name and, as configured, the document of the operation, for easier debugging,
+// e.g. in development environments. The original errors can still be found with errors.Is and errors.As.
+//
+// By default, errors don't include operations, which may be large or sensitive.
+func (c *Client) WithOperationInErrors(config OperationErrorConfig) *Client {
+	c2 := c.clone()
+	c2.operationErrors = &config
+	return c2
+}
+
+// OperationError is an error of an operation, with the operation attached, see WithOperationInErrors.
+type OperationError struct {
+	// OperationName is the name of the operation, or "" if it is anonymous.
+	OperationName string
+
+	// Document is the document of the operation, possibly redacted, or "" if it isn't attached.
+	Document string
+
+	// Err is the error of the operation.
+	Err error
+}
+
+// Error implements error interface.
+func (e *OperationError) Error() string {
+	name := e.OperationName
+	if name == "" {
+		name = "anonymous operation"
+	}
+	if e.Document == "" {
+		return fmt.Sprintf("%s: %v", name, e.Err)
+	}
+	return fmt.Sprintf("%s: %v (document: %s)", name, e.Err, e.Document)
+}
+
+// Unwrap returns the error of the operation.
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// operationError wraps err in an *OperationError with the operation of v, if the client is configured to.
+func (c *Client) operationError(err error, op operationType, v interface{}, variables map[string]interface{}, name string) error {
+	if err == nil || c.operationErrors == nil {
+		return err
+	}
+	var document string
+	if mr, ok := v.(ManualRequest); ok {
+		document = mr.Query
+	} else {
+		document, _ = c.constructOperation(op, v, variables, name)
+	}
+	e := &OperationError{OperationName: name, Err: err}
+	if e.OperationName == "" {
+		e.OperationName = documentOperationName(document)
+	}
+	if c.operationErrors.Document {
+		e.Document = document
+		if c.operationErrors.Redact {
+			e.Document = redactDocument(document)
+		}
+	}
+	return e
+}
+
+// WithOperationNamesFromTypes returns a copy of the client that names anonymous operations after the type of
+// their result, e.g. "GetViewerQuery" for a *GetViewerQuery result, for server logs, APMs and persisted query
+// tooling. Results of anonymous struct types, and operations named otherwise, are left as they are.
+func (c *Client) WithOperationNamesFromTypes() *Client {
+	c2 := c.clone()
+	c2.operationNamesFromTypes = true
+	return c2
+}
+
+// typeOperationName returns the name of the type of v, a struct or a pointer to one,
+// if the client names operations after the types of their results and it is a valid GraphQL name.
+func (c *Client) typeOperationName(v interface{}) string {
+	if !c.operationNamesFromTypes {
+		return ""
+	}
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || !isName(t.Name()) {
+		return ""
+	}
+	return t.Name()
+}
+
+// isName reports whether s is a GraphQL name.
+func isName(s string) bool {
+	if s == "" || isDigit(s[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isNameOrNumberChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// nameOperation returns query with its anonymous operation named name, e.g. "query Name{viewer{login}}"
+// for "{viewer{login}}". query is returned as is if name is empty, or its first operation is already named.
+// Fragment definitions before the operation are skipped.
+func nameOperation(query, name string) string {
+	if name == "" || documentOperationName(query) != "" {
+		return query
+	}
+	for i := operationStart(query); i < len(query); {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], "\ufeff"):
+			i += len("\ufeff")
+		case c == '{':
+			return query[:i] + "query " + name + query[i:]
+		default:
+			for _, keyword := range []string{"query", "mutation", "subscription"} {
+				if end := i + len(keyword); strings.HasPrefix(query[i:], keyword) && (end == len(query) || !isNameOrNumberChar(query[end])) {
+					return query[:end] + " " + name + query[end:]
+				}
+			}
+			return query
+		}
+	}
+	return query
+}
+
+// addOperationDirectives returns query with directives, e.g. "@cached(ttl: 60)", added to its first operation,
+// before its selection set. Anonymous queries in the shorthand form are given the query keyword.
+func addOperationDirectives(query string, directives []string) string {
+	if len(directives) == 0 {
+		return query
+	}
+	list := strings.Join(directives, " ")
+	shorthand := true
+	depth := 0
+	for i := operationStart(query); i < len(query); {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+			continue
+		case c == '#':
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(query[i:], "\ufeff"):
+			i += len("\ufeff")
+			continue
+		case c == '"':
+			i = stringEnd(query, i)
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			depth--
+			i++
+		case c == '{' && depth == 0:
+			if shorthand {
+				return query[:i] + "query " + list + " " + query[i:]
+			}
+			if prev := query[i-1]; prev != ' ' && prev != '\t' && prev != '\n' && prev != '\r' {
+				list = " " + list
+			}
+			return query[:i] + list + " " + query[i:]
+		default:
+			i++
+		}
+		shorthand = false
+	}
+	return query
+}
+
+// documentOperationName returns the name of the first operation in query, or "" if it is anonymous.
+func documentOperationName(query string) string {
+	normalized := normalizeDocument(query[operationStart(query):])
+	for _, keyword := range []string{"query ", "mutation ", "subscription "} {
+		if strings.HasPrefix(normalized, keyword) {
+			name := normalized[len(keyword):]
+			end := 0
+			for end < len(name) && isNameOrNumberChar(name[end]) {
+				end++
+			}
+			return name[:end]
+		}
+	}
+	return ""
+}
+
+// operationStart returns the index of the first operation definition in query, after the fragment definitions
+// that may precede it, or len(query) if there is none.
+func operationStart(query string) int {
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], "\ufeff"):
+			i += len("\ufeff")
+		default:
+			if end := i + len("fragment"); !strings.HasPrefix(query[i:], "fragment") || end < len(query) && isNameOrNumberChar(query[end]) {
+				return i
+			}
+			i = definitionEnd(query, i)
+		}
+	}
+	return len(query)
+}
+
+// definitionEnd returns the index just after the selection set of the definition starting at query[start],
+// or len(query) if it is unterminated.
+func definitionEnd(query string, start int) int {
+	braces, parens := 0, 0
+	for i := start; i < len(query); {
+		switch c := query[i]; {
+		case c == '#':
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+			continue
+		case c == '"':
+			i = stringEnd(query, i)
+			continue
+		case c == '(':
+			parens++
+		case c == ')':
+			parens--
+		case c == '{' && parens == 0:
+			braces++
+		case c == '}' && parens == 0:
+			if braces--; braces == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return len(query)
+}
+
+// redactDocument returns query with its string literals replaced by "<redacted>", its number literals by 0,
+// and its comments removed.
+func redactDocument(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '#':
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+		case c == '"':
+			i = stringEnd(query, i)
+			b.WriteString(`"<redacted>"`)
+		case isNameOrNumberChar(c) || (c == '-' && i+1 < len(query) && isDigit(query[i+1])):
+			start := i
+			i++
+			for i < len(query) && (isNameOrNumberChar(query[i]) || query[i] == '.' && isNumber(query[start:i]) ||
+				(query[i] == '+' || query[i] == '-') && (query[i-1] == 'e' || query[i-1] == 'E') && isNumber(query[start:i-1])) {
+				i++
+			}
+			if isNumber(query[start:i]) {
+				b.WriteString("0")
+			} else {
+				b.WriteString(query[start:i])
+			}
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
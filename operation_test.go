@@ -0,0 +1,205 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_WithOperationInErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"errors": [{"message": "boom"}], "data": null}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	query := `# Look up a user.
+query GetUser($first: Int) {
+	user(email: "gopher@example.com", pin: -1234) { login, repositories(first: $first) { name } }
+}`
+
+	for _, tc := range []struct {
+		name         string
+		config       graphql.OperationErrorConfig
+		wantDocument string
+	}{
+		{name: "name only"},
+		{name: "document", config: graphql.OperationErrorConfig{Document: true}, wantDocument: query},
+		{
+			name:   "redacted",
+			config: graphql.OperationErrorConfig{Document: true, Redact: true},
+			wantDocument: `
+query GetUser($first: Int) {
+	user(email: "<redacted>", pin: 0) { login, repositories(first: $first) { name } }
+}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var q struct{}
+			err := client.WithOperationInErrors(tc.config).Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, nil)
+			var opErr *graphql.OperationError
+			if !errors.As(err, &opErr) {
+				t.Fatalf("got error: %v, want *OperationError", err)
+			}
+			if opErr.OperationName != "GetUser" {
+				t.Errorf("got operation name: %q, want: %q", opErr.OperationName, "GetUser")
+			}
+			if opErr.Document != tc.wantDocument {
+				t.Errorf("got document: %q, want: %q", opErr.Document, tc.wantDocument)
+			}
+			if !errors.Is(err, graphql.ErrGraphQL) {
+				t.Errorf("got error: %v, want it in the ErrGraphQL category", err)
+			}
+		})
+	}
+
+	var q struct{}
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, nil)
+	var opErr *graphql.OperationError
+	if errors.As(err, &opErr) {
+		t.Errorf("got error: %v, want no operation by default", err)
+	}
+}
+
+type GetViewerQuery struct {
+	Viewer struct {
+		Login graphql.String
+	}
+}
+
+func TestClient_WithOperationName(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	for _, tc := range []struct {
+		name     string
+		client   *graphql.Client
+		query    string
+		result   interface{}
+		options  []graphql.RequestOption
+		wantBody string
+	}{
+		{
+			name:     "anonymous",
+			client:   client,
+			query:    "{viewer{login}}",
+			result:   &GetViewerQuery{},
+			wantBody: `{"query":"{viewer{login}}"}`,
+		},
+		{
+			name:     "named document",
+			client:   client,
+			query:    "query Viewer{viewer{login}}",
+			result:   &GetViewerQuery{},
+			wantBody: `{"query":"query Viewer{viewer{login}}","operationName":"Viewer"}`,
+		},
+		{
+			name:     "option",
+			client:   client,
+			query:    "{viewer{login}}",
+			result:   &GetViewerQuery{},
+			options:  []graphql.RequestOption{graphql.WithOperationName("Me")},
+			wantBody: `{"query":"query Me{viewer{login}}","operationName":"Me"}`,
+		},
+		{
+			name:     "option with keyword",
+			client:   client,
+			query:    "# Comment.\nquery ($first: Int) {viewer{login}}",
+			result:   &GetViewerQuery{},
+			options:  []graphql.RequestOption{graphql.WithOperationName("Me")},
+			wantBody: `{"query":"# Comment.\nquery Me ($first: Int) {viewer{login}}","operationName":"Me"}`,
+		},
+		{
+			name:     "leading fragment",
+			client:   client,
+			query:    "fragment F on User @x(a: {b: \"}\"}) {login}\nquery Viewer{viewer{...F}}",
+			result:   &GetViewerQuery{},
+			wantBody: `{"query":"fragment F on User @x(a: {b: \"}\"}) {login}\nquery Viewer{viewer{...F}}","operationName":"Viewer"}`,
+		},
+		{
+			name:     "option with leading fragment",
+			client:   client,
+			query:    "fragment F on User{login} {viewer{...F}}",
+			result:   &GetViewerQuery{},
+			options:  []graphql.RequestOption{graphql.WithOperationName("Me")},
+			wantBody: `{"query":"fragment F on User{login} query Me{viewer{...F}}","operationName":"Me"}`,
+		},
+		{
+			name:     "type name",
+			client:   client.WithOperationNamesFromTypes(),
+			query:    "{viewer{login}}",
+			result:   &GetViewerQuery{},
+			wantBody: `{"query":"query GetViewerQuery{viewer{login}}","operationName":"GetViewerQuery"}`,
+		},
+		{
+			name:     "anonymous type",
+			client:   client.WithOperationNamesFromTypes(),
+			query:    "{viewer{login}}",
+			result:   &struct{ Viewer struct{ Login string } }{},
+			wantBody: `{"query":"{viewer{login}}"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.client.Query(context.Background(), graphql.ManualRequest{Query: tc.query, Result: tc.result}, nil, tc.options...); err != nil {
+				t.Fatal(err)
+			}
+			if gotBody != tc.wantBody+"\n" {
+				t.Errorf("got body: %v, want: %v", gotBody, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestClient_WithMinifiedDocuments(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"repository": {"name": "go"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithMinifiedDocuments()
+
+	query := `
+# Fetches the repository.
+query GetRepository($name: String!) {
+	repository(owner: "golang", name: $name, description: """ keep  this """) {
+		name, stars
+	}
+}`
+	var q struct {
+		Repository struct {
+			Name string
+		}
+	}
+	variables := map[string]interface{}{"name": "go"}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, variables); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"query":"query GetRepository($name:String!){repository(owner:\"golang\"name:$name description:\"\"\" keep  this \"\"\"){name stars}}","operationName":"GetRepository","variables":{"name":"go"}}` + "\n"
+	if gotBody != want {
+		t.Errorf("got body: %v, want %v", gotBody, want)
+	}
+
+	var s struct {
+		Repository struct {
+			Name graphql.String
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	got, err := client.ConstructQuery(&s, map[string]interface{}{"owner": graphql.String("o"), "name": graphql.String("n")}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `query($name:String!$owner:String!){repository(owner:$owner name:$name){name}}`; got != want {
+		t.Errorf("got query: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package graphql
+
+import (
+	"encoding/json"
+)
+
+// Optional is the value of a nullable variable or input object field, in one of three states:
+// unset, the zero value, which is left out of requests; null, sent as an explicit null; or set to a value.
+// It makes the intent of partial updates explicit, where pointers can't tell leaving a variable unchanged
+// from clearing it:
+//
+//	variables := map[string]interface{}{
+//		"id":       graphql.ID(id),
+//		"bio":      graphql.OptionalNull("String"), // Clears the bio.
+//		"location": graphql.Optional{},             // Keeps the location.
+//	}
+//
+// Unset variables are left out of requests, and of the variable definitions of constructed documents.
+// Set variables are declared with the nullable GraphQL type of their value, and null ones with the type
+// given to OptionalNull. Unset fields of the input objects of variables are left out as well.
+type Optional struct {
+	// value is the value, a NullValue if null, or nil if unset.
+	value interface{}
+}
+
+// OptionalOf returns an Optional set to value.
+func OptionalOf(value interface{}) Optional {
+	if value == nil {
+		return Optional{value: NullValue{}}
+	}
+	return Optional{value: value}
+}
+
+// OptionalNull returns an Optional set to null, of the nullable GraphQL type typ, as for Null.
+func OptionalNull(typ string) Optional {
+	return Optional{value: Null(typ)}
+}
+
+// IsSet reports whether o is set, to a value or to null.
+func (o Optional) IsSet() bool {
+	return o.value != nil
+}
+
+// IsNull reports whether o is set to null.
+func (o Optional) IsNull() bool {
+	_, ok := o.value.(NullValue)
+	return ok
+}
+
+// IsZero reports whether o is unset.
+func (o Optional) IsZero() bool {
+	return !o.IsSet()
+}
+
+// Value returns the value of o, and whether it is set to a value rather than unset or null.
+func (o Optional) Value() (interface{}, bool) {
+	if !o.IsSet() || o.IsNull() {
+		return nil, false
+	}
+	return o.value, true
+}
+
+// MarshalJSON implements json.Marshaler, encoding the value of o, or null if it is unset or null.
+func (o Optional) MarshalJSON() ([]byte, error) {
+	if !o.IsSet() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. null sets o to null, and other values are decoded generically,
+// as into an interface{}.
+func (o *Optional) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*o = OptionalOf(value)
+	return nil
+}
@@ -0,0 +1,125 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestOptional(t *testing.T) {
+	type updateUserInput struct {
+		ID       graphql.ID       `json:"id"`
+		Name     graphql.Optional `json:"name"`
+		Bio      graphql.Optional `json:"bio"`
+		Location graphql.Optional `json:"location"`
+	}
+	input := updateUserInput{ID: "1", Name: graphql.OptionalOf(graphql.String("gopher")), Bio: graphql.OptionalNull("String")}
+	if !input.Name.IsSet() || input.Name.IsNull() || !input.Bio.IsNull() || input.Location.IsSet() || !input.Location.IsZero() {
+		t.Errorf("got states: %+v", input)
+	}
+	if value, ok := input.Name.Value(); !ok || value != graphql.String("gopher") {
+		t.Errorf("got value: %v, %v, want: gopher, true", value, ok)
+	}
+	b, err := json.Marshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `{"id":"1","name":"gopher","bio":null,"location":null}`; got != want {
+		t.Errorf("got: %s, want: %s", got, want)
+	}
+
+	var decoded struct {
+		Bio      graphql.Optional
+		Location graphql.Optional
+	}
+	if err := json.Unmarshal([]byte(`{"bio": null}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Bio.IsNull() || decoded.Location.IsSet() {
+		t.Errorf("got decoded: %+v", decoded)
+	}
+}
+
+func TestOptional_variables(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"updateUser": {"id": "1"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	variables := graphql.VariablesOf(struct {
+		ID       graphql.ID       `graphql:"id"`
+		Name     graphql.Optional `graphql:"name"`
+		Bio      graphql.Optional `graphql:"bio"`
+		Location graphql.Optional `graphql:"location"`
+	}{ID: "1", Name: graphql.OptionalOf(graphql.String("gopher")), Bio: graphql.OptionalNull("String")})
+	var m struct {
+		UpdateUser struct {
+			ID graphql.ID
+		} `graphql:"updateUser(id: $id, name: $name, bio: $bio)"`
+	}
+	mutation, err := client.ConstructMutation(&m, variables, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Mutate(context.Background(), graphql.ManualRequest{Query: mutation, Result: &m}, variables); err != nil {
+		t.Fatal(err)
+	}
+	wantBody := `{"query":"mutation ($bio:String$id:ID!$name:String){updateUser(id: $id, name: $name, bio: $bio){id}}","variables":{"bio":null,"id":"1","name":"gopher"}}` + "\n"
+	if gotBody != wantBody {
+		t.Errorf("got body: %v, want: %v", gotBody, wantBody)
+	}
+}
+
+func TestOptional_unsetVariables(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"users": []}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Users []struct {
+			ID graphql.ID
+		} `graphql:"users(first: $first)"`
+	}
+	for _, tc := range []struct {
+		name      string
+		variables map[string]interface{}
+		wantBody  string
+	}{
+		{
+			variables: map[string]interface{}{"first": graphql.Optional{}, "after": graphql.OptionalOf(graphql.String("a"))},
+			wantBody:  `{"query":"query ($after:String){users(first: $first){id}}","variables":{"after":"a"}}`,
+		},
+		{
+			variables: map[string]interface{}{"first": graphql.Optional{}},
+			wantBody:  `{"query":"{users(first: $first){id}}"}`,
+		},
+		{
+			name:      "Users",
+			variables: map[string]interface{}{"first": graphql.Optional{}},
+			wantBody:  `{"query":"query Users{users(first: $first){id}}","operationName":"Users"}`,
+		},
+	} {
+		query, err := client.ConstructQuery(&q, tc.variables, tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, tc.variables); err != nil {
+			t.Fatal(err)
+		}
+		if gotBody != tc.wantBody+"\n" {
+			t.Errorf("got body: %v, want: %v", gotBody, tc.wantBody)
+		}
+	}
+}
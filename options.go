@@ -0,0 +1,121 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// RequestOption configures a single GraphQL request,
+// overriding the client configuration for that request only.
+type RequestOption func(*requestOptions)
+
+// requestOptions holds the per-request configuration collected from RequestOption values.
+type requestOptions struct {
+	proxySet    bool
+	proxyURL    *url.URL
+	credentials credentialsFunc
+	affinityKey string
+
+	uploadProgress func(sent, total int64)
+
+	cachePolicy CachePolicy
+	cacheTTL    time.Duration
+
+	rawData    *json.RawMessage
+	extensions interface{}
+	meta       *ResponseMeta
+	warnings   func(errs GraphQLErrors)
+	strictSet  bool
+	strict     bool
+
+	operationName string
+	directives    []string
+
+	// stream is set by QueryStream, whose response must not be buffered with those of other operations.
+	stream bool
+}
+
+// affectRequest reports whether opts change how the request is sent or cached,
+// as opposed to only how its response is decoded.
+func (opts requestOptions) affectRequest() bool {
+	return opts.proxySet || opts.credentials != nil || opts.affinityKey != "" || opts.uploadProgress != nil ||
+		opts.cachePolicy != CacheFirst || opts.cacheTTL != 0
+}
+
+// newRequestOptions applies options in order and returns the result.
+func newRequestOptions(options []RequestOption) requestOptions {
+	var opts requestOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	return opts
+}
+
+// WithRequestProxy sends the request through the given proxy instead of the one configured on the client.
+// A nil proxyURL makes the request connect directly.
+//
+// It takes effect on clients whose transport was configured with WithProxy.
+func WithRequestProxy(proxyURL *url.URL) RequestOption {
+	return func(opts *requestOptions) {
+		opts.proxySet = true
+		opts.proxyURL = proxyURL
+	}
+}
+
+// WithRawData copies the data of the response, as received, into dst, in addition to decoding it into the result,
+// so that it can be stored or forwarded without encoding the result again.
+// dst is left unchanged if the response has no data; it is set to null if the data is null.
+func WithRawData(dst *json.RawMessage) RequestOption {
+	return func(opts *requestOptions) {
+		opts.rawData = dst
+	}
+}
+
+// WithExtensions decodes the extensions of the response into dst, which should be a pointer to a struct or map.
+// Servers report information such as the cost of the query, tracing and cache hints there.
+// dst is left unchanged if the response has no extensions.
+func WithExtensions(dst interface{}) RequestOption {
+	return func(opts *requestOptions) {
+		opts.extensions = dst
+	}
+}
+
+// WithErrorsAsWarnings delivers the errors of a response to fn, instead of returning them,
+// if the response has data and all of its errors are field errors, i.e. have a path.
+// The operation then returns the partial data with a nil error, with the fields that failed set to null.
+// It suits best-effort screens where some fields failing is expected.
+//
+// Errors of responses without data, or with request errors such as validation errors, are still returned.
+func WithErrorsAsWarnings(fn func(errs GraphQLErrors)) RequestOption {
+	return func(opts *requestOptions) {
+		opts.warnings = fn
+	}
+}
+
+// WithOperationName names the operation of a single request, e.g. for server logs and APMs.
+// The name is sent as the operationName of the request, and given to the operation of its document
+// if it is anonymous. For documents with several operations, it selects the one to execute.
+func WithOperationName(name string) RequestOption {
+	return func(opts *requestOptions) {
+		opts.operationName = name
+	}
+}
+
+// WithOperationDirectives adds directives to the operation of a single request, e.g. "@cached(ttl: 60)"
+// for Hasura's query caching. Field and fragment directives are written in graphql tags instead,
+// e.g. `graphql:"comments @stream(initialCount: 10)"`.
+func WithOperationDirectives(directives ...string) RequestOption {
+	return func(opts *requestOptions) {
+		opts.directives = append(opts.directives, directives...)
+	}
+}
+
+// WithStrict overrides the Strict setting of the client for a single request, so that a client can serve both
+// code relying on graphql tags only, and ad-hoc queries decoded leniently with their json tags.
+func WithStrict(strict bool) RequestOption {
+	return func(opts *requestOptions) {
+		opts.strictSet = true
+		opts.strict = strict
+	}
+}
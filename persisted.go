@@ -0,0 +1,282 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+)
+
+// persistedQueryState records whether the server supports automatic persisted queries.
+// It is shared by a client and the clients derived from it.
+type persistedQueryState struct {
+	// get enables sending the hashes of queries with GET requests, if the URL is at most maxURLLength long.
+	get          bool
+	maxURLLength int
+
+	mu          sync.Mutex
+	unsupported bool
+}
+
+func (s *persistedQueryState) supported() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.unsupported
+}
+
+func (s *persistedQueryState) disable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unsupported = true
+}
+
+// WithPersistedQueries returns a copy of the client that uses Apollo automatic persisted queries (APQ).
+// Requests first send only the SHA-256 hash of the query document. If the server doesn't know the hash yet,
+// the request is sent again with the full document, which the server then stores for later requests.
+//
+// If the server reports that it doesn't support persisted queries, the client goes back to always
+// sending full documents. Requests with file uploads always send the full document.
+//
+// Specification: https://github.com/apollographql/apollo-link-persisted-queries#protocol.
+func (c *Client) WithPersistedQueries() *Client {
+	c2 := c.clone()
+	c2.persisted = &persistedQueryState{}
+	return c2
+}
+
+// WithPersistedQueriesGET returns a copy of the client that uses automatic persisted queries, as WithPersistedQueries,
+// and sends the hashes of queries with GET requests, so that their responses can be cached by CDNs and other HTTP caches.
+// The operation name, variables and extensions are sent as URL query parameters.
+//
+// Queries whose URL would be longer than maxURLLength, mutations, and full documents sent after the server didn't know
+// the hash, are still sent with POST requests. If maxURLLength is not positive, it defaults to 2048.
+func (c *Client) WithPersistedQueriesGET(maxURLLength int) *Client {
+	if maxURLLength <= 0 {
+		maxURLLength = 2048
+	}
+	c2 := c.clone()
+	c2.persisted = &persistedQueryState{get: true, maxURLLength: maxURLLength}
+	return c2
+}
+
+// persistedQueryExtension returns the "persistedQuery" request extension for a document hash.
+func persistedQueryExtension(hash string) map[string]interface{} {
+	return map[string]interface{}{
+		"version":    1,
+		"sha256Hash": hash,
+	}
+}
+
+// documentHash returns the hex-encoded SHA-256 hash of a query document.
+func documentHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// sendPersisted sends in with only the hash of its query.
+// It returns a nil response, and no error, if the full document must be sent instead.
+func (c *Client) sendPersisted(ctx context.Context, op operationType, in requestPayload, header http.Header, opts requestOptions) (*http.Response, error) {
+	in.Query = ""
+	encoded, err := c.marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	body := requestBody{contentType: "application/json", data: encoded}
+	if c.persisted.get && op == queryOperation {
+		params, err := queryParams(in)
+		if err != nil {
+			return nil, err
+		}
+		body.params = params
+		body.maxURLLength = c.persisted.maxURLLength
+	}
+	resp, err := c.send(ctx, op, body, header, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Only the start of the body is read to find persisted query errors, so that large responses are still streamed.
+	prefix, err := readAll(io.LimitReader(resp.Body, maxPersistedQueryErrorSize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(prefix) <= maxPersistedQueryErrorSize {
+		switch persistedQueryError(prefix) {
+		case CodePersistedQueryNotFound:
+			resp.Body.Close()
+			return nil, nil
+		case CodePersistedQueryNotSupported:
+			resp.Body.Close()
+			c.persisted.disable()
+			return nil, nil
+		}
+	}
+	resp.Body = prefixedBody{Reader: io.MultiReader(bytes.NewReader(prefix), resp.Body), Closer: resp.Body}
+	return resp, nil
+}
+
+// maxPersistedQueryErrorSize is the size of the longest response read in full to find persisted query errors.
+// Servers report them in short responses without data.
+const maxPersistedQueryErrorSize = 4 << 10
+
+// prefixedBody reads the start of a response body, already read, followed by the rest of the body.
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// queryParams returns the URL query parameters encoding in for a GET request.
+//
+// Specification: https://github.com/graphql/graphql-over-http/blob/main/spec/GraphQLOverHTTP.md#get.
+func queryParams(in requestPayload) (url.Values, error) {
+	params := make(url.Values)
+	if in.Query != "" {
+		params.Set("query", in.Query)
+	}
+	if in.OperationName != "" {
+		params.Set("operationName", in.OperationName)
+	}
+	for name, value := range map[string]map[string]interface{}{"variables": in.Variables, "extensions": in.Extensions} {
+		if len(value) == 0 {
+			continue
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		params.Set(name, string(b))
+	}
+	return params, nil
+}
+
+// persistedQueryError returns the persisted query error code in a response body, if any.
+// Servers report it either as the error code extension, or as the error message.
+func persistedQueryError(body []byte) string {
+	var out struct {
+		Errors []struct {
+			Message    string
+			Extensions struct {
+				Code string
+			}
+		}
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return ""
+	}
+	for _, e := range out.Errors {
+		switch {
+		case e.Extensions.Code == CodePersistedQueryNotFound || e.Message == "PersistedQueryNotFound":
+			return CodePersistedQueryNotFound
+		case e.Extensions.Code == CodePersistedQueryNotSupported || e.Message == "PersistedQueryNotSupported":
+			return CodePersistedQueryNotSupported
+		}
+	}
+	return ""
+}
+
+// PersistedQueryManifest maps operation names to the hashes of their documents,
+// for servers that only accept operations from a safelist of persisted queries.
+type PersistedQueryManifest struct {
+	// hashes maps operation names to document hashes.
+	hashes map[string]string
+	// known is the set of document hashes in the manifest.
+	known map[string]bool
+}
+
+// NewPersistedQueryManifest returns a manifest mapping operation names to document hashes.
+func NewPersistedQueryManifest(hashes map[string]string) *PersistedQueryManifest {
+	m := &PersistedQueryManifest{
+		hashes: make(map[string]string, len(hashes)),
+		known:  make(map[string]bool, len(hashes)),
+	}
+	for name, hash := range hashes {
+		m.hashes[name] = hash
+		m.known[hash] = true
+	}
+	return m
+}
+
+// LoadPersistedQueryManifest reads a manifest in JSON format. Both the Apollo persisted query manifest format,
+//
+//	{"format": "apollo-persisted-query-manifest", "version": 1, "operations": [{"id": "<hash>", "name": "GetViewer", ...}]}
+//
+// and a plain object mapping operation names to hashes, such as {"GetViewer": "<hash>"}, are accepted.
+func LoadPersistedQueryManifest(r io.Reader) (*PersistedQueryManifest, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var apollo struct {
+		Format     string
+		Operations []struct {
+			ID   string
+			Name string
+		}
+	}
+	if err := json.Unmarshal(data, &apollo); err == nil && apollo.Format == "apollo-persisted-query-manifest" {
+		hashes := make(map[string]string, len(apollo.Operations))
+		for _, op := range apollo.Operations {
+			hashes[op.Name] = op.ID
+		}
+		return NewPersistedQueryManifest(hashes), nil
+	}
+	var hashes map[string]string
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		return nil, fmt.Errorf("decoding persisted query manifest: %v", err)
+	}
+	return NewPersistedQueryManifest(hashes), nil
+}
+
+// OperationNotInManifestError is returned when sending an operation
+// that isn't in the client's persisted query manifest.
+type OperationNotInManifestError struct {
+	// OperationName is the name of the operation, or empty if it is anonymous.
+	OperationName string
+}
+
+// Error implements error interface.
+func (e *OperationNotInManifestError) Error() string {
+	if e.OperationName == "" {
+		return "anonymous operation is not in the persisted query manifest"
+	}
+	return fmt.Sprintf("operation %q is not in the persisted query manifest", e.OperationName)
+}
+
+// WithPersistedQueryManifest returns a copy of the client that only sends the hashes of operations
+// from manifest, and never full documents. Operations are looked up by name, then by the hash of their document.
+// Sending an operation that isn't in the manifest fails with an *OperationNotInManifestError, without contacting the server.
+//
+// It takes precedence over WithPersistedQueries.
+func (c *Client) WithPersistedQueryManifest(manifest *PersistedQueryManifest) *Client {
+	c2 := c.clone()
+	c2.manifest = manifest
+	return c2
+}
+
+// operationNameRe matches the name of the operation defined by a document.
+var operationNameRe = regexp.MustCompile(`^\s*(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// lookup returns the operation name and document hash of an operation named name, with document query.
+// If name is empty, it is read from query.
+func (m *PersistedQueryManifest) lookup(name, query string) (string, string, error) {
+	if name == "" {
+		if match := operationNameRe.FindStringSubmatch(query); match != nil {
+			name = match[1]
+		}
+	}
+	if hash, ok := m.hashes[name]; ok && name != "" {
+		return name, hash, nil
+	}
+	if hash := documentHash(query); m.known[hash] {
+		return name, hash, nil
+	}
+	return "", "", &OperationNotInManifestError{OperationName: name}
+}
@@ -0,0 +1,255 @@
+package graphql_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_WithPersistedQueries(t *testing.T) {
+	const query = "{viewer{login}}"
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+
+	for _, tc := range []struct {
+		name      string
+		errorBody string
+		want      []string
+	}{
+		{
+			name: "known",
+			want: []string{"hash", "hash"},
+		},
+		{
+			name:      "not found",
+			errorBody: `{"errors": [{"message": "PersistedQueryNotFound", "extensions": {"code": "PERSISTED_QUERY_NOT_FOUND"}}]}`,
+			want:      []string{"hash", "query+hash", "hash"},
+		},
+		{
+			name:      "not supported",
+			errorBody: `{"errors": [{"message": "PersistedQueryNotSupported"}]}`,
+			want:      []string{"hash", "query", "query"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			stored := false
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+				var in struct {
+					Query      string
+					Extensions struct {
+						PersistedQuery *struct {
+							Version    int
+							Sha256Hash string
+						}
+					}
+				}
+				if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+					t.Fatal(err)
+				}
+				var parts []string
+				if in.Query != "" {
+					parts = append(parts, "query")
+				}
+				if pq := in.Extensions.PersistedQuery; pq != nil {
+					if pq.Version != 1 || pq.Sha256Hash != hash {
+						t.Errorf("got persisted query: %+v, want version 1 with hash %v", *pq, hash)
+					}
+					parts = append(parts, "hash")
+				}
+				got = append(got, strings.Join(parts, "+"))
+				w.Header().Set("Content-Type", "application/json")
+				if in.Query != "" {
+					stored = true
+				}
+				if tc.errorBody != "" && in.Query == "" && !stored {
+					mustWrite(w, tc.errorBody)
+					return
+				}
+				mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+			})
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithPersistedQueries()
+
+			for i := 0; i < 2; i++ {
+				var q struct {
+					Viewer struct {
+						Login graphql.String
+					}
+				}
+				err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if q.Viewer.Login != "gopher" {
+					t.Errorf("got login: %q, want: %q", q.Viewer.Login, "gopher")
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got requests: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClient_WithPersistedQueriesGET(t *testing.T) {
+	const query = "query($login:String!){user(login:$login){login}}"
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+
+	var got []string
+	stored := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if req.Method == "GET" {
+			got = append(got, "GET "+req.URL.RawQuery)
+			if req.Header.Get("Apollo-Require-Preflight") != "true" {
+				t.Error("GET request without Apollo-Require-Preflight header")
+			}
+			if !stored {
+				mustWrite(w, `{"errors": [{"message": "PersistedQueryNotFound"}]}`)
+				return
+			}
+			mustWrite(w, `{"data": {"user": {"login": "gopher"}}}`)
+			return
+		}
+		var in struct {
+			Query string
+		}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			t.Fatal(err)
+		}
+		if in.Query != "" {
+			stored = true
+			got = append(got, "POST query")
+		} else {
+			got = append(got, "POST hash")
+		}
+		mustWrite(w, `{"data": {"user": {"login": "gopher"}}}`)
+	})
+
+	params := "extensions=" + url.QueryEscape(`{"persistedQuery":{"sha256Hash":"`+hash+`","version":1}}`) +
+		"&variables=" + url.QueryEscape(`{"login":"gopher"}`)
+	for _, tc := range []struct {
+		name         string
+		maxURLLength int
+		want         []string
+	}{
+		{
+			name: "get",
+			want: []string{"GET " + params, "POST query", "GET " + params},
+		},
+		{
+			name:         "too long",
+			maxURLLength: 100,
+			want:         []string{"POST hash", "POST hash"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, stored = nil, false
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+				WithPersistedQueriesGET(tc.maxURLLength)
+			for i := 0; i < 2; i++ {
+				var q userQuery
+				err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, map[string]interface{}{"login": "gopher"})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got requests: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+
+	// Mutations are never sent with GET.
+	got, stored = nil, true
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithPersistedQueriesGET(0)
+	var m userQuery
+	if err := client.Mutate(context.Background(), graphql.ManualRequest{Query: "mutation{user{login}}", Result: &m}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"POST hash"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got requests: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_WithPersistedQueryManifest(t *testing.T) {
+	manifest, err := graphql.LoadPersistedQueryManifest(strings.NewReader(`{
+		"format": "apollo-persisted-query-manifest",
+		"version": 1,
+		"operations": [
+			{"id": "abc123", "name": "GetViewer", "type": "query", "body": "query GetViewer{viewer{login}}"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := mustRead(req.Body), `{"operationName":"GetViewer","extensions":{"persistedQuery":{"sha256Hash":"abc123","version":1}}}`+"\n"; got != want {
+			t.Errorf("got body: %v, want: %v", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithPersistedQueryManifest(manifest)
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	err = client.Query(context.Background(), graphql.ManualRequest{Query: "query GetViewer { viewer { login } }", Result: &q}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Viewer.Login != "gopher" {
+		t.Errorf("got login: %q, want: %q", q.Viewer.Login, "gopher")
+	}
+
+	err = client.Query(context.Background(), graphql.ManualRequest{Query: "query GetRepository { repository { name } }", Result: &q}, nil)
+	if e, ok := err.(*graphql.OperationNotInManifestError); !ok || e.OperationName != "GetRepository" {
+		t.Errorf("got error: %v, want: *OperationNotInManifestError for GetRepository", err)
+	}
+}
+
+func TestLoadPersistedQueryManifest_plain(t *testing.T) {
+	const query = "{viewer{login}}"
+	sum := sha256.Sum256([]byte(query))
+	manifest, err := graphql.LoadPersistedQueryManifest(strings.NewReader(`{"Viewer": "` + hex.EncodeToString(sum[:]) + `"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithPersistedQueryManifest(manifest)
+
+	// Anonymous operations are found by the hash of their document.
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	err = client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{name}}", Result: &q}, nil)
+	if got, want := err, "anonymous operation is not in the persisted query manifest"; got == nil || got.Error() != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+}
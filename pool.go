@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	"container/list"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientPoolConfig configures a ClientPool.
+type ClientPoolConfig struct {
+	// MaxClients caps the number of clients kept in the pool.
+	// When exceeded, the least recently used client is evicted.
+	//
+	// Defaults to 0, meaning unlimited.
+	MaxClients int
+
+	// IdleTimeout evicts clients that haven't been requested from the pool for this long.
+	//
+	// Defaults to 0, meaning clients are never evicted for being idle.
+	IdleTimeout time.Duration
+}
+
+// ClientPool hands out clients for many tenant-specific GraphQL endpoints.
+// Clients are keyed by URL and headers, and derived from a base client,
+// so that they all share the base client's HTTP transport and connections.
+//
+// A ClientPool is safe for concurrent use.
+type ClientPool struct {
+	base   *Client
+	config ClientPoolConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // Elements hold *poolEntry values.
+	lru     list.List                // Most recently used at the front.
+}
+
+type poolEntry struct {
+	key      string
+	client   *Client
+	lastUsed time.Time
+}
+
+// NewClientPool creates a pool of clients derived from base.
+func NewClientPool(base *Client, config ClientPoolConfig) *ClientPool {
+	return &ClientPool{
+		base:    base,
+		config:  config,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the client for the given URL and headers, creating it if needed.
+// The headers are added to the base client's default headers.
+//
+// Evicted clients keep working, but are no longer shared.
+func (p *ClientPool) Get(url string, header http.Header) *Client {
+	key := poolKey(url, header)
+	now := p.now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.evictIdle(now)
+
+	if e, ok := p.entries[key]; ok {
+		entry := e.Value.(*poolEntry)
+		entry.lastUsed = now
+		p.lru.MoveToFront(e)
+		return entry.client
+	}
+
+	entry := &poolEntry{
+		key:      key,
+		client:   p.base.WithURL(url).WithHeaders(header),
+		lastUsed: now,
+	}
+	p.entries[key] = p.lru.PushFront(entry)
+	for p.config.MaxClients > 0 && p.lru.Len() > p.config.MaxClients {
+		p.remove(p.lru.Back())
+	}
+	return entry.client
+}
+
+// Len returns the number of clients in the pool.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.evictIdle(p.now())
+	return p.lru.Len()
+}
+
+// evictIdle removes the clients that have been idle for longer than IdleTimeout.
+// p.mu must be held.
+func (p *ClientPool) evictIdle(now time.Time) {
+	if p.config.IdleTimeout <= 0 {
+		return
+	}
+	for e := p.lru.Back(); e != nil && now.Sub(e.Value.(*poolEntry).lastUsed) > p.config.IdleTimeout; e = p.lru.Back() {
+		p.remove(e)
+	}
+}
+
+// remove removes e from the pool. p.mu must be held.
+func (p *ClientPool) remove(e *list.Element) {
+	p.lru.Remove(e)
+	delete(p.entries, e.Value.(*poolEntry).key)
+}
+
+// poolKey builds a key that identifies url and header, independently of header key order and case.
+func poolKey(url string, header http.Header) string {
+	canonical := make(http.Header, len(header))
+	keys := make([]string, 0, len(header))
+	for key, values := range header {
+		key = http.CanonicalHeaderKey(key)
+		canonical[key] = values
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(url)
+	for _, key := range keys {
+		b.WriteString("\n")
+		b.WriteString(key)
+		for _, value := range canonical[key] {
+			b.WriteString("\x00")
+			b.WriteString(value)
+		}
+	}
+	return b.String()
+}
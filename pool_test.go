@@ -0,0 +1,51 @@
+package graphql
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClientPool(t *testing.T) {
+	transport := &http.Transport{}
+	base := NewClient("/graphql", &http.Client{Transport: transport})
+	base.SetHeader("X-Base", "base")
+
+	now := time.Unix(0, 0)
+	pool := NewClientPool(base, ClientPoolConfig{MaxClients: 2, IdleTimeout: time.Minute})
+	pool.now = func() time.Time { return now }
+
+	a := pool.Get("https://a.example/graphql", http.Header{"X-Tenant": {"a"}, "X-Region": {"eu"}})
+	if got := pool.Get("https://a.example/graphql", http.Header{"x-region": {"eu"}, "x-tenant": {"a"}}); got != a {
+		t.Error("got a new client for the same URL and headers, want the pooled one")
+	}
+	if a.httpClient.Transport != transport {
+		t.Error("pooled client doesn't share the base transport")
+	}
+	if got, want := a.Header().Get("X-Tenant"), "a"; got != want {
+		t.Errorf("got X-Tenant: %q, want: %q", got, want)
+	}
+	if got, want := a.Header().Get("X-Base"), "base"; got != want {
+		t.Errorf("got X-Base: %q, want: %q", got, want)
+	}
+	if base.Header().Get("X-Tenant") != "" {
+		t.Error("deriving a pooled client modified the base client")
+	}
+
+	// Exceeding MaxClients evicts the least recently used client.
+	b := pool.Get("https://b.example/graphql", nil)
+	pool.Get("https://a.example/graphql", http.Header{"X-Tenant": {"a"}, "X-Region": {"eu"}})
+	pool.Get("https://c.example/graphql", nil)
+	if got, want := pool.Len(), 2; got != want {
+		t.Fatalf("got %d clients, want: %d", got, want)
+	}
+	if pool.Get("https://b.example/graphql", nil) == b {
+		t.Error("got evicted client, want a new one")
+	}
+
+	// Idle clients are evicted.
+	now = now.Add(2 * time.Minute)
+	if got, want := pool.Len(), 0; got != want {
+		t.Errorf("got %d clients after idle timeout, want: %d", got, want)
+	}
+}
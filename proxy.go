@@ -0,0 +1,141 @@
+package graphql
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyConfig describes the HTTP proxy used to reach the GraphQL server.
+type ProxyConfig struct {
+	// URL is the proxy URL, e.g. "http://proxy.internal:3128".
+	// If empty, the proxy is taken from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	URL string
+
+	// Username and Password authenticate against the proxy.
+	// They take precedence over credentials embedded in URL.
+	Username string
+	Password string
+
+	// NoProxy is a comma-separated list of hosts that are reached directly, in addition to
+	// those of the NO_PROXY environment variable if URL is empty,
+	// with the same syntax as the NO_PROXY environment variable:
+	// "*" matches every host, "example.com" and ".example.com" match example.com and its subdomains,
+	// "10.0.0.0/8" matches IP addresses in a range, and an optional ":port" restricts a match to that port.
+	NoProxy string
+}
+
+// proxyFromEnvironment returns the proxy of requests when ProxyConfig.URL is empty.
+// It is a variable so that tests can replace it, as http.ProxyFromEnvironment reads the environment only once.
+var proxyFromEnvironment = http.ProxyFromEnvironment
+
+// proxyOverrideKey is the context key of the proxy URL set by WithRequestProxy.
+type proxyOverrideKey struct{}
+
+// WithProxy returns a copy of the client whose transport uses the given proxy settings.
+// The proxy can then be overridden per request with WithRequestProxy.
+func (c *Client) WithProxy(config ProxyConfig) (*Client, error) {
+	var proxyURL *url.URL
+	if config.URL != "" {
+		u, err := url.Parse(config.URL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy URL: %v", err)
+		}
+		if config.Username != "" || config.Password != "" {
+			u.User = url.UserPassword(config.Username, config.Password)
+		}
+		proxyURL = u
+	}
+	noProxy := parseNoProxy(config.NoProxy)
+
+	return c.withTransport(func(t *http.Transport) error {
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			if u, ok := req.Context().Value(proxyOverrideKey{}).(*url.URL); ok {
+				return u, nil
+			}
+			if noProxy.match(req.URL) {
+				return nil, nil
+			}
+			if proxyURL == nil {
+				return proxyFromEnvironment(req)
+			}
+			return proxyURL, nil
+		}
+		return nil
+	})
+}
+
+// noProxyList is a parsed NO_PROXY value.
+type noProxyList []noProxyEntry
+
+type noProxyEntry struct {
+	all    bool       // "*"
+	ipNet  *net.IPNet // CIDR range
+	ip     net.IP     // single IP address
+	domain string     // domain name, matching subdomains too
+	port   string     // optional port restriction
+}
+
+func parseNoProxy(s string) noProxyList {
+	var list noProxyList
+	for _, p := range strings.Split(s, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		switch {
+		case p == "":
+			continue
+		case p == "*":
+			list = append(list, noProxyEntry{all: true})
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(p); err == nil {
+			list = append(list, noProxyEntry{ipNet: ipNet})
+			continue
+		}
+		var e noProxyEntry
+		if host, port, err := net.SplitHostPort(p); err == nil {
+			p, e.port = host, port
+		}
+		if ip := net.ParseIP(p); ip != nil {
+			e.ip = ip
+		} else {
+			e.domain = strings.TrimPrefix(strings.TrimPrefix(p, "*"), ".")
+		}
+		list = append(list, e)
+	}
+	return list
+}
+
+// match reports whether u should be reached without a proxy.
+func (l noProxyList) match(u *url.URL) bool {
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	ip := net.ParseIP(host)
+	for _, e := range l {
+		switch {
+		case e.all:
+			return true
+		case e.port != "" && e.port != port:
+			continue
+		case e.ipNet != nil:
+			if ip != nil && e.ipNet.Contains(ip) {
+				return true
+			}
+		case e.ip != nil:
+			if e.ip.Equal(ip) {
+				return true
+			}
+		case host == e.domain || strings.HasSuffix(host, "."+e.domain):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNoProxyList_match(t *testing.T) {
+	list := parseNoProxy("localhost, .internal.example.com,10.0.0.0/8, 192.168.1.1, api.example.org:8443")
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"http://localhost/graphql", true},
+		{"http://internal.example.com/graphql", true},
+		{"https://svc.internal.example.com/graphql", true},
+		{"https://example.com/graphql", false},
+		{"http://10.1.2.3:8080/graphql", true},
+		{"http://11.1.2.3/graphql", false},
+		{"http://192.168.1.1/graphql", true},
+		{"https://api.example.org:8443/graphql", true},
+		{"https://api.example.org/graphql", false},
+	}
+	for _, tc := range tests {
+		u, err := url.Parse(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := list.match(u); got != tc.want {
+			t.Errorf("match(%q): got %v, want %v", tc.in, got, tc.want)
+		}
+	}
+	if u, _ := url.Parse("https://anything.test"); !parseNoProxy("*").match(u) {
+		t.Error(`"*" should match every host`)
+	}
+}
+
+func TestClient_WithProxy(t *testing.T) {
+	newProxy := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if got, want := req.URL.String(), "http://graphql.example/graphql"; got != want {
+				t.Errorf("%s: got proxied URL %q, want %q", name, got, want)
+			}
+			auth := req.Header.Get("Proxy-Authorization")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"data": {"proxy": "`+name+`", "auth": "`+auth+`"}}`)
+		}))
+	}
+	defaultProxy, tenantProxy := newProxy("default"), newProxy("tenant")
+	defer defaultProxy.Close()
+	defer tenantProxy.Close()
+
+	client, err := NewClient("http://graphql.example/graphql", nil).WithProxy(ProxyConfig{
+		URL:      defaultProxy.URL,
+		Username: "user",
+		Password: "p@ss",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var q struct {
+		Proxy string
+		Auth  string
+	}
+	err = client.Query(context.Background(), ManualRequest{Query: "{proxy}", Result: &q}, nil)
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:p@ss"))
+	if q.Proxy != "default" || q.Auth != wantAuth {
+		t.Errorf("got proxy %q with auth %q, want %q with auth %q", q.Proxy, q.Auth, "default", wantAuth)
+	}
+
+	tenantURL, _ := url.Parse(tenantProxy.URL)
+	err = client.Query(context.Background(), ManualRequest{Query: "{proxy}", Result: &q}, nil, WithRequestProxy(tenantURL))
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+	if q.Proxy != "tenant" {
+		t.Errorf("got proxy %q, want %q", q.Proxy, "tenant")
+	}
+}
+
+func TestClient_WithProxy_environmentNoProxy(t *testing.T) {
+	envProxy, _ := url.Parse("http://env-proxy.example:3128")
+	defer func(f func(*http.Request) (*url.URL, error)) { proxyFromEnvironment = f }(proxyFromEnvironment)
+	proxyFromEnvironment = func(*http.Request) (*url.URL, error) { return envProxy, nil }
+
+	client, err := NewClient("http://graphql.example/graphql", nil).WithProxy(ProxyConfig{NoProxy: "internal.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := client.httpClient.Transport.(*http.Transport).Proxy
+	for _, tc := range []struct {
+		url  string
+		want *url.URL
+	}{
+		{"http://graphql.example/graphql", envProxy},
+		{"http://api.internal.example/graphql", nil},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, tc.url, nil)
+		got, err := proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: got proxy %v, want %v", tc.url, got, tc.want)
+		}
+	}
+}
@@ -3,55 +3,109 @@ package graphql
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/darrensapalo/go-graphql-client/ident"
 )
 
-func constructQuery(v interface{}, variables map[string]interface{}, name string) string {
-	query := query(v)
-	if len(variables) > 0 {
-		return "query " + name + "(" + queryArguments(variables) + ")" + query
-	}
+// ConstructQuery returns the query document that the client sends for v, a pointer to a struct
+// describing the selection set with graphql tags, and variables, with the given operation name, if any.
+// It is useful to snapshot-test documents, to register persisted queries ahead of time,
+// and to debug tags without executing requests.
+// It returns an error if v has fields of recursive types without the depth option, e.g. `graphql:"replies,depth=3"`.
+func ConstructQuery(v interface{}, variables map[string]interface{}, name string) (string, error) {
+	return constructQuery(v, variables, name)
+}
 
-	if name != "" {
-		return "query " + name + query
-	}
-	return query
+// ConstructMutation is like ConstructQuery, for a mutation document.
+func ConstructMutation(v interface{}, variables map[string]interface{}, name string) (string, error) {
+	return constructMutation(v, variables, name)
 }
 
-func constructMutation(v interface{}, variables map[string]interface{}, name string) string {
-	query := query(v)
-	if len(variables) > 0 {
-		return "mutation " + name + "(" + queryArguments(variables) + ")" + query
-	}
-	if name != "" {
-		return "mutation " + name + query
-	}
-	return "mutation" + query
+// ConstructSubscription is like ConstructQuery, for a subscription document.
+func ConstructSubscription(v interface{}, variables map[string]interface{}, name string) (string, error) {
+	return constructSubscription(v, variables, name)
+}
+
+// ConstructQuery is like the ConstructQuery function, with the configuration of the client:
+// the variable types of WithVariableTypes, the union types of WithUnionTypes, WithNamedFragments
+// and WithMinifiedDocuments.
+func (c *Client) ConstructQuery(v interface{}, variables map[string]interface{}, name string) (string, error) {
+	return c.minifiedDocument(c.constructOperation(queryOperation, v, variables, name))
+}
+
+// ConstructMutation is like ConstructQuery, for a mutation document.
+func (c *Client) ConstructMutation(v interface{}, variables map[string]interface{}, name string) (string, error) {
+	return c.minifiedDocument(c.constructOperation(mutationOperation, v, variables, name))
+}
+
+func constructQuery(v interface{}, variables map[string]interface{}, name string) (string, error) {
+	return constructOperation("query", v, variables, name, nil)
+}
+
+func constructMutation(v interface{}, variables map[string]interface{}, name string) (string, error) {
+	return constructOperation("mutation", v, variables, name, nil)
+}
+
+func constructSubscription(v interface{}, variables map[string]interface{}, name string) (string, error) {
+	return constructOperation("subscription", v, variables, name, nil)
 }
 
-func constructSubscription(v interface{}, variables map[string]interface{}, name string) string {
-	query := query(v)
-	if len(variables) > 0 {
-		return "subscription " + name + "(" + queryArguments(variables) + ")" + query
+// constructOperation constructs the document of an operation of the given keyword, e.g. "mutation",
+// with the configuration of c, if not nil: variables are declared with the types of WithVariableTypes,
+// or inferred from their Go types, interface fields select the fragments of WithUnionTypes,
+// and repeated fragments are named with WithNamedFragments.
+// Anonymous queries without variables are written in the shorthand form, without the keyword.
+func constructOperation(keyword string, v interface{}, variables map[string]interface{}, name string, c *Client) (string, error) {
+	var types map[reflect.Type]string
+	var unions *unionTypes
+	var gqlgen bool
+	if c != nil {
+		types, unions, gqlgen = c.variableTypes, c.unionTypes, c.gqlgenModels
 	}
-	if name != "" {
-		return "subscription " + name + query
+	query, err := query(v, unions, gqlgen)
+	if err != nil {
+		return "", err
 	}
-	return "subscription" + query
+	var fragments string
+	if c != nil && c.namedFragments {
+		query, fragments = nameFragments(query, reflect.TypeOf(v), unions, gqlgen)
+	}
+	// Variables that are all unset Optionals are left out, as if there were none.
+	switch definitions := variableDefinitions(variables, types); {
+	case definitions != "":
+		return keyword + " " + name + "(" + definitions + ")" + query + fragments, nil
+	case name != "":
+		return keyword + " " + name + query + fragments, nil
+	case keyword == "query":
+		return query + fragments, nil
+	}
+	return keyword + query + fragments, nil
 }
 
 // queryArguments constructs a minified arguments string for variables.
 //
 // E.g., map[string]interface{}{"a": Int(123), "b": NewBoolean(true)} -> "$a:Int!$b:Boolean".
 func queryArguments(variables map[string]interface{}) string {
-	// Sort keys in order to produce deterministic output for testing purposes.
-	// TODO: If tests can be made to work with non-deterministic output, then no need to sort.
+	return variableDefinitions(variables, nil)
+}
+
+// variableDefinitions is like queryArguments, with the types mapped by types, if any.
+func variableDefinitions(variables map[string]interface{}, types map[reflect.Type]string) string {
+	// Sort keys in order to produce deterministic output, so that the documents of the same operation,
+	// and their persisted query hashes and cache keys, don't depend on map iteration order.
 	keys := make([]string, 0, len(variables))
-	for k := range variables {
+	for k, v := range variables {
+		if o, ok := v.(Optional); ok && !o.IsSet() {
+			// Unset, so left out.
+			continue
+		}
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
@@ -61,7 +115,16 @@ func queryArguments(variables map[string]interface{}) string {
 		io.WriteString(&buf, "$")
 		io.WriteString(&buf, k)
 		io.WriteString(&buf, ":")
-		writeArgumentType(&buf, reflect.TypeOf(variables[k]), true)
+		value, required := variables[k], true
+		if o, ok := value.(Optional); ok {
+			value, required = o.value, false
+		}
+		if null, ok := value.(NullValue); ok {
+			// Explicit null, declared with its nullable type. E.g., "String".
+			io.WriteString(&buf, null.Type)
+			continue
+		}
+		writeArgumentType(&buf, reflect.TypeOf(value), required, types)
 		// Don't insert a comma here.
 		// Commas in GraphQL are insignificant, and we want minified output.
 		// See https://facebook.github.io/graphql/October2016/#sec-Insignificant-Commas.
@@ -72,26 +135,45 @@ func queryArguments(variables map[string]interface{}) string {
 // writeArgumentType writes a minified GraphQL type for t to w.
 // value indicates whether t is a value (required) type or pointer (optional) type.
 // If value is true, then "!" is written at the end of t.
-func writeArgumentType(w io.Writer, t reflect.Type, value bool) {
+// Named types are looked up in types, if any, before being inferred, see variableTypeName.
+func writeArgumentType(w io.Writer, t reflect.Type, value bool, types map[reflect.Type]string) {
+	if name, ok := types[t]; ok {
+		io.WriteString(w, name)
+		if value {
+			io.WriteString(w, "!")
+		}
+		return
+	}
+	if t == fileType {
+		// Files are uploaded. They are required even though they are pointers.
+		io.WriteString(w, "Upload!")
+		return
+	}
 	if t.Kind() == reflect.Ptr {
 		// Pointer is an optional type, so no "!" at the end of the pointer's underlying type.
-		writeArgumentType(w, t.Elem(), false)
+		writeArgumentType(w, t.Elem(), false, types)
 		return
 	}
 
-	switch t.Kind() {
-	case reflect.Slice, reflect.Array:
+	scalar, registered := lookupScalar(t)
+	switch {
+	case t.Implements(graphQLTyperType):
+		// Named type declared by the type itself. E.g., "DateTime".
+		io.WriteString(w, reflect.Zero(t).Interface().(GraphQLTyper).GraphQLType())
+	case registered:
+		// Registered custom scalar. E.g., "URI".
+		io.WriteString(w, scalar.Name)
+	case isUUID(t):
+		// UUID, including as [16]byte.
+		io.WriteString(w, "UUID")
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
 		// List. E.g., "[Int]".
 		io.WriteString(w, "[")
-		writeArgumentType(w, t.Elem(), true)
+		writeArgumentType(w, t.Elem(), true, types)
 		io.WriteString(w, "]")
 	default:
 		// Named type. E.g., "Int".
-		name := t.Name()
-		if name == "string" { // HACK: Workaround for https://github.com/shurcooL/githubv4/issues/12.
-			name = "ID"
-		}
-		io.WriteString(w, name)
+		io.WriteString(w, variableTypeName(t))
 	}
 
 	if value {
@@ -100,45 +182,116 @@ func writeArgumentType(w io.Writer, t reflect.Type, value bool) {
 	}
 }
 
-// query uses writeQuery to recursively construct
-// a minified query string from the provided struct v.
+// queryCache maps the types of queries to their query strings, as constructed by query,
+// so that the reflection walk is done once per type.
+var queryCache sync.Map // map[queryKey]string
+
+// queryKey is the key of the query strings of types in caches.
+type queryKey struct {
+	t reflect.Type
+	// gqlgen is whether the type is written as a gqlgen model, see WithGQLGenModels.
+	gqlgen bool
+}
+
+// query uses a queryWriter to recursively construct
+// a minified query string from the provided struct v, with the union types in unions, if any,
+// and the conventions of gqlgen models if gqlgen is true.
+// Query strings are cached per type of v, and per unions for the types with interface fields of union types.
 //
 // E.g., struct{Foo Int, BarBaz *Boolean} -> "{foo,barBaz}".
-func query(v interface{}) string {
+func query(v interface{}, unions *unionTypes, gqlgen bool) (string, error) {
+	t := reflect.TypeOf(v)
+	key := queryKey{t: t, gqlgen: gqlgen}
+	cache := &queryCache
+	if unions.in(t) {
+		cache = &unions.queries
+	}
+	if q, ok := cache.Load(key); ok {
+		return q.(string), nil
+	}
 	var buf bytes.Buffer
-	writeQuery(&buf, reflect.TypeOf(v), false)
-	return buf.String()
+	qw := &queryWriter{unions: unions, gqlgen: gqlgen}
+	qw.write(&buf, t, false)
+	if qw.err != nil {
+		return "", qw.err
+	}
+	q := buf.String()
+	cache.Store(key, q)
+	return q, nil
+}
+
+// queryWriter writes minified queries for Go types, with the union types in unions, if any.
+type queryWriter struct {
+	unions *unionTypes
+
+	// gqlgen writes structs as gqlgen models, see WithGQLGenModels.
+	gqlgen bool
+
+	// stack holds the struct types being written, to detect recursive types.
+	stack []reflect.Type
+
+	// err is the first error writing a query, e.g. for a recursive type without depth.
+	err error
 }
 
-// writeQuery writes a minified query for t to w.
+// write writes a minified query for t to w.
 // If inline is true, the struct fields of t are inlined into parent struct.
-func writeQuery(w io.Writer, t reflect.Type, inline bool) {
+// Interface types of unions are written as a selection of __typename and the fragments of their types.
+// Fields of recursive types are written up to their depth option, e.g. `graphql:"replies,depth=3"`;
+// without it, an error is recorded instead of recursing forever.
+func (qw *queryWriter) write(w io.Writer, t reflect.Type, inline bool) {
 	switch t.Kind() {
-	case reflect.Ptr, reflect.Slice:
-		writeQuery(w, t.Elem(), false)
+	case reflect.Ptr:
+		// Embedded struct pointers are inlined as embedded structs are.
+		qw.write(w, t.Elem(), inline)
+	case reflect.Slice, reflect.Array:
+		qw.write(w, t.Elem(), false)
+	case reflect.Interface:
+		if qw.unions.lookup(t) != nil || qw.unions.fallback(t) != nil {
+			qw.writeUnion(w, t)
+		}
 	case reflect.Struct:
 		// If the type implements json.Unmarshaler, it's a scalar. Don't expand it.
-		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+		if reflect.PtrTo(t).Implements(jsonUnmarshaler) || qw.gqlgen && isGQLGenScalar(t) {
 			return
 		}
+		qw.stack = append(qw.stack, t)
+		defer func() { qw.stack = qw.stack[:len(qw.stack)-1] }()
 		if !inline {
 			io.WriteString(w, "{")
 		}
+		first := true
 		for i := 0; i < t.NumField(); i++ {
-			if i != 0 {
-				io.WriteString(w, ",")
-			}
 			f := t.Field(i)
 			value, ok := f.Tag.Lookup("graphql")
-			inlineField := f.Anonymous && !ok
-			if !inlineField {
-				if ok {
-					io.WriteString(w, value)
-				} else {
-					io.WriteString(w, ident.ParseMixedCaps(f.Name).ToLowerCamelCase())
+			if ok && value == excludeTag || !ok && qw.gqlgen && qw.excludeGQLGen(f) || !qw.expand(t, f) {
+				continue
+			}
+			inlineField := f.Anonymous && !ok && !qw.hasJSONName(f)
+			if inlineField {
+				// Fields of embedded structs are flattened into the selection set, if they select any.
+				var fields bytes.Buffer
+				qw.write(&fields, f.Type, true)
+				if fields.Len() == 0 {
+					continue
+				}
+				if !first {
+					io.WriteString(w, ",")
 				}
+				first = false
+				fields.WriteTo(w)
+				continue
 			}
-			writeQuery(w, f.Type, inlineField)
+			if !first {
+				io.WriteString(w, ",")
+			}
+			first = false
+			if ok {
+				io.WriteString(w, tagSelection(value))
+			} else {
+				io.WriteString(w, qw.fieldName(f))
+			}
+			qw.write(w, f.Type, false)
 		}
 		if !inline {
 			io.WriteString(w, "}")
@@ -146,4 +299,98 @@ func writeQuery(w io.Writer, t reflect.Type, inline bool) {
 	}
 }
 
+// fieldName returns the name of the field f without graphql tag: the name of its json tag for gqlgen models,
+// or its name in lower camel case.
+func (qw *queryWriter) fieldName(f reflect.StructField) string {
+	if name, ok := jsonName(f); ok && qw.gqlgen {
+		return name
+	}
+	return ident.ParseMixedCaps(f.Name).ToLowerCamelCase()
+}
+
+// hasJSONName reports whether the field f of a gqlgen model has a json tag name, which encoding/json
+// decodes embedded structs by rather than inlining them.
+func (qw *queryWriter) hasJSONName(f reflect.StructField) bool {
+	_, ok := jsonName(f)
+	return ok && qw.gqlgen
+}
+
+// expand reports whether the field f of the struct type t is to be written. Fields of struct types
+// being written are written as long as these types are nested no more than the depth option of f allows.
+// An error is recorded for such fields without depth option, unless they are fields of gqlgen models,
+// which are left out.
+func (qw *queryWriter) expand(t reflect.Type, f reflect.StructField) bool {
+	elem := f.Type
+	for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array {
+		elem = elem.Elem()
+	}
+	nested := 0
+	for _, parent := range qw.stack {
+		if parent == elem {
+			nested++
+		}
+	}
+	if nested == 0 {
+		return true
+	}
+	_, _, depth := tagOptions(f.Tag.Get("graphql"))
+	if depth == 0 {
+		if qw.err == nil && !qw.gqlgen {
+			qw.err = fmt.Errorf("graphql: field %s of %v has recursive type %v; limit its depth with the depth option, e.g. `graphql:\"%s,depth=3\"`",
+				f.Name, t, elem, ident.ParseMixedCaps(f.Name).ToLowerCamelCase())
+		}
+		return false
+	}
+	return nested <= depth
+}
+
+// excludeTag is the graphql tag of fields excluded from queries, e.g. fields computed by the application.
+const excludeTag = "-"
+
+// Options of graphql tags, following the field selection, e.g. `graphql:"login,required"`.
+const (
+	// requiredOption marks fields that must not be null.
+	requiredOption = "required"
+
+	// depthOption limits the depth of fields of recursive types, e.g. `graphql:"replies,depth=3"`.
+	depthOption = "depth="
+)
+
+// tagOptions returns the field selection in the graphql tag value, and its options.
+func tagOptions(tag string) (selection string, required bool, depth int) {
+	selection = tag
+	for {
+		i := strings.LastIndex(selection, ",")
+		if i < 0 {
+			return selection, required, depth
+		}
+		option := strings.TrimSpace(selection[i+1:])
+		switch {
+		case option == requiredOption:
+			required = true
+		case strings.HasPrefix(option, depthOption):
+			n, err := strconv.Atoi(option[len(depthOption):])
+			if err != nil || n < 0 {
+				return selection, required, depth
+			}
+			depth = n
+		default:
+			return selection, required, depth
+		}
+		selection = selection[:i]
+	}
+}
+
+// tagSelection returns the field selection in the graphql tag value, without its options.
+func tagSelection(tag string) string {
+	selection, _, _ := tagOptions(tag)
+	return selection
+}
+
+// isConditional reports whether the field selection has an @include or @skip directive,
+// e.g. "details @include(if: $withDetails)", in which case it may be missing from responses.
+func isConditional(selection string) bool {
+	return strings.Contains(selection, "@include(") || strings.Contains(selection, "@skip(")
+}
+
 var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
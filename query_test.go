@@ -2,6 +2,9 @@ package graphql
 
 import (
 	"net/url"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -219,6 +222,31 @@ func TestConstructQuery(t *testing.T) {
 			}(),
 			want: `{actor{login,avatarUrl,url},createdAt,... on IssueComment{body},currentTitle,previousTitle,label{name,color}}`,
 		},
+		// Embedded struct pointers and nested embedded structs are flattened too,
+		// and embedded structs without selected fields are left out.
+		{
+			inV: func() interface{} {
+				type node struct {
+					ID ID
+				}
+				type timestamps struct {
+					node
+					CreatedAt DateTime
+					UpdatedAt DateTime
+				}
+				type local struct {
+					Draft bool `graphql:"-"`
+				}
+				return struct {
+					Issue struct {
+						*timestamps
+						local
+						Title String
+					}
+				}{}
+			}(),
+			want: `{issue{id,createdAt,updatedAt,title}}`,
+		},
 		{
 			inV: struct {
 				Viewer struct {
@@ -230,9 +258,32 @@ func TestConstructQuery(t *testing.T) {
 			}{},
 			want: `{viewer{login,createdAt,id,databaseId}}`,
 		},
+		{
+			inV: struct {
+				Viewer struct {
+					Login String `graphql:"login,required"`
+					Name  String `graphql:"fullName:name,required"`
+				} `graphql:"viewer,required"`
+			}{},
+			want: `{viewer{login,fullName:name}}`,
+		},
+		{
+			inV: struct {
+				Cached bool `graphql:"-"`
+				Viewer struct {
+					Login   String
+					Display string `graphql:"-"`
+					Name    String
+				}
+			}{},
+			want: `{viewer{login,name}}`,
+		},
 	}
 	for _, tc := range tests {
-		got := constructQuery(tc.inV, tc.inVariables, tc.name)
+		got, err := constructQuery(tc.inV, tc.inVariables, tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
 		if got != tc.want {
 			t.Errorf("\ngot:  %q\nwant: %q\n", got, tc.want)
 		}
@@ -267,7 +318,10 @@ func TestConstructMutation(t *testing.T) {
 		},
 	}
 	for _, tc := range tests {
-		got := constructMutation(tc.inV, tc.inVariables, "")
+		got, err := constructMutation(tc.inV, tc.inVariables, "")
+		if err != nil {
+			t.Fatal(err)
+		}
 		if got != tc.want {
 			t.Errorf("\ngot:  %q\nwant: %q\n", got, tc.want)
 		}
@@ -500,13 +554,84 @@ func TestConstructSubscription(t *testing.T) {
 		},
 	}
 	for _, tc := range tests {
-		got := constructSubscription(tc.inV, tc.inVariables, tc.name)
+		got, err := constructSubscription(tc.inV, tc.inVariables, tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
 		if got != tc.want {
 			t.Errorf("\ngot:  %q\nwant: %q\n", got, tc.want)
 		}
 	}
 }
 
+type comment struct {
+	Body    String
+	Replies []comment `graphql:"replies(first: 10),depth=2"`
+}
+
+type thread struct {
+	Body    String
+	Replies []thread
+}
+
+func TestConstructQuery_recursive(t *testing.T) {
+	var q struct {
+		Comments []comment `graphql:"comments(first: 10)"`
+	}
+	got, err := constructQuery(&q, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{comments(first: 10){body,replies(first: 10){body,replies(first: 10){body}}}}`; got != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", got, want)
+	}
+
+	var q2 struct {
+		Threads []thread
+	}
+	if _, err := constructQuery(&q2, nil, ""); err == nil || !strings.Contains(err.Error(), "recursive type") {
+		t.Errorf("got error: %v, want recursive type error", err)
+	}
+}
+
+func TestQuery_cached(t *testing.T) {
+	type q struct {
+		Viewer struct {
+			Login String
+		}
+	}
+	want := "{viewer{login}}"
+	for i := 0; i < 2; i++ {
+		if got, err := query(&q{}, nil, false); err != nil || got != want {
+			t.Errorf("got: %q, %v, want: %q", got, err, want)
+		}
+	}
+	if got, ok := queryCache.Load(queryKey{t: reflect.TypeOf(&q{})}); !ok || got != want {
+		t.Errorf("got cached query: %v, %v, want: %q", got, ok, want)
+	}
+}
+
+func BenchmarkConstructQuery(b *testing.B) {
+	var q struct {
+		Repository struct {
+			Issues struct {
+				Nodes []struct {
+					Number Int
+					Title  String
+					Author struct {
+						Login String
+					}
+				}
+			} `graphql:"issues(first: $first)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	variables := map[string]interface{}{"owner": String("o"), "name": String("n"), "first": Int(10)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		constructQuery(&q, variables, "")
+	}
+}
+
 func TestQueryArguments(t *testing.T) {
 	tests := []struct {
 		in   map[string]interface{}
@@ -549,6 +674,23 @@ func TestQueryArguments(t *testing.T) {
 			in:   map[string]interface{}{"ids": &[]ID{"someID", "anotherID"}},
 			want: `$ids:[ID!]`,
 		},
+		{
+			in: map[string]interface{}{
+				"file":     Upload{},
+				"optional": &Upload{},
+				"stdin":    os.Stdin,
+				"files":    []*os.File{os.Stdin},
+			},
+			want: `$file:Upload!$files:[Upload!]!$optional:Upload$stdin:Upload!`,
+		},
+		{
+			in:   map[string]interface{}{"first": 10, "ratio": NewFloat(0.5), "draft": false, "login": "gopher"},
+			want: `$draft:Boolean!$first:Int!$login:ID!$ratio:Float`,
+		},
+		{
+			in:   map[string]interface{}{"id": UUID{}, "optional": &UUID{}, "ids": []UUID{{}}},
+			want: `$id:UUID!$ids:[UUID!]!$optional:UUID`,
+		},
 	}
 	for i, tc := range tests {
 		got := queryArguments(tc.in)
@@ -558,6 +700,22 @@ func TestQueryArguments(t *testing.T) {
 	}
 }
 
+func TestVariableDefinitions(t *testing.T) {
+	types := map[reflect.Type]string{
+		reflect.TypeOf(DateTime{}): "Date",
+		reflect.TypeOf(""):         "String",
+	}
+	variables := map[string]interface{}{
+		"since": DateTime{},
+		"until": &DateTime{},
+		"query": "go",
+		"tags":  []string{"lang"},
+	}
+	if got, want := variableDefinitions(variables, types), `$query:String!$since:Date!$tags:[String!]!$until:Date`; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
 // Custom GraphQL types for testing.
 type (
 	// DateTime is an ISO-8601 encoded UTC date.
@@ -569,6 +727,11 @@ type (
 
 func (u *URI) UnmarshalJSON(data []byte) error { panic("mock implementation") }
 
+// UUID is a universally unique identifier, declaring its GraphQL type.
+type UUID [16]byte
+
+func (UUID) GraphQLType() string { return "UUID" }
+
 // IssueState represents the possible states of an issue.
 type IssueState string
 
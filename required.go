@@ -0,0 +1,189 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/darrensapalo/go-graphql-client/ident"
+)
+
+// RequiredFieldError is returned, as the error of a *DecodeError, when a field of the result of an operation
+// with the required option in its graphql tag, e.g. `graphql:"login,required"`, is null or missing in the data
+// of a response without errors.
+//
+// Fields of inline fragments, e.g. `graphql:"... on Droid"`, are not checked,
+// as they are missing from the data of objects of other types.
+type RequiredFieldError struct {
+	// Path is the path of the field in the data. Its elements are strings for field names and aliases,
+	// and ints for list indexes, as in GraphQLError.
+	Path []interface{}
+}
+
+// Error implements error interface.
+func (e *RequiredFieldError) Error() string {
+	var b strings.Builder
+	for _, elem := range e.Path {
+		switch elem := elem.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(elem) + "]")
+		default:
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+			fmt.Fprint(&b, elem)
+		}
+	}
+	return fmt.Sprintf("required field %s is null or missing", b.String())
+}
+
+// requiredTypes maps types to whether they have required fields, as reported by hasRequired.
+var requiredTypes sync.Map // map[reflect.Type]bool
+
+// hasRequired reports whether t has fields with the required option, directly or in nested types.
+func hasRequired(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if required, ok := requiredTypes.Load(t); ok {
+		return required.(bool)
+	}
+	required := findRequired(t, map[reflect.Type]bool{})
+	requiredTypes.Store(t, required)
+	return required
+}
+
+// findRequired reports whether t has fields with the required option, skipping the types in seen.
+func findRequired(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return findRequired(t.Elem(), seen)
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+			return false
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("graphql")
+			if _, required, _ := tagOptions(tag); required || findRequired(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkRequired checks that the fields of t with the required option are neither null nor missing in value,
+// the data of a response decoded from JSON. It returns a *RequiredFieldError for the first one that is.
+func checkRequired(t reflect.Type, value interface{}) error {
+	if err := checkRequiredValue(t, value, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkRequiredValue checks value, decoded from JSON, against t, at path in the data.
+func checkRequiredValue(t reflect.Type, value interface{}, path []interface{}) *RequiredFieldError {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return checkRequiredValue(t.Elem(), value, path)
+	case reflect.Slice, reflect.Array:
+		list, _ := value.([]interface{})
+		for i, item := range list {
+			if err := checkRequiredValue(t.Elem(), item, appendPath(path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+			return nil
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag, hasTag := f.Tag.Lookup("graphql")
+			name, hasName := jsonName(f)
+			if f.Anonymous && !hasName {
+				// Embedded structs are inlined by encoding/json, whether they are fragments or not.
+				if hasTag && strings.HasPrefix(strings.TrimSpace(tag), "...") {
+					continue
+				}
+				if err := checkRequiredValue(f.Type, value, path); err != nil {
+					return err
+				}
+				continue
+			}
+			if f.PkgPath != "" || name == "-" {
+				continue
+			}
+			key, fieldValue := lookupField(object, name)
+			missing := key == ""
+			if missing {
+				key = responseKey(f, tag, hasTag)
+			}
+			fieldPath := appendPath(path, key)
+			if fieldValue == nil {
+				// Fields excluded by @include or @skip are missing from the response, even if required.
+				if selection, required, _ := tagOptions(tag); required && !(missing && isConditional(selection)) {
+					return &RequiredFieldError{Path: fieldPath}
+				}
+				continue
+			}
+			if err := checkRequiredValue(f.Type, fieldValue, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonName returns the name of the field in JSON, and whether it is set by a json tag.
+// Without a json tag, it is the alias of the graphql tag, if any, or the name of the field, as for encoding/json.
+func jsonName(f reflect.StructField) (string, bool) {
+	if tag := f.Tag.Get("json"); tag != "" {
+		if name := strings.Split(tag, ",")[0]; name != "" {
+			return name, true
+		}
+	}
+	if alias, ok := graphqlAlias(f); ok {
+		return alias, false
+	}
+	return f.Name, false
+}
+
+// lookupField returns the key and value of the field name in object, matched case-insensitively
+// if there is no exact match, as encoding/json does. The key is "" if the field is missing.
+func lookupField(object map[string]interface{}, name string) (string, interface{}) {
+	if value, ok := object[name]; ok {
+		return name, value
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return key, value
+		}
+	}
+	return "", nil
+}
+
+// responseKey returns the key of the field f in the data of a response, as selected by queryWriter.write.
+func responseKey(f reflect.StructField, tag string, hasTag bool) string {
+	if !hasTag {
+		return ident.ParseMixedCaps(f.Name).ToLowerCamelCase()
+	}
+	key, _ := splitAlias(tagSelection(tag))
+	return key
+}
+
+// appendPath returns a copy of path with elem appended.
+func appendPath(path []interface{}, elem interface{}) []interface{} {
+	return append(path[:len(path):len(path)], elem)
+}
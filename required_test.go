@@ -0,0 +1,84 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_Query_required(t *testing.T) {
+	type repository struct {
+		Name        graphql.String  `graphql:"name,required"`
+		Description *graphql.String `graphql:"description"`
+	}
+	type query struct {
+		Viewer struct {
+			Login        graphql.String `graphql:"login,required"`
+			Repositories []repository
+		}
+	}
+	for _, tc := range []struct {
+		name     string
+		response string
+		wantPath []interface{} // Path of the missing field; nil if none.
+		wantErrs bool
+	}{
+		{
+			name:     "present",
+			response: `{"data": {"viewer": {"login": "gopher", "repositories": [{"name": "go", "description": null}]}}}`,
+		},
+		{
+			name:     "null",
+			response: `{"data": {"viewer": {"login": null, "repositories": []}}}`,
+			wantPath: []interface{}{"viewer", "login"},
+		},
+		{
+			name:     "missing in list",
+			response: `{"data": {"viewer": {"login": "gopher", "repositories": [{"name": "go"}, {"description": "?"}]}}}`,
+			wantPath: []interface{}{"viewer", "repositories", 1, "name"},
+		},
+		{
+			name:     "null with errors",
+			response: `{"data": {"viewer": {"login": null, "repositories": []}}, "errors": [{"message": "login unavailable", "path": ["viewer", "login"]}]}`,
+			wantErrs: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, tc.response)
+			})
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+			var q query
+			err := client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login,repositories{name,description}}}", Result: &q}, nil)
+			var requiredErr *graphql.RequiredFieldError
+			switch {
+			case tc.wantErrs:
+				if !errors.Is(err, graphql.ErrGraphQL) {
+					t.Errorf("got error: %v, want GraphQL errors", err)
+				}
+			case tc.wantPath == nil:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case !errors.Is(err, graphql.ErrDecode) || !errors.As(err, &requiredErr):
+				t.Errorf("got error: %v, want *RequiredFieldError", err)
+			case !reflect.DeepEqual(requiredErr.Path, tc.wantPath):
+				t.Errorf("got path: %v, want: %v", requiredErr.Path, tc.wantPath)
+			}
+		})
+	}
+}
+
+func TestRequiredFieldError(t *testing.T) {
+	err := &graphql.RequiredFieldError{Path: []interface{}{"viewer", "repositories", 1, "name"}}
+	if got, want := err.Error(), "required field viewer.repositories[1].name is null or missing"; got != want {
+		t.Errorf("got error: %q, want: %q", got, want)
+	}
+}
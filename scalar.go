@@ -1,5 +1,14 @@
 package graphql
 
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
 // Note: These custom types are meant to be used in queries for now.
 // But the plan is to switch to using native Go types (string, int, bool, time.Time, etc.).
 // See https://github.com/shurcooL/githubv4/issues/9 for details.
@@ -33,8 +42,29 @@ type (
 	// This type is most often used by GraphQL to represent free-form
 	// human-readable text.
 	String string
+
+	// JSON represents values of JSON scalars, such as Hasura's jsonb, as their
+	// raw JSON encoding, passed through verbatim in variables and results, as
+	// json.RawMessage is, without being interpreted. Results decoded generically,
+	// e.g. with decode hooks, are re-encoded, with the same meaning.
+	JSON json.RawMessage
+
+	// Bytes represents binary data, encoded as a base64 string in the
+	// standard alphabet, or the one set with Client.WithBytesEncoding.
+	// Values are decoded from either alphabet, padded or not.
+	Bytes []byte
+
+	// FlexID represents ID values as strings, decoded from either JSON
+	// strings or numbers, e.g. "42" or 42, for servers inconsistent about
+	// the encoding of IDs. It is encoded as a string, or as a number with
+	// Client.WithNumericIDs if it is an integer.
+	FlexID string
 )
 
+// NewBool is a helper to make a new *bool, e.g. for optional variables and the fields of input objects
+// of type *bool, which are declared as Boolean.
+func NewBool(v bool) *bool { return &v }
+
 // NewBoolean is a helper to make a new *Boolean.
 func NewBoolean(v Boolean) *Boolean { return &v }
 
@@ -49,3 +79,114 @@ func NewInt(v Int) *Int { return &v }
 
 // NewString is a helper to make a new *String.
 func NewString(v String) *String { return &v }
+
+// GraphQLType implements GraphQLTyper, declaring variables of type JSON as JSON;
+// map it to the name of the scalar of the server, e.g. "jsonb", with WithVariableTypes.
+func (JSON) GraphQLType() string { return "JSON" }
+
+// MarshalJSON returns j as the JSON encoding of j, or null if j is nil.
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if j == nil {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// UnmarshalJSON sets *j to a copy of data.
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[0:0], data...)
+	return nil
+}
+
+// WithBytesEncoding returns a copy of the client that encodes the Bytes values of variables with encoding,
+// e.g. base64.URLEncoding or base64.RawURLEncoding for servers expecting the URL-safe alphabet,
+// rather than base64.StdEncoding.
+func (c *Client) WithBytesEncoding(encoding *base64.Encoding) *Client {
+	c2 := c.clone()
+	c2.bytesEncoding = encoding
+	return c2
+}
+
+var bytesType = reflect.TypeOf(Bytes(nil))
+
+// GraphQLType implements GraphQLTyper, declaring variables of type Bytes as Bytes.
+func (Bytes) GraphQLType() string { return "Bytes" }
+
+// MarshalJSON returns b as a JSON string of its standard base64 encoding, or null if b is nil.
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	if b == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(base64.StdEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON sets *b to the bytes of the JSON string data, encoded in base64
+// in the standard or URL-safe alphabet, padded or not.
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*b = nil
+		return nil
+	}
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(*s, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	decoded, err := encoding.DecodeString(strings.TrimRight(*s, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// WithNumericIDs returns a copy of the client that encodes the FlexID values of variables that are integers,
+// e.g. "42", as JSON numbers rather than strings, for servers that expect them.
+func (c *Client) WithNumericIDs() *Client {
+	c2 := c.clone()
+	c2.numericIDs = true
+	return c2
+}
+
+var flexIDType = reflect.TypeOf(FlexID(""))
+
+// GraphQLType implements GraphQLTyper, declaring variables of type FlexID as ID.
+func (FlexID) GraphQLType() string { return "ID" }
+
+// UnmarshalJSON sets *id to the JSON string data, or to the literal of the JSON number data,
+// e.g. "42" for 42. null leaves *id as is, as for strings.
+func (id *FlexID) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case nil:
+	case string:
+		*id = FlexID(value)
+	case json.Number:
+		*id = FlexID(value)
+	default:
+		return fmt.Errorf("cannot decode %T into graphql.FlexID, want a string or a number", value)
+	}
+	return nil
+}
+
+// isInteger reports whether s is the literal of an integer, e.g. "-42".
+func isInteger(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
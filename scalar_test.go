@@ -1,12 +1,21 @@
 package graphql_test
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/darrensapalo/go-graphql-client"
 )
 
 func TestNewScalars(t *testing.T) {
+	if got := graphql.NewBool(true); got == nil || !*got {
+		t.Error("NewBool didn't return a pointer to true")
+	}
 	if got := graphql.NewBoolean(false); got == nil {
 		t.Error("NewBoolean returned nil")
 	}
@@ -27,4 +36,181 @@ func TestNewScalars(t *testing.T) {
 	if got := graphql.NewString(""); got == nil {
 		t.Error("NewString returned nil")
 	}
+
+	variables := map[string]interface{}{"after": graphql.NewString("cursor"), "archived": graphql.NewBool(false)}
+	var q struct {
+		Issues struct {
+			TotalCount graphql.Int
+		} `graphql:"issues(after: $after, archived: $archived)"`
+	}
+	if got, err := graphql.ConstructQuery(&q, variables, ""); err != nil || got != `query ($after:String$archived:Boolean){issues(after: $after, archived: $archived){totalCount}}` {
+		t.Errorf("got query: %v, %v", got, err)
+	}
+}
+
+type uri string
+
+func init() {
+	graphql.RegisterScalar(uri(""), graphql.Scalar{
+		Name: "URI",
+		Validate: func(value interface{}) error {
+			if !strings.HasPrefix(string(value.(uri)), "https://") {
+				return errors.New("not an https URI")
+			}
+			return nil
+		},
+	})
+}
+
+func TestRegisterScalar(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"addLink": {"id": "1"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var m struct {
+		AddLink struct {
+			ID graphql.ID
+		} `graphql:"addLink(url: $url, mirrors: $mirrors)"`
+	}
+	variables := map[string]interface{}{"url": uri("https://golang.org"), "mirrors": []*uri{nil}}
+	if got, err := client.ConstructMutation(&m, variables, ""); err != nil || got != `mutation ($mirrors:[URI]!$url:URI!){addLink(url: $url, mirrors: $mirrors){id}}` {
+		t.Errorf("got mutation: %v, %v", got, err)
+	}
+	overridden := client.WithVariableTypes(map[reflect.Type]string{reflect.TypeOf(uri("")): "Url"})
+	if got, err := overridden.ConstructMutation(&m, variables, ""); err != nil || got != `mutation ($mirrors:[Url]!$url:Url!){addLink(url: $url, mirrors: $mirrors){id}}` {
+		t.Errorf("got mutation: %v, %v", got, err)
+	}
+
+	invalid := uri("http://golang.org")
+	variables["mirrors"] = []*uri{&invalid}
+	err := client.Mutate(context.Background(), graphql.ManualRequest{Query: "mutation{addLink{id}}", Result: &m}, variables)
+	if err == nil || err.Error() != "variable $mirrors: invalid URI: not an https URI" {
+		t.Errorf("got error: %v, want invalid URI error", err)
+	}
+	if gotBody != "" {
+		t.Errorf("got request body: %v, want no request", gotBody)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"settings": {"value": {"b": [1, 2.50], "a": null}, "empty": null}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Settings struct {
+			Value graphql.JSON
+			Empty graphql.JSON
+		} `graphql:"settings(filter: $filter)"`
+	}
+	variables := map[string]interface{}{"filter": graphql.JSON(`{"z": 1, "y": [true]}`)}
+	query, err := client.ConstructQuery(&q, variables, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, variables); err != nil {
+		t.Fatal(err)
+	}
+	wantBody := `{"query":"query ($filter:JSON!){settings(filter: $filter){value,empty}}","variables":{"filter":{"z":1,"y":[true]}}}` + "\n"
+	if gotBody != wantBody {
+		t.Errorf("got body: %v, want: %v", gotBody, wantBody)
+	}
+	if got, want := string(q.Settings.Value), `{"b": [1, 2.50], "a": null}`; got != want {
+		t.Errorf("got value: %s, want: %s", got, want)
+	}
+	if got, want := string(q.Settings.Empty), `null`; got != want {
+		t.Errorf("got empty: %s, want: %s", got, want)
+	}
+}
+
+func TestBytes(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"file": {"std": "+/8=", "url": "-_8", "none": null}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		File struct {
+			Std  graphql.Bytes
+			URL  graphql.Bytes
+			None graphql.Bytes
+		} `graphql:"file(checksum: $checksum)"`
+	}
+	variables := map[string]interface{}{"checksum": graphql.Bytes{0xfb, 0xff}}
+	query, err := client.ConstructQuery(&q, variables, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, variables); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"query":"query ($checksum:Bytes!){file(checksum: $checksum){std,url,none}}","variables":{"checksum":"+/8="}}` + "\n"; gotBody != want {
+		t.Errorf("got body: %v, want: %v", gotBody, want)
+	}
+	want := graphql.Bytes{0xfb, 0xff}
+	if !reflect.DeepEqual(q.File.Std, want) || !reflect.DeepEqual(q.File.URL, want) || q.File.None != nil {
+		t.Errorf("got file: %+v, want: %v", q.File, want)
+	}
+
+	err = client.WithBytesEncoding(base64.RawURLEncoding).Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"query":"query ($checksum:Bytes!){file(checksum: $checksum){std,url,none}}","variables":{"checksum":"-_8"}}` + "\n"; gotBody != want {
+		t.Errorf("got body: %v, want: %v", gotBody, want)
+	}
+}
+
+func TestFlexID(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"nodes": [{"id": "VXNlci0xMA=="}, {"id": 12345678901234567890}, {"id": null}]}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Nodes []struct {
+			ID graphql.FlexID
+		} `graphql:"nodes(ids: $ids)"`
+	}
+	variables := map[string]interface{}{"ids": []graphql.FlexID{"42", "VXNlci0xMA=="}}
+	query, err := client.ConstructQuery(&q, variables, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		client   *graphql.Client
+		wantBody string
+	}{
+		{client, `{"query":"query ($ids:[ID!]!){nodes(ids: $ids){id}}","variables":{"ids":["42","VXNlci0xMA=="]}}`},
+		{client.WithNumericIDs(), `{"query":"query ($ids:[ID!]!){nodes(ids: $ids){id}}","variables":{"ids":[42,"VXNlci0xMA=="]}}`},
+	} {
+		q.Nodes = nil
+		if err := tc.client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, variables); err != nil {
+			t.Fatal(err)
+		}
+		if gotBody != tc.wantBody+"\n" {
+			t.Errorf("got body: %v, want: %v", gotBody, tc.wantBody)
+		}
+		if len(q.Nodes) != 3 || q.Nodes[0].ID != "VXNlci0xMA==" || q.Nodes[1].ID != "12345678901234567890" || q.Nodes[2].ID != "" {
+			t.Errorf("got nodes: %+v", q.Nodes)
+		}
+	}
 }
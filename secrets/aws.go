@@ -0,0 +1,193 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	graphql "github.com/darrensapalo/go-graphql-client"
+)
+
+// AWSSecretsManagerConfig configures a provider reading a secret from AWS Secrets Manager.
+type AWSSecretsManagerConfig struct {
+	// SecretID is the name or ARN of the secret.
+	SecretID string
+
+	// Region is the AWS region of the secret.
+	//
+	// Defaults to the AWS_REGION or AWS_DEFAULT_REGION environment variable.
+	Region string
+
+	// AccessKeyID, SecretAccessKey and SessionToken are the AWS credentials used to sign requests.
+	//
+	// Default to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint overrides the Secrets Manager endpoint, e.g. for VPC endpoints or local testing.
+	//
+	// Defaults to "https://secretsmanager.<Region>.amazonaws.com".
+	Endpoint string
+
+	// Mapping describes how the fields of the secret become credentials.
+	Mapping Mapping
+
+	// RefreshInterval is how long fetched credentials are used before being fetched again.
+	// A negative value disables refreshing.
+	//
+	// Defaults to DefaultRefreshInterval.
+	RefreshInterval time.Duration
+
+	// HTTPClient is used to talk to AWS.
+	//
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// AWSSecretsManager returns a provider reading credentials from the SecretString of an AWS Secrets Manager secret.
+func AWSSecretsManager(config AWSSecretsManagerConfig) graphql.CredentialsProvider {
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_REGION")
+	}
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if config.AccessKeyID == "" && config.SecretAccessKey == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://secretsmanager." + config.Region + ".amazonaws.com"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return awsProvider{config: config, now: time.Now}
+}
+
+type awsProvider struct {
+	config AWSSecretsManagerConfig
+	now    func() time.Time
+}
+
+// Credentials implements graphql.CredentialsProvider.
+func (p awsProvider) Credentials(ctx context.Context) (graphql.Credentials, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": p.config.SecretID})
+	if err != nil {
+		return graphql.Credentials{}, err
+	}
+	req, err := http.NewRequest("POST", p.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return graphql.Credentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	p.sign(req, body)
+
+	resp, err := p.config.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return graphql.Credentials{}, fmt.Errorf("secrets manager: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return graphql.Credentials{}, fmt.Errorf("secrets manager: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return graphql.Credentials{}, fmt.Errorf("secrets manager: non-200 OK status code: %v body: %q", resp.Status, respBody)
+	}
+
+	var out struct {
+		SecretString *string
+	}
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return graphql.Credentials{}, fmt.Errorf("secrets manager: decoding response: %v", err)
+	}
+	if out.SecretString == nil {
+		return graphql.Credentials{}, fmt.Errorf("secrets manager: secret %q has no SecretString", p.config.SecretID)
+	}
+	return p.config.Mapping.credentials(parseFields(*out.SecretString), p.config.RefreshInterval)
+}
+
+// sign adds an AWS Signature Version 4 to req.
+// See https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html.
+func (p awsProvider) sign(req *http.Request, body []byte) {
+	const service = "secretsmanager"
+	now := p.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if p.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", p.config.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key := range req.Header {
+		headers[strings.ToLower(key)] = strings.TrimSpace(req.Header.Get(key))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+
+	scope := date + "/" + p.config.Region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+p.config.SecretAccessKey), date)
+	key = hmacSHA256(key, p.config.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+p.config.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalQuery(query url.Values) string {
+	// url.Values.Encode sorts by key, and escapes spaces as "+", which SigV4 wants as "%20".
+	return strings.Replace(query.Encode(), "+", "%20", -1)
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,81 @@
+// Package secrets provides graphql.CredentialsProvider adapters that fetch API keys and tokens
+// from secret stores, so that they are rotated without restarting the process.
+//
+// The adapters talk to the stores' HTTP APIs directly, and don't depend on their SDKs.
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	graphql "github.com/darrensapalo/go-graphql-client"
+)
+
+// DefaultRefreshInterval is how long fetched credentials are used before being fetched again,
+// unless configured otherwise.
+const DefaultRefreshInterval = 5 * time.Minute
+
+// Mapping describes how the fields of a secret become credentials.
+//
+// Secrets are read as JSON objects of string fields. A secret that isn't a JSON object,
+// such as a plain string, is read as a single field named "value".
+type Mapping struct {
+	// TokenField is the field holding the bearer token. If empty, no token is sent.
+	TokenField string
+
+	// Headers maps header names to the fields holding their values, e.g. {"X-Api-Key": "api_key"}.
+	Headers map[string]string
+}
+
+// credentials builds the credentials described by m from the fields of a secret.
+func (m Mapping) credentials(fields map[string]string, refresh time.Duration) (graphql.Credentials, error) {
+	var creds graphql.Credentials
+	if m.TokenField != "" {
+		token, ok := fields[m.TokenField]
+		if !ok {
+			return graphql.Credentials{}, fmt.Errorf("secret has no field %q", m.TokenField)
+		}
+		creds.Token = token
+	}
+	for header, field := range m.Headers {
+		value, ok := fields[field]
+		if !ok {
+			return graphql.Credentials{}, fmt.Errorf("secret has no field %q", field)
+		}
+		if creds.Header == nil {
+			creds.Header = make(http.Header)
+		}
+		creds.Header.Set(header, value)
+	}
+	if refresh == 0 {
+		refresh = DefaultRefreshInterval
+	}
+	if refresh > 0 {
+		creds.Expiry = time.Now().Add(refresh)
+	}
+	return creds, nil
+}
+
+// parseFields reads the fields of a secret value.
+func parseFields(secret string) map[string]string {
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &object); err != nil {
+		return map[string]string{"value": secret}
+	}
+	return stringFields(object)
+}
+
+// stringFields converts the values of a JSON object to strings.
+func stringFields(object map[string]interface{}) map[string]string {
+	fields := make(map[string]string, len(object))
+	for key, value := range object {
+		if s, ok := value.(string); ok {
+			fields[key] = s
+		} else {
+			fields[key] = fmt.Sprint(value)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,116 @@
+package secrets
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.URL.Path, "/v1/kv/data/graphql/github"; got != want {
+			t.Errorf("got path: %q, want: %q", got, want)
+		}
+		if got, want := req.Header.Get("X-Vault-Token"), "root"; got != want {
+			t.Errorf("got X-Vault-Token: %q, want: %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {"data": {"token": "abc", "key": "k1"}, "metadata": {"version": 3}}}`))
+	}))
+	defer server.Close()
+
+	p := Vault(VaultConfig{
+		Address: server.URL,
+		Token:   "root",
+		Mount:   "kv",
+		Path:    "graphql/github",
+		Mapping: Mapping{TokenField: "token", Headers: map[string]string{"X-Api-Key": "key"}},
+	})
+	creds, err := p.Credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := creds.Token, "abc"; got != want {
+		t.Errorf("got token: %q, want: %q", got, want)
+	}
+	if got, want := creds.Header.Get("X-Api-Key"), "k1"; got != want {
+		t.Errorf("got X-Api-Key: %q, want: %q", got, want)
+	}
+	if d := time.Until(creds.Expiry); d <= 0 || d > DefaultRefreshInterval {
+		t.Errorf("got expiry in %v, want within %v", d, DefaultRefreshInterval)
+	}
+}
+
+func TestVault_missingField(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"data": {"other": "abc"}}}`))
+	}))
+	defer server.Close()
+
+	p := Vault(VaultConfig{Address: server.URL, Path: "x", Mapping: Mapping{TokenField: "token"}})
+	_, err := p.Credentials(context.Background())
+	if got, want := err, `secret has no field "token"`; got == nil || got.Error() != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.Header.Get("X-Amz-Target"), "secretsmanager.GetSecretValue"; got != want {
+			t.Errorf("got X-Amz-Target: %q, want: %q", got, want)
+		}
+		if got, want := req.Header.Get("X-Amz-Security-Token"), "session"; got != want {
+			t.Errorf("got X-Amz-Security-Token: %q, want: %q", got, want)
+		}
+		auth := req.Header.Get("Authorization")
+		if want := "AWS4-HMAC-SHA256 Credential=AKID/20240102/eu-west-1/secretsmanager/aws4_request, "; !strings.HasPrefix(auth, want) {
+			t.Errorf("got Authorization: %q, want prefix: %q", auth, want)
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		if got, want := string(body), `{"SecretId":"graphql/api-key"}`; got != want {
+			t.Errorf("got body: %s, want: %s", got, want)
+		}
+		_, _ = w.Write([]byte(`{"Name": "graphql/api-key", "SecretString": "plain-token"}`))
+	}))
+	defer server.Close()
+
+	p := AWSSecretsManager(AWSSecretsManagerConfig{
+		SecretID:        "graphql/api-key",
+		Region:          "eu-west-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+		Endpoint:        server.URL,
+		Mapping:         Mapping{TokenField: "value"},
+		RefreshInterval: -1,
+	}).(awsProvider)
+	p.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	creds, err := p.Credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := creds.Token, "plain-token"; got != want {
+		t.Errorf("got token: %q, want: %q", got, want)
+	}
+	if !creds.Expiry.IsZero() {
+		t.Errorf("got expiry: %v, want: zero", creds.Expiry)
+	}
+}
+
+// TestAWSSign checks the credential scope and signed headers of a signature.
+func TestAWSSign(t *testing.T) {
+	p := awsProvider{
+		config: AWSSecretsManagerConfig{Region: "us-east-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+		now:    func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+	}
+	req, _ := http.NewRequest("POST", "https://secretsmanager.us-east-1.amazonaws.com/", nil)
+	p.sign(req, nil)
+	const want = "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/secretsmanager/aws4_request, SignedHeaders=host;x-amz-date, Signature="
+	if got := req.Header.Get("Authorization"); !strings.HasPrefix(got, want) || len(got) != len(want)+64 {
+		t.Errorf("got Authorization: %q, want: %q followed by a hex signature", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	graphql "github.com/darrensapalo/go-graphql-client"
+)
+
+// VaultConfig configures a provider reading a secret from the HashiCorp Vault KV version 2 secrets engine.
+type VaultConfig struct {
+	// Address is the Vault server address, e.g. "https://vault.internal:8200".
+	//
+	// Defaults to the VAULT_ADDR environment variable.
+	Address string
+
+	// Token authenticates against Vault.
+	//
+	// Defaults to the VAULT_TOKEN environment variable.
+	Token string
+
+	// Mount is the mount path of the KV secrets engine.
+	//
+	// Defaults to "secret".
+	Mount string
+
+	// Path is the path of the secret within the mount, e.g. "graphql/github".
+	Path string
+
+	// Mapping describes how the fields of the secret become credentials.
+	Mapping Mapping
+
+	// RefreshInterval is how long fetched credentials are used before being fetched again.
+	// A negative value disables refreshing.
+	//
+	// Defaults to DefaultRefreshInterval.
+	RefreshInterval time.Duration
+
+	// HTTPClient is used to talk to Vault.
+	//
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Vault returns a provider reading credentials from a Vault KV version 2 secret.
+func Vault(config VaultConfig) graphql.CredentialsProvider {
+	if config.Address == "" {
+		config.Address = os.Getenv("VAULT_ADDR")
+	}
+	if config.Token == "" {
+		config.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if config.Mount == "" {
+		config.Mount = "secret"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return vaultProvider{config: config}
+}
+
+type vaultProvider struct {
+	config VaultConfig
+}
+
+// Credentials implements graphql.CredentialsProvider.
+func (p vaultProvider) Credentials(ctx context.Context) (graphql.Credentials, error) {
+	url := strings.TrimRight(p.config.Address, "/") + "/v1/" + strings.Trim(p.config.Mount, "/") + "/data/" + strings.TrimLeft(p.config.Path, "/")
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return graphql.Credentials{}, err
+	}
+	req.Header.Set("X-Vault-Token", p.config.Token)
+	resp, err := p.config.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return graphql.Credentials{}, fmt.Errorf("vault: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return graphql.Credentials{}, fmt.Errorf("vault: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return graphql.Credentials{}, fmt.Errorf("vault: non-200 OK status code: %v body: %q", resp.Status, body)
+	}
+
+	var out struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return graphql.Credentials{}, fmt.Errorf("vault: decoding response: %v", err)
+	}
+	return p.config.Mapping.credentials(stringFields(out.Data.Data), p.config.RefreshInterval)
+}
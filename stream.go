@@ -0,0 +1,121 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// QueryStream executes a query whose response holds a large list, and passes the items of the list to fn
+// one at a time, as they are read from the response. Unlike Query, it never holds the whole response in memory,
+// so that memory use doesn't grow with the length of the list. request.Result is not used.
+//
+// path is the sequence of response keys leading from the data of the response to the list,
+// e.g. []string{"repository", "issues", "nodes"}. Other fields of the response are skipped.
+// If fn returns an error, reading the response stops, and the error is returned.
+// If the response has errors, they are returned once all items have been passed to fn.
+//
+// Responses are held in memory when the client caches them, see WithCache and WithNormalizedCache.
+// The query is never batched with others, see WithBatching, and only the start of responses to persisted queries
+// is read ahead to find persisted query errors, see WithPersistedQueries.
+func (c *Client) QueryStream(ctx context.Context, request ManualRequest, variables map[string]interface{}, path []string, fn func(item json.RawMessage) error, options ...RequestOption) error {
+	request.Result = nil
+	options = append(options[:len(options):len(options)], func(opts *requestOptions) { opts.stream = true })
+	resp, _, err := c.execute(ctx, queryOperation, request, variables, "", options)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	errs := responseErrors{client: c}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "data":
+			if err := streamList(dec, path, fn); err != nil {
+				return err
+			}
+		case "errors":
+			if err := dec.Decode(&errs); err != nil {
+				return err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+	}
+	if len(errs.errs) > 0 {
+		return errs.errs
+	}
+	return nil
+}
+
+// streamList reads a JSON value from dec, passing the items of the list at path in it to fn.
+// A null value, or a null object on the path, holds no items.
+func streamList(dec *json.Decoder, path []string, fn func(item json.RawMessage) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if len(path) == 0 {
+		if tok != json.Delim('[') {
+			return fmt.Errorf("got %v, want a list", tok)
+		}
+		for dec.More() {
+			var item json.RawMessage
+			if err := dec.Decode(&item); err != nil {
+				return err
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		_, err := dec.Token() // ']'
+		return err
+	}
+
+	if tok != json.Delim('{') {
+		return fmt.Errorf("got %v at %q, want an object", tok, path[0])
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if key == path[0] {
+			err = streamList(dec, path[1:], fn)
+		} else {
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token() // '}'
+	return err
+}
+
+// expectDelim reads the next token from dec, and checks that it is delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("got %v, want %v", tok, delim)
+	}
+	return nil
+}
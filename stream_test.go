@@ -0,0 +1,151 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_QueryStream(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		body    string
+		stopAt  int
+		want    []int
+		wantErr string
+	}{
+		{
+			name: "list",
+			body: `{"data": {"repository": {"name": "go", "issues": {"totalCount": 3, "nodes": [{"number": 1}, {"number": 2}, {"number": 3}]}, "stars": 1}}}`,
+			want: []int{1, 2, 3},
+		},
+		{
+			name:    "stopped",
+			body:    `{"data": {"repository": {"issues": {"nodes": [{"number": 1}, {"number": 2}, {"number": 3}]}}}}`,
+			stopAt:  2,
+			want:    []int{1, 2},
+			wantErr: "stop at 2",
+		},
+		{
+			name:    "partial",
+			body:    `{"data": {"repository": {"issues": {"nodes": [{"number": 1}]}}}, "errors": [{"message": "rate limited"}]}`,
+			want:    []int{1},
+			wantErr: "Message: rate limited, Locations: []",
+		},
+		{
+			name:    "null",
+			body:    `{"errors": [{"message": "not found"}], "data": {"repository": null}}`,
+			wantErr: "Message: not found, Locations: []",
+		},
+		{
+			name:    "not a list",
+			body:    `{"data": {"repository": {"issues": {"nodes": {"number": 1}}}}}`,
+			wantErr: "got {, want a list",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, tc.body)
+			})
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+			var got []int
+			err := client.QueryStream(context.Background(), graphql.ManualRequest{Query: "{repository{issues{nodes{number}}}}"}, nil,
+				[]string{"repository", "issues", "nodes"}, func(item json.RawMessage) error {
+					var issue struct {
+						Number int
+					}
+					if err := json.Unmarshal(item, &issue); err != nil {
+						return err
+					}
+					got = append(got, issue.Number)
+					if issue.Number == tc.stopAt {
+						return fmt.Errorf("stop at %d", issue.Number)
+					}
+					return nil
+				})
+			if gotErr := fmt.Sprint(err); (err != nil || tc.wantErr != "") && gotErr != tc.wantErr {
+				t.Errorf("got error: %v, want: %v", gotErr, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got items: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClient_QueryStream_notBuffered(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		client func(*graphql.Client) *graphql.Client
+	}{
+		{"batching", func(c *graphql.Client) *graphql.Client { return c.WithBatching(graphql.BatchConfig{}) }},
+		{"persisted queries", func(c *graphql.Client) *graphql.Client { return c.WithPersistedQueries() }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// The response is longer than what is read ahead of persisted queries, and only ends
+			// once its first item was streamed, which fails if the client buffers it.
+			first, timedOut := make(chan struct{}), make(chan struct{})
+			transport := graphql.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				pr, pw := io.Pipe()
+				go func() {
+					_, _ = io.WriteString(pw, `{"data": {"items": [1`+strings.Repeat(", 0", 5000))
+					select {
+					case <-first:
+					case <-time.After(5 * time.Second):
+						close(timedOut)
+					}
+					_, _ = io.WriteString(pw, `, 2]}}`)
+					pw.Close()
+				}()
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       pr,
+					Request:    req,
+				}, nil
+			})
+			client := tc.client(graphql.NewClient("/graphql", &http.Client{Transport: transport}))
+
+			var got []int
+			streamed := false
+			err := client.QueryStream(context.Background(), graphql.ManualRequest{Query: "{items}"}, nil, []string{"items"}, func(item json.RawMessage) error {
+				var n int
+				if err := json.Unmarshal(item, &n); err != nil {
+					return err
+				}
+				if len(got) == 0 {
+					select {
+					case <-timedOut:
+					default:
+						streamed = true
+						close(first)
+					}
+				}
+				if n != 0 {
+					got = append(got, n)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !streamed {
+				t.Error("got the first item once the whole response was read")
+			}
+			if want := []int{1, 2}; !reflect.DeepEqual(got, want) {
+				t.Errorf("got items: %v, want: %v", got, want)
+			}
+		})
+	}
+}
@@ -157,7 +157,8 @@ func (sc *SubscriptionClient) WithRetryTimeout(timeout time.Duration) *Subscript
 	return sc
 }
 
-// WithLog sets loging function to print out received messages. By default, nothing is printed
+// WithLog sets loging function to print out received messages. By default, nothing is printed.
+// Start messages are followed by their document, formatted with FormatDocument.
 func (sc *SubscriptionClient) WithLog(logger func(args ...interface{})) *SubscriptionClient {
 	sc.log = logger
 	return sc
@@ -243,16 +244,24 @@ func (sc *SubscriptionClient) init() error {
 }
 
 func (sc *SubscriptionClient) printLog(message interface{}, opType OperationMessageType) {
-	if sc.log == nil {
+	if !sc.logEnabled(opType) {
 		return
 	}
+
+	sc.log(message)
+}
+
+// logEnabled reports whether messages of opType are logged, so that they are only built if they are.
+func (sc *SubscriptionClient) logEnabled(opType OperationMessageType) bool {
+	if sc.log == nil {
+		return false
+	}
 	for _, ty := range sc.disabledLogTypes {
 		if ty == opType {
-			return
+			return false
 		}
 	}
-
-	sc.log(message)
+	return true
 }
 
 func (sc *SubscriptionClient) sendConnectionInit() (err error) {
@@ -293,7 +302,10 @@ func (sc *SubscriptionClient) SubscribeRaw(query string, variables map[string]in
 }
 
 func (sc *SubscriptionClient) do(v interface{}, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error, name string) (string, error) {
-	query := constructSubscription(v, variables, name)
+	query, err := constructSubscription(v, variables, name)
+	if err != nil {
+		return "", err
+	}
 	return sc.doRaw(query, variables, handler)
 }
 
@@ -346,7 +358,9 @@ func (sc *SubscriptionClient) startSubscription(id string, sub *subscription) er
 		Payload: payload,
 	}
 
-	sc.printLog(msg, GQL_START)
+	if sc.logEnabled(GQL_START) {
+		sc.printLog(msg.String()+"\n"+FormatDocument(sub.query), GQL_START)
+	}
 	if err := sc.conn.WriteJSON(msg); err != nil {
 		return err
 	}
@@ -437,7 +451,7 @@ func (sc *SubscriptionClient) Run() error {
 				}
 				var out struct {
 					Data   *json.RawMessage
-					Errors errors
+					Errors GraphQLErrors
 					//Extensions interface{} // Unused.
 				}
 
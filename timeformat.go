@@ -0,0 +1,92 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// TimeFormat is how a server encodes time.Time values, see WithTimeFormat:
+// either as strings of Layout, or as integer numbers of Unit since the Unix epoch if Unit isn't 0.
+type TimeFormat struct {
+	// Layout is the layout of times encoded as strings, as for time.Time.Format.
+	Layout string
+
+	// Unit, if not 0, is the unit of times encoded as numbers, e.g. time.Millisecond.
+	Unit time.Duration
+}
+
+// Common time formats of servers.
+var (
+	TimeRFC3339     = TimeFormat{Layout: time.RFC3339}
+	TimeRFC3339Nano = TimeFormat{Layout: time.RFC3339Nano}
+	TimeUnixSeconds = TimeFormat{Unit: time.Second}
+	TimeUnixMillis  = TimeFormat{Unit: time.Millisecond}
+	TimeDate        = TimeFormat{Layout: "2006-01-02"}
+)
+
+// WithTimeFormat returns a copy of the client that encodes time.Time values in variables, and decodes them
+// from the data of responses, in format, e.g. TimeUnixMillis, rather than as RFC 3339 strings,
+// so that the DateTime conventions of servers don't require custom types for every time field.
+//
+// Encoding applies to the values nested in variables as for GraphQLMarshaler. Decoding applies to all
+// time.Time values of results, as a DecodeHook does, so it is slower, and applies to the default
+// encoding/json decoding only, not to a codec set with WithCodec.
+func (c *Client) WithTimeFormat(format TimeFormat) *Client {
+	c2 := c.clone()
+	c2.timeFormat = &format
+	return c2
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// format returns the value t is encoded as.
+func (f TimeFormat) format(t time.Time) interface{} {
+	if f.Unit != 0 {
+		return t.UnixNano() / int64(f.Unit)
+	}
+	return t.Format(f.Layout)
+}
+
+// parse returns the time encoded as data, decoded from JSON.
+func (f TimeFormat) parse(data interface{}) (time.Time, error) {
+	switch data := data.(type) {
+	case string:
+		if f.Unit == 0 {
+			return time.Parse(f.Layout, data)
+		}
+	case float64:
+		if f.Unit != 0 {
+			whole := int64(data)
+			return time.Unix(0, whole*int64(f.Unit)+int64((data-float64(whole))*float64(f.Unit))), nil
+		}
+	case json.Number:
+		if f.Unit != 0 {
+			n, err := data.Int64()
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(0, n*int64(f.Unit)), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot decode %T into time.Time", data)
+}
+
+// decodeHook returns a DecodeHook decoding time.Time values in the format.
+func (f TimeFormat) decodeHook() DecodeHook {
+	return func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != timeType || data == nil {
+			return data, nil
+		}
+		return f.parse(data)
+	}
+}
+
+// hooks returns the decode hooks of the client, including that of its time format, if any.
+func (c *Client) hooks() []DecodeHook {
+	if c.timeFormat == nil {
+		return c.decodeHooks
+	}
+	return append(c.decodeHooks[:len(c.decodeHooks):len(c.decodeHooks)], c.timeFormat.decodeHook())
+}
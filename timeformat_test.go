@@ -0,0 +1,61 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_WithTimeFormat(t *testing.T) {
+	var gotBody string
+	var response string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, response)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	since := time.Date(2021, 2, 3, 4, 5, 6, 7000000, time.UTC)
+	for _, tc := range []struct {
+		format   graphql.TimeFormat
+		response string
+		wantBody string
+		want     time.Time
+	}{
+		{
+			format:   graphql.TimeUnixMillis,
+			response: `{"data": {"events": [{"at": 1612325106007}, {"at": null}]}}`,
+			wantBody: `{"query":"{events{at}}","variables":{"since":1612325106007,"until":[1612325106007]}}`,
+			want:     since,
+		},
+		{
+			format:   graphql.TimeDate,
+			response: `{"data": {"events": [{"at": "2021-02-03"}, {"at": null}]}}`,
+			wantBody: `{"query":"{events{at}}","variables":{"since":"2021-02-03","until":["2021-02-03"]}}`,
+			want:     time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC),
+		},
+	} {
+		response = tc.response
+		var q struct {
+			Events []struct {
+				At *time.Time
+			}
+		}
+		variables := map[string]interface{}{"since": since, "until": []*time.Time{&since}}
+		err := client.WithTimeFormat(tc.format).Query(context.Background(), graphql.ManualRequest{Query: "{events{at}}", Result: &q}, variables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotBody != tc.wantBody+"\n" {
+			t.Errorf("got body: %v, want: %v", gotBody, tc.wantBody)
+		}
+		if len(q.Events) != 2 || q.Events[0].At == nil || !q.Events[0].At.Equal(tc.want) || q.Events[1].At != nil {
+			t.Errorf("got events: %+v, want: %v", q.Events, tc.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package graphql_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_WithTLSConfig_mutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graphql-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, caKey, caPEM := newTestCert(t, nil, nil, "test CA")
+	_, _, serverPEM := newTestCert(t, ca, caKey, "server")
+	_, _, clientPEM := newTestCert(t, ca, caKey, "client")
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeTestFile(t, certFile, clientPEM.cert)
+	writeTestFile(t, keyFile, clientPEM.key)
+
+	serverCert, err := tls.X509KeyPair(serverPEM.cert, serverPEM.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM.cert)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(req.TLS.PeerCertificates) == 0 || req.TLS.PeerCertificates[0].Subject.CommonName != "client" {
+			t.Error("missing client certificate")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client, err := graphql.NewClient(server.URL, nil).WithTLSConfig(graphql.TLSConfig{
+		CAPEM:          caPEM.cert,
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Minute,
+		MinVersion:     tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+
+	var q struct {
+		Viewer struct {
+			Login graphql.String
+		}
+	}
+	err = client.Query(context.Background(), graphql.ManualRequest{Query: "{viewer{login}}", Result: &q}, nil)
+	if err != nil {
+		t.Fatalf("got error: %v, want: nil", err)
+	}
+	if got, want := q.Viewer.Login, graphql.String("gopher"); got != want {
+		t.Errorf("got q.Viewer.Login: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_WithTLSConfig_errors(t *testing.T) {
+	_, err := graphql.NewClient("/graphql", nil).WithTLSConfig(graphql.TLSConfig{CAPEM: []byte("not a certificate")})
+	if err == nil {
+		t.Error("got error: nil, want: non-nil for invalid CA bundle")
+	}
+	_, err = graphql.NewClient("/graphql", nil).WithTLSConfig(graphql.TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"})
+	if err == nil {
+		t.Error("got error: nil, want: non-nil for missing client certificate")
+	}
+	_, err = graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{}}).WithTLSConfig(graphql.TLSConfig{})
+	if err == nil {
+		t.Error("got error: nil, want: non-nil for custom round tripper")
+	}
+}
+
+type testPEM struct {
+	cert, key []byte
+}
+
+// newTestCert creates a certificate for 127.0.0.1 signed by parent,
+// or a self-signed CA certificate if parent is nil.
+func newTestCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, name string) (*x509.Certificate, *ecdsa.PrivateKey, testPEM) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key, testPEM{
+		cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeTestFile(t *testing.T, name string, data []byte) {
+	if err := ioutil.WriteFile(name, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,220 @@
+package graphql
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// WithUnionTypes returns a copy of the client that decodes objects into the fields of the interface type
+// pointed to by iface, e.g. (*SearchResult)(nil), as values of the Go type registered for their __typename
+// in types, so that the results of unions and interfaces don't need one struct with every possible fragment:
+//
+//	type SearchResult interface{}
+//
+//	client = client.WithUnionTypes((*SearchResult)(nil), map[string]interface{}{
+//		"Issue":       Issue{},
+//		"PullRequest": &PullRequest{},
+//	})
+//
+// The values of types are examples of the Go types to decode into, which must implement the interface.
+// Documents constructed by the client, see Client.ConstructQuery, select __typename and an inline fragment
+// for each type in such fields; hand-written documents must select __typename.
+// The types are added to those of the client.
+// It panics if iface isn't a pointer to an interface, or a type doesn't implement it.
+//
+// Results with such fields are decoded generically, with encoding/json, even if a codec is set with WithCodec,
+// and even in strict mode.
+func (c *Client) WithUnionTypes(iface interface{}, types map[string]interface{}) *Client {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("graphql: WithUnionTypes of %T, want a pointer to an interface", iface))
+	}
+	t = t.Elem()
+	byTypename := make(map[string]reflect.Type, len(types))
+	for typename, v := range types {
+		concrete := reflect.TypeOf(v)
+		if concrete == nil || !concrete.Implements(t) {
+			panic(fmt.Sprintf("graphql: WithUnionTypes type %T of %q doesn't implement %v", v, typename, t))
+		}
+		byTypename[typename] = concrete
+	}
+
+	c2 := c.clone()
+	c2.unionTypes = c.unionTypes.clone()
+	for typename, concrete := range c2.unionTypes.types[t] {
+		if _, ok := byTypename[typename]; !ok {
+			byTypename[typename] = concrete
+		}
+	}
+	c2.unionTypes.types[t] = byTypename
+	return c2
+}
+
+// WithUnionFallback returns a copy of the client that decodes objects of __typenames without a Go type
+// registered with WithUnionTypes into fields of the interface type pointed to by iface as values of the type of
+// fallback, rather than failing with an *UnknownTypenameError, e.g. to tolerate types added to a union by
+// the server. Only __typename is selected for such objects; other fields are decoded if the response has them.
+// It panics as WithUnionTypes does.
+func (c *Client) WithUnionFallback(iface interface{}, fallback interface{}) *Client {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("graphql: WithUnionFallback of %T, want a pointer to an interface", iface))
+	}
+	t = t.Elem()
+	concrete := reflect.TypeOf(fallback)
+	if concrete == nil || !concrete.Implements(t) {
+		panic(fmt.Sprintf("graphql: WithUnionFallback type %T doesn't implement %v", fallback, t))
+	}
+	c2 := c.clone()
+	c2.unionTypes = c.unionTypes.clone()
+	c2.unionTypes.fallbacks[t] = concrete
+	return c2
+}
+
+// UnknownTypenameError is returned when an object is decoded into an interface field, see WithUnionTypes,
+// and no Go type is registered for its __typename, nor a fallback type. It is wrapped in a *DecodeError.
+type UnknownTypenameError struct {
+	// Typename is the __typename of the object, or "" if it has none.
+	Typename string
+
+	// Type is the interface type of the field.
+	Type reflect.Type
+}
+
+// Error implements error interface.
+func (e *UnknownTypenameError) Error() string {
+	if e.Typename == "" {
+		return fmt.Sprintf("cannot decode object without __typename into %v", e.Type)
+	}
+	return fmt.Sprintf("no Go type registered for __typename %q of %v", e.Typename, e.Type)
+}
+
+// unionTypes maps interface types to the Go types that objects are decoded into by __typename.
+type unionTypes struct {
+	types     map[reflect.Type]map[string]reflect.Type
+	fallbacks map[reflect.Type]reflect.Type
+
+	// found caches whether types have interface fields of union types, as reported by in.
+	found sync.Map // map[reflect.Type]bool
+	// queries caches the query strings of such types, see query.
+	queries sync.Map // map[queryKey]string
+}
+
+// clone returns a copy of u, without its caches, which may be nil.
+func (u *unionTypes) clone() *unionTypes {
+	u2 := &unionTypes{types: map[reflect.Type]map[string]reflect.Type{}, fallbacks: map[reflect.Type]reflect.Type{}}
+	if u != nil {
+		for t, types := range u.types {
+			u2.types[t] = types
+		}
+		for t, fallback := range u.fallbacks {
+			u2.fallbacks[t] = fallback
+		}
+	}
+	return u2
+}
+
+// lookup returns the Go types by __typename of the interface type t, or nil if it isn't registered.
+func (u *unionTypes) lookup(t reflect.Type) map[string]reflect.Type {
+	if u == nil {
+		return nil
+	}
+	return u.types[t]
+}
+
+// fallback returns the fallback Go type of the interface type t, or nil if it has none.
+func (u *unionTypes) fallback(t reflect.Type) reflect.Type {
+	if u == nil {
+		return nil
+	}
+	return u.fallbacks[t]
+}
+
+// in reports whether t has interface fields of union types, directly or in nested types.
+func (u *unionTypes) in(t reflect.Type) bool {
+	if u == nil || t == nil {
+		return false
+	}
+	if found, ok := u.found.Load(t); ok {
+		return found.(bool)
+	}
+	found := u.find(t, map[reflect.Type]bool{})
+	u.found.Store(t, found)
+	return found
+}
+
+// find reports whether t has interface fields of union types, skipping the types in seen.
+func (u *unionTypes) find(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return u.types[t] != nil || u.fallbacks[t] != nil
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return u.find(t.Elem(), seen)
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+			return false
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if u.find(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeUnion writes the selection of the interface type t of a union to w: __typename,
+// and an inline fragment for each Go type registered for t, in the order of their __typenames.
+func (qw *queryWriter) writeUnion(w io.Writer, t reflect.Type) {
+	types := qw.unions.lookup(t)
+	typenames := make([]string, 0, len(types))
+	for typename := range types {
+		typenames = append(typenames, typename)
+	}
+	sort.Strings(typenames)
+	io.WriteString(w, "{__typename")
+	for _, typename := range typenames {
+		var selection bytes.Buffer
+		qw.write(&selection, types[typename], false)
+		if selection.Len() <= len("{}") {
+			continue
+		}
+		io.WriteString(w, ",... on "+typename)
+		selection.WriteTo(w)
+	}
+	io.WriteString(w, "}")
+}
+
+// decodeUnion decodes the object value into v, of an interface type, as a value of the Go type in types
+// registered for its __typename, or of the fallback type of the interface type.
+func (d *hookDecoder) decodeUnion(v reflect.Value, value interface{}, types map[string]reflect.Type) error {
+	if value == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("cannot decode %T into %v, want an object", value, v.Type())
+	}
+	typename, _ := object["__typename"].(string)
+	concrete, ok := types[typename]
+	if !ok || typename == "" {
+		if concrete = d.unions.fallback(v.Type()); concrete == nil {
+			return &UnknownTypenameError{Typename: typename, Type: v.Type()}
+		}
+	}
+	elem := reflect.New(concrete).Elem()
+	if err := d.decode(elem, object); err != nil {
+		return err
+	}
+	v.Set(elem)
+	return nil
+}
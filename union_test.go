@@ -0,0 +1,117 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+type SearchResult interface {
+	isSearchResult()
+}
+
+type Issue struct {
+	Title graphql.String
+}
+
+func (Issue) isSearchResult() {}
+
+type PullRequest struct {
+	Title  graphql.String
+	Merged graphql.Boolean
+}
+
+func (*PullRequest) isSearchResult() {}
+
+func TestClient_WithUnionTypes(t *testing.T) {
+	response := `{"data": {"search": {"nodes": [
+		{"__typename": "Issue", "title": "Bug"},
+		{"__typename": "PullRequest", "title": "Fix", "merged": true},
+		null
+	]}}}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, response)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithUnionTypes((*SearchResult)(nil), map[string]interface{}{
+			"Issue":       Issue{},
+			"PullRequest": &PullRequest{},
+		})
+
+	var q struct {
+		Search struct {
+			Nodes []SearchResult
+		}
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: "{search{nodes{__typename}}}", Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Search.Nodes) != 3 {
+		t.Fatalf("got %d nodes, want 3", len(q.Search.Nodes))
+	}
+	if issue, ok := q.Search.Nodes[0].(Issue); !ok || issue.Title != "Bug" {
+		t.Errorf("got node 0: %#v, want Issue", q.Search.Nodes[0])
+	}
+	if pr, ok := q.Search.Nodes[1].(*PullRequest); !ok || pr.Title != "Fix" || pr.Merged != true {
+		t.Errorf("got node 1: %#v, want *PullRequest", q.Search.Nodes[1])
+	}
+	if q.Search.Nodes[2] != nil {
+		t.Errorf("got node 2: %#v, want nil", q.Search.Nodes[2])
+	}
+
+	response = `{"data": {"search": {"nodes": [{"__typename": "Discussion", "title": "?"}]}}}`
+	err := client.Query(context.Background(), graphql.ManualRequest{Query: "{search{nodes{__typename}}}", Result: &q}, nil)
+	if err == nil || !strings.Contains(err.Error(), `no Go type registered for __typename "Discussion"`) {
+		t.Errorf("got error: %v, want unregistered __typename error", err)
+	}
+}
+
+type Discussion map[string]interface{}
+
+func (Discussion) isSearchResult() {}
+
+func TestClient_WithUnionFallback(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"search": {"nodes": [{"__typename": "Issue", "title": "Bug"}, {"__typename": "Discussion"}]}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithUnionTypes((*SearchResult)(nil), map[string]interface{}{
+			"PullRequest": &PullRequest{},
+			"Issue":       Issue{},
+		})
+
+	var q struct {
+		Search struct {
+			Nodes []SearchResult
+		} `graphql:"search(query: \"go\")"`
+	}
+	document, err := client.ConstructQuery(&q, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{search(query: "go"){nodes{__typename,... on Issue{title},... on PullRequest{title,merged}}}}`; document != want {
+		t.Errorf("got query: %v, want: %v", document, want)
+	}
+
+	err = client.Query(context.Background(), graphql.ManualRequest{Query: document, Result: &q}, nil)
+	var typenameErr *graphql.UnknownTypenameError
+	if !errors.As(err, &typenameErr) || typenameErr.Typename != "Discussion" || !errors.Is(err, graphql.ErrDecode) {
+		t.Fatalf("got error: %v, want *UnknownTypenameError", err)
+	}
+
+	client = client.WithUnionFallback((*SearchResult)(nil), Discussion{})
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: document, Result: &q}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if other, ok := q.Search.Nodes[1].(Discussion); !ok || other["__typename"] != "Discussion" {
+		t.Errorf("got node 1: %#v, want fallback Discussion", q.Search.Nodes[1])
+	}
+}
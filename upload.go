@@ -0,0 +1,381 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Upload is a file sent with a GraphQL request, as specified by the GraphQL multipart request spec.
+// It is used as the value of a variable of the Upload scalar type.
+//
+// Variables holding an *os.File are uploaded as well. Other readers must be wrapped in an Upload,
+// so that values that happen to implement io.Reader aren't uploaded by mistake.
+//
+// Specification: https://github.com/jaydenseric/graphql-multipart-request-spec.
+type Upload struct {
+	// File is the content of the file.
+	File io.Reader
+
+	// Filename is the name of the file sent to the server.
+	// If empty, the base name of File is used if it has a Name method, such as *os.File.
+	Filename string
+
+	// ContentType is the media type of the file.
+	//
+	// Defaults to "application/octet-stream".
+	ContentType string
+
+	// Size is the exact number of bytes read from File, if known in advance.
+	// It is used for the Content-Length of the request and for progress reporting.
+	//
+	// If zero, it is determined from File for *os.File, *bytes.Buffer, *bytes.Reader and *strings.Reader.
+	Size int64
+}
+
+var fileType = reflect.TypeOf(&os.File{})
+
+// isUploadType reports whether values of type t are uploaded as files.
+func isUploadType(t reflect.Type) bool {
+	return t == reflect.TypeOf(Upload{}) || t == reflect.TypeOf(&Upload{}) || t == fileType
+}
+
+// fileUpload is an upload found in the variables of a request.
+type fileUpload struct {
+	// paths are the object paths of the variables holding the file, e.g. "variables.files.0".
+	paths  []string
+	upload Upload
+
+	// seeker and start are used to rewind the file before sending it again; seeker is nil if it can't be.
+	seeker io.Seeker
+	start  int64
+}
+
+// extractUploads returns a copy of variables where uploads are replaced by null,
+// together with the uploads found. The copy is only made if there are uploads.
+func extractUploads(variables map[string]interface{}) (map[string]interface{}, []fileUpload) {
+	var uploads []fileUpload
+	out, found := replaceUploads(reflect.ValueOf(variables), "variables", &uploads)
+	if !found {
+		return variables, nil
+	}
+	return out.(map[string]interface{}), uploads
+}
+
+// replaceUploads walks v, which is located at path, recording uploads and replacing them by nil.
+// It reports whether any upload was found; if not, the returned value must be ignored.
+// Maps with string keys, slices and arrays are walked, other values are left as they are.
+func replaceUploads(v reflect.Value, path string, uploads *[]fileUpload) (interface{}, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if isUploadType(v.Type()) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, false
+		}
+		*uploads = append(*uploads, fileUpload{paths: []string{path}, upload: toUpload(v.Interface())})
+		return nil, true
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		out := make(map[string]interface{}, v.Len())
+		found := false
+		keys := v.MapKeys()
+		// Sort keys so that uploads are numbered deterministically.
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			value := v.MapIndex(key)
+			if replaced, ok := replaceUploads(value, path+"."+key.String(), uploads); ok {
+				out[key.String()] = replaced
+				found = true
+			} else {
+				out[key.String()] = value.Interface()
+			}
+		}
+		return out, found
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, false
+		}
+		out := make([]interface{}, v.Len())
+		found := false
+		for i := range out {
+			if replaced, ok := replaceUploads(v.Index(i), path+"."+strconv.Itoa(i), uploads); ok {
+				out[i] = replaced
+				found = true
+			} else {
+				out[i] = v.Index(i).Interface()
+			}
+		}
+		return out, found
+	default:
+		return nil, false
+	}
+}
+
+// toUpload converts a variable value of an upload type to an Upload.
+func toUpload(v interface{}) Upload {
+	var upload Upload
+	switch v := v.(type) {
+	case Upload:
+		upload = v
+	case *Upload:
+		upload = *v
+	case *os.File:
+		upload = Upload{File: v}
+	}
+	if upload.Filename == "" {
+		if named, ok := upload.File.(interface{ Name() string }); ok {
+			upload.Filename = filepath.Base(named.Name())
+		}
+	}
+	if upload.Filename == "" {
+		upload.Filename = "blob"
+	}
+	if upload.ContentType == "" {
+		upload.ContentType = "application/octet-stream"
+	}
+	return upload
+}
+
+// multipartBody encodes a GraphQL request with file uploads as a multipart/form-data body,
+// with the operations part first, then the map part, then one part per file.
+//
+// The files are streamed rather than buffered. progress, if not nil, is called as they are sent.
+func multipartBody(operations []byte, uploads []fileUpload, progress func(sent, total int64)) (requestBody, error) {
+	m := &multipartUpload{
+		operations: operations,
+		uploads:    uploads,
+		boundary:   multipart.NewWriter(nil).Boundary(),
+		progress:   progress,
+	}
+	for i := range m.uploads {
+		if s, ok := m.uploads[i].upload.File.(io.Seeker); ok {
+			start, err := s.Seek(0, io.SeekCurrent)
+			if err == nil {
+				m.uploads[i].seeker, m.uploads[i].start = s, start
+			}
+		}
+	}
+	m.total = m.filesSize()
+	length, err := m.length()
+	if err != nil {
+		return requestBody{}, err
+	}
+	reopenable := true
+	for _, u := range m.uploads {
+		reopenable = reopenable && u.seeker != nil
+	}
+	return requestBody{
+		contentType: "multipart/form-data; boundary=" + m.boundary,
+		open:        m.open,
+		length:      length,
+		reopenable:  reopenable,
+	}, nil
+}
+
+// multipartUpload streams the multipart body of a request with file uploads.
+type multipartUpload struct {
+	operations []byte
+	uploads    []fileUpload
+	boundary   string
+	progress   func(sent, total int64)
+
+	// total is the size of the files, or -1 if unknown.
+	total int64
+	// opened records whether the files were already read, and must be rewound before being sent again.
+	opened bool
+	// reader is the body of the last attempt, and written is closed once its writer stopped reading the files.
+	reader  *io.PipeReader
+	written chan struct{}
+}
+
+// open starts streaming the body. Requests that are sent again, e.g. to another endpoint,
+// rewind the files to where they were before the first attempt, which requires them to implement io.Seeker.
+func (m *multipartUpload) open() (io.ReadCloser, error) {
+	if m.opened {
+		// Stop the writer of the last attempt, which may still be reading the files, before rewinding them.
+		m.reader.CloseWithError(errBodyReopened)
+		<-m.written
+		for _, u := range m.uploads {
+			if u.seeker == nil {
+				return nil, fmt.Errorf("cannot send file %q again: it does not implement io.Seeker", u.upload.Filename)
+			}
+			if _, err := u.seeker.Seek(u.start, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("rewinding file %q: %v", u.upload.Filename, err)
+			}
+		}
+	}
+	m.opened = true
+
+	var sent int64
+	pr, pw := io.Pipe()
+	written := make(chan struct{})
+	m.reader, m.written = pr, written
+	go func() {
+		defer close(written)
+		pw.CloseWithError(m.write(pw, func(n int) {
+			if m.progress != nil {
+				sent += int64(n)
+				m.progress(sent, m.total)
+			}
+		}))
+	}()
+	return pr, nil
+}
+
+// errBodyReopened stops writing the body of an attempt when the request is sent again.
+var errBodyReopened = errors.New("graphql: request body opened again")
+
+// write writes the body to w, calling onRead with the number of file bytes read.
+// If onRead is nil, the contents of the files are left out, to measure the length of the rest of the body.
+func (m *multipartUpload) write(w io.Writer, onRead func(n int)) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(m.boundary); err != nil {
+		return err
+	}
+	if err := writeMultipartHeader(mw, m.operations, m.uploads); err != nil {
+		return err
+	}
+	for i, u := range m.uploads {
+		part, err := createFilePart(mw, i, u.upload)
+		if err != nil {
+			return err
+		}
+		if onRead == nil {
+			continue
+		}
+		if _, err := io.Copy(part, progressReader{r: u.upload.File, onRead: onRead}); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// length returns the length of the body, or -1 if the size of a file is unknown.
+func (m *multipartUpload) length() (int64, error) {
+	if m.total < 0 {
+		return -1, nil
+	}
+	var counter countingWriter
+	if err := m.write(&counter, nil); err != nil {
+		return 0, err
+	}
+	return int64(counter) + m.total, nil
+}
+
+// filesSize returns the total size of the files, or -1 if unknown.
+func (m *multipartUpload) filesSize() int64 {
+	var total int64
+	for _, u := range m.uploads {
+		size := u.upload.Size
+		if size <= 0 {
+			size = readerSize(u.upload.File)
+		}
+		if size < 0 {
+			return -1
+		}
+		total += size
+	}
+	return total
+}
+
+// readerSize returns the number of bytes left to read from r, or -1 if unknown.
+func readerSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }: // *bytes.Buffer, *bytes.Reader, *strings.Reader.
+		return int64(r.Len())
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	default:
+		return -1
+	}
+}
+
+// progressReader calls onRead after every read from r.
+type progressReader struct {
+	r      io.Reader
+	onRead func(n int)
+}
+
+func (p progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.onRead(n)
+	}
+	return n, err
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter int64
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	*c += countingWriter(len(b))
+	return len(b), nil
+}
+
+// WithUploadProgress reports the progress of the file uploads of the request to fn,
+// with the number of bytes of the files sent so far, and their total size, or -1 if unknown.
+// fn is called from another goroutine, and starts over from zero if the request is sent again.
+func WithUploadProgress(fn func(sent, total int64)) RequestOption {
+	return func(opts *requestOptions) {
+		opts.uploadProgress = fn
+	}
+}
+
+// writeMultipartHeader writes the operations and map parts of a multipart request.
+func writeMultipartHeader(w *multipart.Writer, operations []byte, uploads []fileUpload) error {
+	if err := w.WriteField("operations", strings.TrimSuffix(string(operations), "\n")); err != nil {
+		return err
+	}
+	fileMap := make(map[string][]string, len(uploads))
+	for i, u := range uploads {
+		fileMap[strconv.Itoa(i)] = u.paths
+	}
+	mapJSON, err := json.Marshal(fileMap)
+	if err != nil {
+		return err
+	}
+	return w.WriteField("map", string(mapJSON))
+}
+
+// createFilePart starts the part holding the i-th file of a multipart request.
+func createFilePart(w *multipart.Writer, i int, upload Upload) (io.Writer, error) {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="`+strconv.Itoa(i)+`"; filename="`+escapeQuotes(upload.Filename)+`"`)
+	h.Set("Content-Type", upload.ContentType)
+	return w.CreatePart(h)
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,200 @@
+package graphql_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+func TestClient_Mutate_upload(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.Header.Get("Apollo-Require-Preflight"), "true"; got != want {
+			t.Errorf("got Apollo-Require-Preflight: %q, want: %q", got, want)
+		}
+		mr, err := req.MultipartReader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := ioutil.ReadAll(part)
+			got = append(got, part.FormName()+" "+part.FileName()+" "+part.Header.Get("Content-Type")+" "+string(body))
+		}
+		want := []string{
+			// Readers other than files aren't uploaded unless wrapped in an Upload.
+			`operations   {"query":"mutation($file:Upload!$files:[Upload!]!){upload(file:$file, files:$files)}","variables":{"file":null,"files":[null,null],"name":"avatar","text":{}}}`,
+			`map   {"0":["variables.file"],"1":["variables.files.0"],"2":["variables.files.1"]}`,
+			`0 a.txt text/plain alpha`,
+			`1 blob application/octet-stream beta`,
+			`2 blob application/octet-stream gamma`,
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("got parts:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"upload": true}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var m struct {
+		Upload bool
+	}
+	err := client.Mutate(context.Background(), graphql.ManualRequest{
+		Query:  "mutation($file:Upload!$files:[Upload!]!){upload(file:$file, files:$files)}",
+		Result: &m,
+	}, map[string]interface{}{
+		"file":  graphql.Upload{File: strings.NewReader("alpha"), Filename: "a.txt", ContentType: "text/plain"},
+		"files": []graphql.Upload{{File: strings.NewReader("beta")}, {File: strings.NewReader("gamma")}},
+		"text":  strings.NewReader("not a file"),
+		"name":  "avatar",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Upload {
+		t.Errorf("got upload: false, want: true")
+	}
+}
+
+func TestClient_Mutate_uploadProgress(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if req.ContentLength <= int64(len("alphabeta")) {
+			t.Errorf("got Content-Length: %v, want the length of the whole body", req.ContentLength)
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		if int64(len(body)) != req.ContentLength {
+			t.Errorf("got body of %v bytes, want: %v", len(body), req.ContentLength)
+		}
+		if req.Header.Get("Authorization") != "Bearer fresh" {
+			http.Error(w, "token expired", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"upload": true}}`)
+	})
+	ts := &rotatingToken{token: "stale"}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithTokenSource(ts).
+		WithAuthRefresh(ts.refresh)
+
+	var progress []int64
+	var m struct {
+		Upload bool
+	}
+	err := client.Mutate(context.Background(), graphql.ManualRequest{
+		Query:  "mutation($files:[Upload!]!){upload(files:$files)}",
+		Result: &m,
+	}, map[string]interface{}{
+		"files": []graphql.Upload{{File: strings.NewReader("alpha")}, {File: strings.NewReader("beta")}},
+	}, graphql.WithUploadProgress(func(sent, total int64) {
+		if total != 9 {
+			t.Errorf("got total: %v, want: 9", total)
+		}
+		progress = append(progress, sent)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("got %v requests, want: 2", requests)
+	}
+	// Progress starts over when the files are sent again.
+	if got, want := progress, []int64{5, 9, 5, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got progress: %v, want: %v", got, want)
+	}
+}
+
+func TestClient_Mutate_uploadNotSeekable(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if req.ContentLength != -1 {
+			t.Errorf("got Content-Length: %v, want: -1", req.ContentLength)
+		}
+		_, _ = ioutil.ReadAll(req.Body)
+		http.Error(w, "token expired", http.StatusUnauthorized)
+	})
+	ts := &rotatingToken{token: "stale"}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithTokenSource(ts).
+		WithAuthRefresh(ts.refresh)
+
+	var m struct {
+		Upload bool
+	}
+	err := client.Mutate(context.Background(), graphql.ManualRequest{
+		Query:  "mutation($file:Upload!){upload(file:$file)}",
+		Result: &m,
+	}, map[string]interface{}{
+		"file": graphql.Upload{File: ioutil.NopCloser(strings.NewReader("alpha")), Filename: "a.txt"},
+	})
+	if got, want := err, `cannot send file "a.txt" again: it does not implement io.Seeker`; got == nil || got.Error() != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+}
+
+func TestClient_Mutate_uploadRetryWhileSending(t *testing.T) {
+	content := strings.Repeat("0123456789", 100000)
+	var got string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer fresh" {
+			// Answer before the body is sent, which keeps being read, as by a transport still writing it.
+			go func() { _, _ = io.Copy(ioutil.Discard, req.Body) }()
+			http.Error(w, "token expired", http.StatusUnauthorized)
+			return
+		}
+		mr, err := req.MultipartReader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if part.FormName() == "0" {
+				got = mustRead(part)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"upload": true}}`)
+	})
+	ts := &rotatingToken{token: "stale"}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithTokenSource(ts).
+		WithAuthRefresh(ts.refresh)
+
+	var m struct {
+		Upload bool
+	}
+	err := client.Mutate(context.Background(), graphql.ManualRequest{
+		Query:  "mutation($file:Upload!){upload(file:$file)}",
+		Result: &m,
+	}, map[string]interface{}{
+		"file": graphql.Upload{File: strings.NewReader(content)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != content {
+		t.Errorf("got file of %v bytes, want the %v bytes of the file", len(got), len(content))
+	}
+}
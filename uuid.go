@@ -0,0 +1,46 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+)
+
+var (
+	uuidType  = reflect.TypeOf(uuid.UUID{})
+	bytesUUID = reflect.TypeOf([16]byte{})
+)
+
+// WithUUIDType returns a copy of the client that declares variables of UUIDs, of type uuid.UUID
+// of github.com/google/uuid or [16]byte, with the GraphQL type name, e.g. "uuid" for Hasura,
+// rather than "UUID".
+//
+// UUIDs are encoded in variables, and decoded from the data of responses, as strings in their canonical form,
+// e.g. "6ba7b810-9dad-11d1-80b4-00c04fd430c8", including as [16]byte.
+func (c *Client) WithUUIDType(name string) *Client {
+	return c.WithVariableTypes(map[reflect.Type]string{uuidType: name, bytesUUID: name})
+}
+
+// isUUID reports whether t is a UUID type, see WithUUIDType.
+func isUUID(t reflect.Type) bool {
+	return t == uuidType || t == bytesUUID
+}
+
+// parseUUID decodes the UUID encoded as data, decoded from JSON, into v, of a UUID type.
+func parseUUID(v reflect.Value, data interface{}) error {
+	if data == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	s, ok := data.(string)
+	if !ok {
+		return fmt.Errorf("cannot decode %T into %v, want a string", data, v.Type())
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(id).Convert(v.Type()))
+	return nil
+}
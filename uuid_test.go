@@ -0,0 +1,52 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+	"github.com/google/uuid"
+)
+
+func TestClient_UUID(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "orgId": "6ba7b811-9dad-11d1-80b4-00c04fd430c8", "teamId": null}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	id := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	var q struct {
+		User struct {
+			ID     uuid.UUID
+			OrgID  [16]byte
+			TeamID *[16]byte
+		} `graphql:"user(id: $id, orgId: $orgId)"`
+	}
+	variables := map[string]interface{}{"id": id, "orgId": [16]byte(id)}
+	query, err := client.ConstructQuery(&q, variables, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `query ($id:UUID!$orgId:UUID!){user(id: $id, orgId: $orgId){id,orgId,teamId}}`; query != want {
+		t.Errorf("got query: %v, want: %v", query, want)
+	}
+	if got, err := client.WithUUIDType("uuid").ConstructQuery(&q, variables, ""); err != nil || got != `query ($id:uuid!$orgId:uuid!){user(id: $id, orgId: $orgId){id,orgId,teamId}}` {
+		t.Errorf("got query: %v, %v", got, err)
+	}
+
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, variables); err != nil {
+		t.Fatal(err)
+	}
+	wantBody := `{"query":"query ($id:UUID!$orgId:UUID!){user(id: $id, orgId: $orgId){id,orgId,teamId}}","variables":{"id":"6ba7b810-9dad-11d1-80b4-00c04fd430c8","orgId":"6ba7b810-9dad-11d1-80b4-00c04fd430c8"}}` + "\n"
+	if gotBody != wantBody {
+		t.Errorf("got body: %v, want: %v", gotBody, wantBody)
+	}
+	if q.User.ID != id || uuid.UUID(q.User.OrgID).String() != "6ba7b811-9dad-11d1-80b4-00c04fd430c8" || q.User.TeamID != nil {
+		t.Errorf("got user: %+v", q.User)
+	}
+}
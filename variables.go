@@ -0,0 +1,286 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/darrensapalo/go-graphql-client/ident"
+)
+
+// GraphQLTyper is implemented by the types of variables that declare their GraphQL type,
+// e.g. a DateTime scalar backed by a struct, instead of having it inferred from the name of the Go type.
+// GraphQLType is called on the zero value of the type, and returns a named type, e.g. "DateTime";
+// whether the type is required is still inferred, pointers being optional.
+type GraphQLTyper interface {
+	GraphQLType() string
+}
+
+var graphQLTyperType = reflect.TypeOf((*GraphQLTyper)(nil)).Elem()
+
+// Scalar describes a custom scalar type of GraphQL servers, see RegisterScalar.
+type Scalar struct {
+	// Name is the name of the scalar type, e.g. "DateTime".
+	Name string
+
+	// Validate, if not nil, checks the values of variables before requests are sent,
+	// e.g. that a URI is absolute, so that invalid values fail early, without a round trip.
+	Validate func(value interface{}) error
+}
+
+// scalars maps Go types to the custom scalar types registered with RegisterScalar.
+var scalars sync.Map // map[reflect.Type]Scalar
+
+// RegisterScalar registers scalar as the GraphQL type of the Go type of example, e.g. time.Time{} for "DateTime",
+// for all clients, so that variables of the type are declared with the scalar type and validated by it.
+// As for inferred types, "!" is added to the scalar type of variables that aren't pointers.
+// It is meant to be called during initialization, e.g. in init functions.
+//
+// Clients of servers that name the scalar differently, e.g. "timestamptz" rather than "DateTime",
+// override its name with WithVariableTypes. The types of GraphQLTyper implementations take precedence
+// over registered scalars.
+//
+// The values of variables are validated, as are the elements of lists and the values pointed to by pointers,
+// but not the fields of input objects.
+// It panics if example is nil or scalar has no name.
+func RegisterScalar(example interface{}, scalar Scalar) {
+	t := reflect.TypeOf(example)
+	if t == nil || scalar.Name == "" {
+		panic(fmt.Sprintf("graphql: RegisterScalar of %T with name %q", example, scalar.Name))
+	}
+	scalars.Store(t, scalar)
+}
+
+// lookupScalar returns the custom scalar type registered for the Go type t, if any.
+func lookupScalar(t reflect.Type) (Scalar, bool) {
+	scalar, ok := scalars.Load(t)
+	if !ok {
+		return Scalar{}, false
+	}
+	return scalar.(Scalar), true
+}
+
+// validateVariables checks the values of variables with the Validate functions of the registered scalars,
+// and the IsValid methods of GraphQLEnum types.
+func validateVariables(variables map[string]interface{}) error {
+	for name, value := range variables {
+		if err := validateValue(reflect.ValueOf(value)); err != nil {
+			return fmt.Errorf("variable $%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validateValue checks v, and the elements of lists or values pointed to by v, see validateVariables.
+func validateValue(v reflect.Value) error {
+	if !v.IsValid() {
+		return nil
+	}
+	if o, ok := v.Interface().(Optional); ok {
+		return validateValue(reflect.ValueOf(o.value))
+	}
+	if v.Type().Implements(graphQLEnumType) && !(v.Kind() == reflect.Ptr && v.IsNil()) {
+		if !v.Interface().(GraphQLEnum).IsValid() {
+			return &UnknownEnumError{Value: v.Interface()}
+		}
+		return nil
+	}
+	if scalar, ok := lookupScalar(v.Type()); ok && scalar.Validate != nil {
+		if err := scalar.Validate(v.Interface()); err != nil {
+			return fmt.Errorf("invalid %s: %w", scalar.Name, err)
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return validateValue(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WithVariableTypes returns a copy of the client that declares the variables of the Go types in types with
+// the mapped GraphQL types, e.g. "UUID" or "[String!]", instead of inferring them, for types that can't
+// implement GraphQLTyper, such as those of other packages. As for inferred types, "!" is added to the mapped
+// types of variables that aren't pointers. The types are added to those of the client.
+//
+// By default, the GraphQL types of variables are inferred from their Go types: GraphQLTyper implementations
+// declare theirs, pointers are optional, slices are lists, Go's booleans, integers and floating-point numbers
+// are Boolean, Int and Float, strings are ID, the types registered with RegisterScalar are declared with their
+// scalar types, and other types are named as in Go, e.g. graphql.String is String.
+func (c *Client) WithVariableTypes(types map[reflect.Type]string) *Client {
+	c2 := c.clone()
+	c2.variableTypes = make(map[reflect.Type]string, len(c.variableTypes)+len(types))
+	for t, name := range c.variableTypes {
+		c2.variableTypes[t] = name
+	}
+	for t, name := range types {
+		c2.variableTypes[t] = name
+	}
+	return c2
+}
+
+// NullValue is a value sent as an explicit null, see Null.
+type NullValue struct {
+	// Type is the nullable GraphQL type of the value, e.g. "String", declared for variables in constructed documents.
+	Type string
+}
+
+// Null returns a value sent as an explicit null, of the nullable GraphQL type typ, e.g. "String" or "[ID!]",
+// as opposed to a variable or a key of an input object left out, which isn't sent at all. Servers treat the two
+// differently, e.g. partial-update mutations set fields to null, but leave absent fields unchanged:
+//
+//	variables := map[string]interface{}{
+//		"id":  graphql.ID("1"),
+//		"bio": graphql.Null("String"), // Clears the bio; leaving "bio" out would keep it.
+//	}
+//
+// typ is used to declare variables in constructed documents only, so it may be empty otherwise, e.g. for keys
+// of input objects. Typed nil pointers, e.g. (*graphql.String)(nil), are also sent as null.
+func Null(typ string) NullValue {
+	return NullValue{Type: typ}
+}
+
+// MarshalJSON implements json.Marshaler, encoding null.
+func (NullValue) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// VariablesOf returns the variables described by the fields of v, a struct or a pointer to a struct,
+// to pass to Query, Mutate and the other methods instead of a hand-written map, so that the names and types
+// of variables are checked by the compiler:
+//
+//	type RepositoryVariables struct {
+//		Owner graphql.String `graphql:"owner"`
+//		Name  graphql.String `graphql:"name"`
+//		First *graphql.Int   `graphql:"first"`
+//	}
+//
+//	err := client.Query(ctx, request, graphql.VariablesOf(RepositoryVariables{Owner: "golang", Name: "go"}))
+//
+// Each exported field is a variable, named by its graphql tag, or else its json tag, or else its name in
+// lowerCamelCase. Fields tagged "-" are left out, and embedded structs without tags have their fields inlined.
+// Fields with the omitempty option, e.g. `graphql:"bio,omitempty"`, are left out if they are nil or empty, as
+// encoding/json does, rather than sent as null, e.g. for partial updates; use Null to send explicit nulls.
+// Unset Optional fields are always left out.
+// The GraphQL types of the variables are inferred from the types of the fields as for the values
+// of maps, e.g. Go strings are ID, see WithVariableTypes, and their values are encoded as JSON as usual.
+//
+// It panics if v isn't a struct or a non-nil pointer to a struct.
+func VariablesOf(v interface{}) map[string]interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("graphql: VariablesOf of %T, want a struct or a pointer to a struct", v))
+	}
+	variables := make(map[string]interface{})
+	addVariables(variables, rv)
+	return variables
+}
+
+// addVariables adds the fields of the struct v to variables, see VariablesOf.
+func addVariables(variables map[string]interface{}, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := f.Tag.Lookup("graphql")
+		if !ok {
+			name, ok = f.Tag.Lookup("json")
+		}
+		var omitEmpty bool
+		if i := strings.Index(name, ","); i >= 0 {
+			omitEmpty = strings.Contains(name[i:]+",", ",omitempty,")
+			name = name[:i]
+		}
+		if name == excludeTag {
+			continue
+		}
+		if f.Anonymous && !ok {
+			embedded := v.Field(i)
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addVariables(variables, embedded)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = ident.ParseMixedCaps(f.Name).ToLowerCamelCase()
+		}
+		if omitEmpty && isEmptyValue(v.Field(i)) {
+			continue
+		}
+		if o, ok := v.Field(i).Interface().(Optional); ok && !o.IsSet() {
+			continue
+		}
+		variables[name] = v.Field(i).Interface()
+	}
+}
+
+// isEmptyValue reports whether v is empty, as for the omitempty option of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// constructOperation constructs the document of the operation op for v and variables,
+// with the configuration of the client.
+func (c *Client) constructOperation(op operationType, v interface{}, variables map[string]interface{}, name string) (string, error) {
+	switch op {
+	case queryOperation:
+		return constructOperation("query", v, variables, name, c)
+	case mutationOperation:
+		return constructOperation("mutation", v, variables, name, c)
+	}
+	return "", fmt.Errorf("unsupported operation type %v", op)
+}
+
+// variableTypeName returns the GraphQL name of the named type t.
+func variableTypeName(t reflect.Type) string {
+	if t.PkgPath() != "" {
+		return t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "Boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "Int"
+	case reflect.Float32, reflect.Float64:
+		return "Float"
+	case reflect.String:
+		// HACK: Workaround for https://github.com/shurcooL/githubv4/issues/12.
+		return "ID"
+	}
+	return t.Name()
+}
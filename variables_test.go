@@ -0,0 +1,105 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/darrensapalo/go-graphql-client"
+)
+
+type pageVariables struct {
+	First *graphql.Int `json:"first"`
+}
+
+func TestVariablesOf(t *testing.T) {
+	type repositoryVariables struct {
+		Owner      graphql.String `graphql:"owner"`
+		Name       graphql.String
+		IncludeAll graphql.Boolean
+		pageVariables
+		Cached bool `graphql:"-"`
+		secret string
+	}
+	variables := graphql.VariablesOf(&repositoryVariables{Owner: "golang", Name: "go", secret: "s"})
+	want := map[string]interface{}{
+		"owner":      graphql.String("golang"),
+		"name":       graphql.String("go"),
+		"includeAll": graphql.Boolean(false),
+		"first":      (*graphql.Int)(nil),
+	}
+	if !reflect.DeepEqual(variables, want) {
+		t.Errorf("got variables: %#v, want: %#v", variables, want)
+	}
+
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"repository": {"name": "go"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Repository struct {
+			Name graphql.String
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	query, err := client.ConstructQuery(&q, variables, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Query(context.Background(), graphql.ManualRequest{Query: query, Result: &q}, variables); err != nil {
+		t.Fatal(err)
+	}
+	wantBody := `{"query":"query ($first:Int$includeAll:Boolean!$name:String!$owner:String!){repository(owner: $owner, name: $name){name}}","variables":{"first":null,"includeAll":false,"name":"go","owner":"golang"}}` + "\n"
+	if gotBody != wantBody {
+		t.Errorf("got body: %v, want: %v", gotBody, wantBody)
+	}
+}
+
+func TestVariablesOf_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic, want a panic for a map")
+		}
+	}()
+	graphql.VariablesOf(map[string]interface{}{})
+}
+
+func TestNull(t *testing.T) {
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotBody = mustRead(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"updateUser": {"id": "1"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	type updateVariables struct {
+		ID       graphql.ID      `graphql:"id"`
+		Name     *graphql.String `graphql:"name,omitempty"`
+		Bio      interface{}     `graphql:"bio,omitempty"`
+		Location *graphql.String `graphql:"location"`
+	}
+	variables := graphql.VariablesOf(updateVariables{ID: "1", Bio: graphql.Null("String")})
+	var m struct {
+		UpdateUser struct {
+			ID graphql.ID
+		} `graphql:"updateUser(id: $id, bio: $bio, location: $location)"`
+	}
+	mutation, err := client.ConstructMutation(&m, variables, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Mutate(context.Background(), graphql.ManualRequest{Query: mutation, Result: &m}, variables); err != nil {
+		t.Fatal(err)
+	}
+	wantBody := `{"query":"mutation ($bio:String$id:ID!$location:String){updateUser(id: $id, bio: $bio, location: $location){id}}","variables":{"bio":null,"id":"1","location":null}}` + "\n"
+	if gotBody != wantBody {
+		t.Errorf("got body: %v, want: %v", gotBody, wantBody)
+	}
+}